@@ -14,6 +14,7 @@ import (
 	"github.com/bitcoinbrisbane/yachtlife/internal/api/routes"
 	"github.com/bitcoinbrisbane/yachtlife/internal/config"
 	"github.com/bitcoinbrisbane/yachtlife/internal/database"
+	"github.com/bitcoinbrisbane/yachtlife/internal/jobs"
 	"github.com/gin-gonic/gin"
 )
 
@@ -62,8 +63,13 @@ func main() {
 		})
 	})
 
-	// Setup API routes
-	routes.SetupRoutes(router, db, cfg)
+	// Setup API routes and background jobs
+	runner := jobs.NewRunner()
+	routes.SetupRoutes(router, db, cfg, runner)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	runner.Start(jobsCtx)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -90,6 +96,9 @@ func main() {
 
 	log.Println("🛑 Shutting down server...")
 
+	// Stop background jobs
+	stopJobs()
+
 	// Create shutdown context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
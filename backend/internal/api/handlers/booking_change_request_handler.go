@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateChangeRequestRequest represents the request body for asking to move
+// a booking to new dates
+type CreateChangeRequestRequest struct {
+	StartDate time.Time `json:"start_date" binding:"required"`
+	EndDate   time.Time `json:"end_date" binding:"required"`
+	Reason    string    `json:"reason"`
+}
+
+// DecideChangeRequestRequest represents the request body for approving or
+// rejecting a change request. A reason is required to reject.
+type DecideChangeRequestRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CreateChangeRequest asks a manager to move a booking to new dates
+// POST /api/v1/bookings/:id/change-requests
+func (h *BookingHandler) CreateChangeRequest(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	bookingID, ok := h.authorizeBookingAccess(c)
+	if !ok {
+		return
+	}
+
+	var req CreateChangeRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.bookingService.RequestChange(services.RequestChangeInput{
+		BookingID:   bookingID,
+		RequestedBy: uid,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		respondChangeRequestError(c, err, "Failed to create change request")
+		return
+	}
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// ListBookingChangeRequests returns the change request history of a booking
+// GET /api/v1/bookings/:id/change-requests
+func (h *BookingHandler) ListBookingChangeRequests(c *gin.Context) {
+	bookingID, ok := h.authorizeBookingAccess(c)
+	if !ok {
+		return
+	}
+
+	requests, err := h.bookingService.ChangeRequests(services.ChangeRequestsFilter{BookingID: &bookingID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// ListChangeRequests returns change requests for review (manager only)
+// GET /api/v1/booking-change-requests?status={status}&yacht_id={yacht_id}
+func (h *BookingHandler) ListChangeRequests(c *gin.Context) {
+	filter := services.ChangeRequestsFilter{
+		Status: models.BookingChangeRequestStatus(c.Query("status")),
+	}
+	if yachtIDStr := c.Query("yacht_id"); yachtIDStr != "" {
+		yachtID, err := uuid.Parse(yachtIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+			return
+		}
+		filter.YachtID = &yachtID
+	}
+
+	requests, err := h.bookingService.ChangeRequests(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// ApproveChangeRequest moves the booking to the requested dates (manager only)
+// POST /api/v1/booking-change-requests/:id/approve
+func (h *BookingHandler) ApproveChangeRequest(c *gin.Context) {
+	h.decideChangeRequest(c, h.bookingService.ApproveChange, "Failed to approve change request")
+}
+
+// RejectChangeRequest declines a change request (manager only)
+// POST /api/v1/booking-change-requests/:id/reject
+func (h *BookingHandler) RejectChangeRequest(c *gin.Context) {
+	h.decideChangeRequest(c, h.bookingService.RejectChange, "Failed to reject change request")
+}
+
+// decideChangeRequest records the caller's decision on the change request in
+// the URL
+func (h *BookingHandler) decideChangeRequest(c *gin.Context,
+	decide func(requestID, deciderID uuid.UUID, reason string) (*models.BookingChangeRequest, error), fallback string) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	requestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change request ID"})
+		return
+	}
+
+	var req DecideChangeRequestRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	request, err := decide(requestID, uid, req.Reason)
+	if err != nil {
+		respondChangeRequestError(c, err, fallback)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// respondChangeRequestError maps change request errors to HTTP responses
+func respondChangeRequestError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrChangeRequestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change request not found"})
+	case errors.Is(err, services.ErrChangeRequestPending),
+		errors.Is(err, services.ErrChangeRequestDecided):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDecisionReason):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		respondBookingError(c, err, fallback)
+	}
+}
@@ -1,17 +1,45 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type BookingHandler struct {
-	db *gorm.DB
+	db             *gorm.DB
+	bookingService *services.BookingService
+}
+
+// CreateBookingRequest represents the request body for creating a booking
+type CreateBookingRequest struct {
+	YachtID   string    `json:"yacht_id" binding:"required"`
+	StartDate time.Time `json:"start_date" binding:"required"`
+	EndDate   time.Time `json:"end_date" binding:"required"`
+	Notes     string    `json:"notes"`
+
+	// Only used when the request is collected for priority resolution
+	StandbyIfOutranked bool `json:"standby_if_outranked"`
+}
+
+// ResolveBookingRequestsRequest represents the request body for a priority
+// resolution run. Omitting until resolves every outstanding request.
+type ResolveBookingRequestsRequest struct {
+	Until *time.Time `json:"until"`
+}
+
+// UpdateBookingRequest represents the request body for amending a booking.
+// Omitted fields are left unchanged.
+type UpdateBookingRequest struct {
+	StartDate *time.Time `json:"start_date"`
+	EndDate   *time.Time `json:"end_date"`
+	Notes     *string    `json:"notes"`
 }
 
 // BookingDetailViewModel represents detailed booking information with logbook data
@@ -36,8 +64,11 @@ type LogbookSummary struct {
 	Notes                string    `json:"notes,omitempty"`
 }
 
-func NewBookingHandler(db *gorm.DB) *BookingHandler {
-	return &BookingHandler{db: db}
+func NewBookingHandler(db *gorm.DB, bookingService *services.BookingService) *BookingHandler {
+	return &BookingHandler{
+		db:             db,
+		bookingService: bookingService,
+	}
 }
 
 // ListBookings returns all bookings with optional filtering by yacht_id
@@ -178,3 +209,199 @@ func (h *BookingHandler) GetBookingDetail(c *gin.Context) {
 
 	c.JSON(http.StatusOK, viewModel)
 }
+
+// CreateBooking books a yacht for the authenticated syndicate member
+// POST /api/v1/bookings
+func (h *BookingHandler) CreateBooking(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	yachtID, err := uuid.Parse(req.YachtID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	booking, err := h.bookingService.Create(services.CreateBookingInput{
+		YachtID:   yachtID,
+		UserID:    uid,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Notes:     req.Notes,
+
+		StandbyIfOutranked: req.StandbyIfOutranked,
+	})
+	if err != nil {
+		respondBookingError(c, err, "Failed to create booking")
+		return
+	}
+
+	h.db.Preload("Yacht").Preload("User").First(booking, booking.ID)
+
+	c.JSON(http.StatusCreated, booking)
+}
+
+// UpdateBooking amends the dates or notes of a booking
+// PATCH /api/v1/bookings/:id
+func (h *BookingHandler) UpdateBooking(c *gin.Context) {
+	bookingID, ok := h.authorizeBookingAccess(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := h.bookingService.Update(bookingID, services.UpdateBookingInput{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Notes:     req.Notes,
+	})
+	if err != nil {
+		respondBookingError(c, err, "Failed to update booking")
+		return
+	}
+
+	h.db.Preload("Yacht").Preload("User").First(booking, booking.ID)
+
+	c.JSON(http.StatusOK, booking)
+}
+
+// CancelBooking cancels a pending or confirmed booking
+// POST /api/v1/bookings/:id/cancel
+func (h *BookingHandler) CancelBooking(c *gin.Context) {
+	bookingID, ok := h.authorizeBookingAccess(c)
+	if !ok {
+		return
+	}
+
+	booking, err := h.bookingService.Cancel(bookingID)
+	if err != nil {
+		respondBookingError(c, err, "Failed to cancel booking")
+		return
+	}
+
+	h.db.Preload("Yacht").Preload("User").First(booking, booking.ID)
+
+	c.JSON(http.StatusOK, booking)
+}
+
+// ResolveBookingRequests runs priority resolution over the collected booking
+// requests for a yacht (manager only)
+// POST /api/v1/yachts/:id/booking-requests/resolve
+func (h *BookingHandler) ResolveBookingRequests(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req ResolveBookingRequestsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var until time.Time
+	if req.Until != nil {
+		until = *req.Until
+	}
+
+	outcomes, err := h.bookingService.ResolveRequests(yachtID, until)
+	if err != nil {
+		if errors.Is(err, services.ErrYachtNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve booking requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"yacht_id": yachtID,
+		"outcomes": outcomes,
+	})
+}
+
+// authorizeBookingAccess checks the authenticated user owns the booking in the
+// URL or is a manager. It writes an error response and returns false otherwise.
+func (h *BookingHandler) authorizeBookingAccess(c *gin.Context) (uuid.UUID, bool) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return uuid.Nil, false
+	}
+
+	var booking models.Booking
+	if err := h.db.Select("id", "user_id").First(&booking, bookingID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			return uuid.Nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking"})
+		return uuid.Nil, false
+	}
+
+	if booking.UserID != uid && !isManagerRole(role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return uuid.Nil, false
+	}
+
+	return bookingID, true
+}
+
+// bookingErrors maps booking service errors to an HTTP status and a stable
+// code clients can use to explain a rejection
+var bookingErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{services.ErrBookingNotFound, http.StatusNotFound, "booking_not_found"},
+	{services.ErrNotSyndicateMember, http.StatusForbidden, "not_syndicate_member"},
+	{services.ErrOutsideBookingWindow, http.StatusForbidden, "outside_booking_window"},
+	{services.ErrBookingOverlap, http.StatusConflict, "booking_overlap"},
+	{services.ErrDuplicateRequest, http.StatusConflict, "duplicate_request"},
+	{services.ErrBookingNotModifiable, http.StatusConflict, "booking_not_modifiable"},
+	{services.ErrTripGapTooShort, http.StatusConflict, "trip_gap_too_short"},
+	{services.ErrTurnaroundClash, http.StatusConflict, "turnaround_clash"},
+	{services.ErrBookingInvalidDates, http.StatusBadRequest, "invalid_dates"},
+	{services.ErrBookingInPast, http.StatusBadRequest, "booking_in_past"},
+	{services.ErrBookingTooFarAhead, http.StatusBadRequest, "booking_too_far_ahead"},
+	{services.ErrBookingTooLong, http.StatusBadRequest, "trip_too_long"},
+}
+
+// respondBookingError maps booking errors to HTTP responses. Rejections
+// carry a code alongside the message.
+func respondBookingError(c *gin.Context, err error, fallback string) {
+	for _, e := range bookingErrors {
+		if !errors.Is(err, e.err) {
+			continue
+		}
+		message := err.Error()
+		if e.err == services.ErrBookingNotFound {
+			message = "Booking not found"
+		}
+		c.JSON(e.status, gin.H{"error": message, "code": e.code})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
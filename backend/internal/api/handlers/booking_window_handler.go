@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BookingWindowRequest represents the request body for creating or replacing
+// a booking window rule
+type BookingWindowRequest struct {
+	Name           string                          `json:"name" binding:"required"`
+	Dates          models.BookingWindowDates       `json:"dates"`
+	Eligibility    models.BookingWindowEligibility `json:"eligibility"`
+	OpensDaysAhead int                             `json:"opens_days_ahead"`
+	Active         *bool                           `json:"active"`
+}
+
+func (r BookingWindowRequest) input() services.BookingWindowInput {
+	in := services.BookingWindowInput{
+		Name:           r.Name,
+		Dates:          r.Dates,
+		Eligibility:    r.Eligibility,
+		OpensDaysAhead: r.OpensDaysAhead,
+		Active:         true,
+	}
+	if in.Dates == "" {
+		in.Dates = models.BookingWindowAllDates
+	}
+	if in.Eligibility == "" {
+		in.Eligibility = models.BookingWindowAllOwners
+	}
+	if r.Active != nil {
+		in.Active = *r.Active
+	}
+	return in
+}
+
+// ListBookingWindows returns a yacht's booking window rules
+// GET /api/v1/yachts/:id/booking-windows
+func (h *BookingHandler) ListBookingWindows(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	windows, err := h.bookingService.BookingWindows(yachtID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking windows"})
+		return
+	}
+
+	c.JSON(http.StatusOK, windows)
+}
+
+// CreateBookingWindow adds a booking window rule to a yacht (manager only)
+// POST /api/v1/yachts/:id/booking-windows
+func (h *BookingHandler) CreateBookingWindow(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req BookingWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := h.bookingService.CreateBookingWindow(yachtID, req.input())
+	if err != nil {
+		respondBookingWindowError(c, err, "Failed to create booking window")
+		return
+	}
+
+	c.JSON(http.StatusCreated, window)
+}
+
+// UpdateBookingWindow replaces a booking window rule (manager only)
+// PUT /api/v1/booking-windows/:id
+func (h *BookingHandler) UpdateBookingWindow(c *gin.Context) {
+	windowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking window ID"})
+		return
+	}
+
+	var req BookingWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := h.bookingService.UpdateBookingWindow(windowID, req.input())
+	if err != nil {
+		respondBookingWindowError(c, err, "Failed to update booking window")
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+// DeleteBookingWindow removes a booking window rule (manager only)
+// DELETE /api/v1/booking-windows/:id
+func (h *BookingHandler) DeleteBookingWindow(c *gin.Context) {
+	windowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking window ID"})
+		return
+	}
+
+	if err := h.bookingService.DeleteBookingWindow(windowID); err != nil {
+		respondBookingWindowError(c, err, "Failed to delete booking window")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetWindowAvailability returns which dates are open to the caller under the
+// yacht's booking windows and when the next closed dates open. Managers may
+// pass user_id to see an owner's view. Defaults to the next 365 days.
+// GET /api/v1/yachts/:id/booking-windows/availability?from={date}&to={date}&user_id={id}
+func (h *BookingHandler) GetWindowAvailability(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	if userIDStr := c.Query("user_id"); userIDStr != "" && isManagerRole(role) {
+		uid, err = uuid.Parse(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 365)
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+
+	availability, err := h.bookingService.WindowAvailability(yachtID, uid, from, to)
+	if err != nil {
+		respondBookingError(c, err, "Failed to calculate booking window availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// respondBookingWindowError maps booking window errors to HTTP responses
+func respondBookingWindowError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrBookingWindowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking window not found"})
+	case errors.Is(err, services.ErrYachtNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+	case errors.Is(err, services.ErrInvalidBookingWindow):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
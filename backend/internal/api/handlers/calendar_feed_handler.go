@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CalendarFeedHandler handles iCalendar feed requests
+type CalendarFeedHandler struct {
+	feedService *services.CalendarFeedService
+}
+
+// NewCalendarFeedHandler creates a new calendar feed handler
+func NewCalendarFeedHandler(feedService *services.CalendarFeedService) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		feedService: feedService,
+	}
+}
+
+// CreateCalendarFeedRequest represents the request body for a new feed
+type CreateCalendarFeedRequest struct {
+	Name string `json:"name"`
+}
+
+// CalendarFeedResponse is a newly issued feed with its subscription URLs
+type CalendarFeedResponse struct {
+	services.IssuedCalendarFeed
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcal_url"`
+}
+
+// ListCalendarFeeds returns the current user's calendar feeds
+// GET /api/v1/calendar-feeds
+func (h *CalendarFeedHandler) ListCalendarFeeds(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	feeds, err := h.feedService.Feeds(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar feeds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, feeds)
+}
+
+// CreateCalendarFeed issues a secret feed URL for the current user. The URL
+// is only returned once.
+// POST /api/v1/calendar-feeds
+func (h *CalendarFeedHandler) CreateCalendarFeed(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feed, err := h.feedService.Create(uid, req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	path := c.Request.Host + "/calendar/" + feed.Token + ".ics"
+
+	c.JSON(http.StatusCreated, CalendarFeedResponse{
+		IssuedCalendarFeed: *feed,
+		URL:                scheme + "://" + path,
+		WebcalURL:          "webcal://" + path,
+	})
+}
+
+// RevokeCalendarFeed stops one of the current user's feeds working
+// DELETE /api/v1/calendar-feeds/:id
+func (h *CalendarFeedHandler) RevokeCalendarFeed(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	feedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calendar feed ID"})
+		return
+	}
+
+	feed, err := h.feedService.Revoke(feedID, uid)
+	if err != nil {
+		if errors.Is(err, services.ErrCalendarFeedNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// GetCalendarFeed renders an iCalendar feed. The token in the URL is the
+// only credential, so calendar apps can subscribe without signing in.
+// GET /calendar/:token.ics
+func (h *CalendarFeedHandler) GetCalendarFeed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("token"), ".ics")
+	if !ok || token == "" {
+		c.String(http.StatusNotFound, "Not found")
+		return
+	}
+
+	body, err := h.feedService.Render(token, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrCalendarFeedNotFound) {
+			c.String(http.StatusNotFound, "Not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "Failed to render calendar")
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body)
+}
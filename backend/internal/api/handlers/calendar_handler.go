@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/holidays"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CalendarHandler handles holiday calendar requests
+type CalendarHandler struct {
+	calendarService *services.CalendarService
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(calendarService *services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// CalendarResponse represents a region's holidays for a year
+type CalendarResponse struct {
+	Region   string             `json:"region"`
+	Name     string             `json:"name"`
+	State    string             `json:"state,omitempty"`
+	Year     int                `json:"year"`
+	Notes    string             `json:"notes,omitempty"`
+	Holidays []holidays.Holiday `json:"holidays"`
+}
+
+// CustomHolidayRequest represents the request body for adding a custom holiday
+type CustomHolidayRequest struct {
+	Name      string             `json:"name" binding:"required"`
+	Kind      models.HolidayKind `json:"kind"`
+	StartDate time.Time          `json:"start_date" binding:"required"`
+	EndDate   time.Time          `json:"end_date" binding:"required"`
+}
+
+// ListRegions returns the bundled holiday regions and their states
+// GET /api/v1/calendars
+func (h *CalendarHandler) ListRegions(c *gin.Context) {
+	regions, err := holidays.Regions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load holiday calendars"})
+		return
+	}
+
+	c.JSON(http.StatusOK, regions)
+}
+
+// GetCalendar returns a region's public and school holidays for a year.
+// Without a state only national holidays are returned. Passing yacht_id adds
+// that yacht's custom dates.
+// GET /api/v1/calendars/:region?year={year}&state={state}&yacht_id={id}
+func (h *CalendarHandler) GetCalendar(c *gin.Context) {
+	input := services.CalendarInput{
+		Region: c.Param("region"),
+		State:  c.Query("state"),
+		Year:   time.Now().Year(),
+	}
+
+	if yearStr := c.Query("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		input.Year = year
+	}
+
+	if yachtIDStr := c.Query("yacht_id"); yachtIDStr != "" {
+		yachtID, err := uuid.Parse(yachtIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+			return
+		}
+		input.YachtID = &yachtID
+	}
+
+	region, list, err := h.calendarService.Calendar(input)
+	if err != nil {
+		respondCalendarError(c, err, "Failed to fetch holiday calendar")
+		return
+	}
+	if list == nil {
+		list = []holidays.Holiday{}
+	}
+
+	c.JSON(http.StatusOK, CalendarResponse{
+		Region:   region.Code,
+		Name:     region.Name,
+		State:    input.State,
+		Year:     input.Year,
+		Notes:    region.Notes,
+		Holidays: list,
+	})
+}
+
+// ListCustomHolidays returns the custom dates on a yacht's holiday calendar
+// GET /api/v1/yachts/:id/holidays
+func (h *CalendarHandler) ListCustomHolidays(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	custom, err := h.calendarService.CustomHolidays(yachtID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch custom holidays"})
+		return
+	}
+
+	c.JSON(http.StatusOK, custom)
+}
+
+// AddCustomHoliday adds a date range such as a regatta weekend to a yacht's
+// holiday calendar (manager only). Kind defaults to public.
+// POST /api/v1/yachts/:id/holidays
+func (h *CalendarHandler) AddCustomHoliday(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req CustomHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = models.HolidayKindPublic
+	}
+
+	holiday, err := h.calendarService.AddCustomHoliday(yachtID, uid, services.CustomHolidayInput{
+		Name:      req.Name,
+		Kind:      req.Kind,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+	})
+	if err != nil {
+		respondCalendarError(c, err, "Failed to add custom holiday")
+		return
+	}
+
+	c.JSON(http.StatusCreated, holiday)
+}
+
+// DeleteCustomHoliday removes a custom holiday (manager only)
+// DELETE /api/v1/holidays/:id
+func (h *CalendarHandler) DeleteCustomHoliday(c *gin.Context) {
+	holidayID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holiday ID"})
+		return
+	}
+
+	if err := h.calendarService.DeleteCustomHoliday(holidayID); err != nil {
+		respondCalendarError(c, err, "Failed to delete custom holiday")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondCalendarError maps calendar errors to HTTP responses
+func respondCalendarError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, holidays.ErrUnknownRegion),
+		errors.Is(err, holidays.ErrUnknownState):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrYachtNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+	case errors.Is(err, services.ErrCustomHolidayNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Custom holiday not found"})
+	case errors.Is(err, services.ErrInvalidCustomHoliday):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
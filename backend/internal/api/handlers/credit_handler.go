@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreditHandler handles fair share credit requests
+type CreditHandler struct {
+	db     *gorm.DB
+	ledger *fairshare.Ledger
+}
+
+// NewCreditHandler creates a new credit handler
+func NewCreditHandler(db *gorm.DB, ledger *fairshare.Ledger) *CreditHandler {
+	return &CreditHandler{
+		db:     db,
+		ledger: ledger,
+	}
+}
+
+// CreditsResponse represents credit balances for a yacht
+type CreditsResponse struct {
+	YachtID  uuid.UUID                  `json:"yacht_id"`
+	Year     int                        `json:"year"`
+	Balances []fairshare.Balance        `json:"balances"`
+	Entries  []models.CreditLedgerEntry `json:"entries"` // The caller's own ledger history
+}
+
+// GetCredits returns fair share credit balances for a yacht. Owners see their
+// own balance; managers see every shareholder.
+// GET /api/v1/yachts/:id/credits?year={year}
+func (h *CreditHandler) GetCredits(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	year := time.Now().Year()
+	if yearStr := c.Query("year"); yearStr != "" {
+		year, err = strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+	}
+
+	manager := isManagerRole(role)
+	if !manager {
+		var count int64
+		if err := h.db.Model(&models.SyndicateShare{}).
+			Where("yacht_id = ? AND user_id = ?", yachtID, uid).
+			Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shares"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+	}
+
+	balances, err := h.ledger.Balances(yachtID, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate credits"})
+		return
+	}
+
+	if !manager {
+		own := []fairshare.Balance{}
+		for _, b := range balances {
+			if b.UserID == uid {
+				own = append(own, b)
+			}
+		}
+		balances = own
+	}
+
+	entries, err := h.ledger.Entries(yachtID, uid, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credit history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CreditsResponse{
+		YachtID:  yachtID,
+		Year:     year,
+		Balances: balances,
+		Entries:  entries,
+	})
+}
@@ -5,17 +5,19 @@ import (
 	"time"
 
 	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type DashboardHandler struct {
-	db *gorm.DB
+	db               *gorm.DB
+	reportingService *services.ReportingService
 }
 
-func NewDashboardHandler(db *gorm.DB) *DashboardHandler {
-	return &DashboardHandler{db: db}
+func NewDashboardHandler(db *gorm.DB, reportingService *services.ReportingService) *DashboardHandler {
+	return &DashboardHandler{db: db, reportingService: reportingService}
 }
 
 func (h *DashboardHandler) GetDashboard(c *gin.Context) {
@@ -237,5 +239,23 @@ func (h *DashboardHandler) GetDashboard(c *gin.Context) {
 		})
 	}
 
+	// 7. Get fairness score over the last year
+	if report, err := h.reportingService.Fairness(yachtID, defaultFairnessWindowDays, now); err == nil {
+		for _, o := range report.Owners {
+			if o.UserID == uid {
+				viewModel.Fairness = &models.FairnessInfo{
+					Score:          o.Score,
+					Used:           o.Used,
+					Entitled:       o.Entitled,
+					Days:           o.Days,
+					PremiumDays:    o.PremiumDays,
+					SystemFairness: report.SystemFairness,
+					Gini:           report.Gini,
+					WindowDays:     defaultFairnessWindowDays,
+				}
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, viewModel)
 }
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DraftHandler handles snake draft requests
+type DraftHandler struct {
+	db           *gorm.DB
+	draftService *services.DraftService
+}
+
+// NewDraftHandler creates a new draft handler
+func NewDraftHandler(db *gorm.DB, draftService *services.DraftService) *DraftHandler {
+	return &DraftHandler{
+		db:           db,
+		draftService: draftService,
+	}
+}
+
+// DraftSlotRequest represents a manager-defined slot in a new draft
+type DraftSlotRequest struct {
+	Label     string    `json:"label"`
+	StartDate time.Time `json:"start_date" binding:"required"`
+	EndDate   time.Time `json:"end_date" binding:"required"`
+}
+
+// OpenDraftRequest represents the request body for opening a draft. Omitting
+// slots drafts every weekend and public holiday between the season dates.
+type OpenDraftRequest struct {
+	Season               int                `json:"season" binding:"required"`
+	Name                 string             `json:"name" binding:"required"`
+	SeasonStart          time.Time          `json:"season_start" binding:"required"`
+	SeasonEnd            time.Time          `json:"season_end" binding:"required"`
+	Rounds               int                `json:"rounds"`
+	PickTimeLimitMinutes int                `json:"pick_time_limit_minutes"`
+	Slots                []DraftSlotRequest `json:"slots"`
+}
+
+// DraftPickRequest represents the request body for making a pick
+type DraftPickRequest struct {
+	SlotID string `json:"slot_id" binding:"required"`
+}
+
+// DraftWishlistRequest represents an owner's slots in preference order
+type DraftWishlistRequest struct {
+	SlotIDs []string `json:"slot_ids"`
+}
+
+// DraftResponse represents a draft with the owner currently on the clock
+type DraftResponse struct {
+	*models.Draft
+	OnTheClock *uuid.UUID `json:"on_the_clock,omitempty"`
+}
+
+// OpenDraft opens a snake draft for a yacht's season (manager only)
+// POST /api/v1/yachts/:id/drafts
+func (h *DraftHandler) OpenDraft(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req OpenDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slots := make([]services.DraftSlotInput, len(req.Slots))
+	for i, s := range req.Slots {
+		slots[i] = services.DraftSlotInput{Label: s.Label, StartDate: s.StartDate, EndDate: s.EndDate}
+	}
+
+	draft, err := h.draftService.Open(services.OpenDraftInput{
+		YachtID:       yachtID,
+		CreatedBy:     uid,
+		Season:        req.Season,
+		Name:          req.Name,
+		SeasonStart:   req.SeasonStart,
+		SeasonEnd:     req.SeasonEnd,
+		Rounds:        req.Rounds,
+		PickTimeLimit: time.Duration(req.PickTimeLimitMinutes) * time.Minute,
+		Slots:         slots,
+	})
+	if err != nil {
+		respondDraftError(c, err, "Failed to open draft")
+		return
+	}
+
+	c.JSON(http.StatusCreated, newDraftResponse(draft))
+}
+
+// ListDrafts returns a yacht's drafts
+// GET /api/v1/yachts/:id/drafts
+func (h *DraftHandler) ListDrafts(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	if !isManagerRole(role) {
+		var count int64
+		if err := h.db.Model(&models.SyndicateShare{}).
+			Where("yacht_id = ? AND user_id = ?", yachtID, uid).
+			Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shares"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+	}
+
+	drafts, err := h.draftService.ListForYacht(yachtID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch drafts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, drafts)
+}
+
+// GetDraft returns a draft's order, slots and picks so far
+// GET /api/v1/drafts/:id
+func (h *DraftHandler) GetDraft(c *gin.Context) {
+	draftID, ok := h.authorizeDraftAccess(c)
+	if !ok {
+		return
+	}
+
+	draft, err := h.draftService.Get(draftID)
+	if err != nil {
+		respondDraftError(c, err, "Failed to fetch draft")
+		return
+	}
+
+	c.JSON(http.StatusOK, newDraftResponse(draft))
+}
+
+// MakePick picks a slot for the owner currently on the clock
+// POST /api/v1/drafts/:id/picks
+func (h *DraftHandler) MakePick(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid draft ID"})
+		return
+	}
+
+	var req DraftPickRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slotID, err := uuid.Parse(req.SlotID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slot ID"})
+		return
+	}
+
+	pick, err := h.draftService.Pick(draftID, uid, slotID)
+	if err != nil {
+		respondDraftError(c, err, "Failed to make pick")
+		return
+	}
+
+	c.JSON(http.StatusCreated, pick)
+}
+
+// GetWishlist returns the caller's wishlist for a draft
+// GET /api/v1/drafts/:id/wishlist
+func (h *DraftHandler) GetWishlist(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid draft ID"})
+		return
+	}
+
+	items, err := h.draftService.Wishlist(draftID, uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// SetWishlist replaces the caller's wishlist. When their pick clock runs out
+// the first available slot on the list is picked for them.
+// PUT /api/v1/drafts/:id/wishlist
+func (h *DraftHandler) SetWishlist(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid draft ID"})
+		return
+	}
+
+	var req DraftWishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slotIDs := make([]uuid.UUID, len(req.SlotIDs))
+	for i, s := range req.SlotIDs {
+		slotIDs[i], err = uuid.Parse(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slot ID"})
+			return
+		}
+	}
+
+	items, err := h.draftService.SetWishlist(draftID, uid, slotIDs)
+	if err != nil {
+		respondDraftError(c, err, "Failed to update wishlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// authorizeDraftAccess checks the authenticated user is taking part in the
+// draft in the URL or is a manager. It writes an error response and returns
+// false otherwise.
+func (h *DraftHandler) authorizeDraftAccess(c *gin.Context) (uuid.UUID, bool) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	draftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid draft ID"})
+		return uuid.Nil, false
+	}
+
+	if isManagerRole(role) {
+		return draftID, true
+	}
+
+	participant, err := h.draftService.IsParticipant(draftID, uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch draft"})
+		return uuid.Nil, false
+	}
+	if !participant {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return uuid.Nil, false
+	}
+
+	return draftID, true
+}
+
+func newDraftResponse(draft *models.Draft) DraftResponse {
+	resp := DraftResponse{Draft: draft}
+	if draft.Status == models.DraftStatusOpen && len(draft.Participants) > 0 {
+		userID := services.DraftOnTheClock(draft)
+		resp.OnTheClock = &userID
+	}
+	return resp
+}
+
+// respondDraftError maps draft service errors to HTTP responses
+func respondDraftError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrDraftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Draft not found"})
+	case errors.Is(err, services.ErrDraftSlotNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotYourPick),
+		errors.Is(err, services.ErrNotDraftParticipant),
+		errors.Is(err, services.ErrNotSyndicateMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDraftExists),
+		errors.Is(err, services.ErrDraftClosed),
+		errors.Is(err, services.ErrDraftDisabled),
+		errors.Is(err, services.ErrDraftSlotTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDraftInvalidSeason),
+		errors.Is(err, services.ErrNoDraftSlots),
+		errors.Is(err, services.ErrNoDraftParticipants),
+		errors.Is(err, services.ErrBookingInvalidDates):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DunningHandler handles requests for how a syndicate chases unpaid
+// invoices
+type DunningHandler struct {
+	dunningService *services.DunningService
+}
+
+// NewDunningHandler creates a new dunning handler
+func NewDunningHandler(dunningService *services.DunningService) *DunningHandler {
+	return &DunningHandler{
+		dunningService: dunningService,
+	}
+}
+
+// UpdateDunningPolicyRequest represents the request body for changing a
+// dunning policy. Omitted fields keep their current value.
+type UpdateDunningPolicyRequest struct {
+	Enabled        *bool                 `json:"enabled"`
+	Steps          *[]models.DunningStep `json:"steps"`
+	LateFeeAmount  *money.Money          `json:"late_fee_amount"`
+	LateFeePercent *float64              `json:"late_fee_percent"`
+}
+
+// GetDunningPolicy returns a yacht's reminder sequence and late fees, or the
+// defaults if none have been saved (manager only)
+// GET /api/v1/yachts/:id/dunning
+func (h *DunningHandler) GetDunningPolicy(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	policy, err := h.dunningService.Policy(yachtID)
+	if err != nil {
+		respondDunningError(c, err, "Failed to fetch dunning policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateDunningPolicy changes a yacht's reminder sequence and late fees
+// (manager only)
+// PUT /api/v1/yachts/:id/dunning
+func (h *DunningHandler) UpdateDunningPolicy(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req UpdateDunningPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.dunningService.SavePolicy(yachtID, uid, services.DunningPolicyInput{
+		Enabled:        req.Enabled,
+		Steps:          req.Steps,
+		LateFeeAmount:  req.LateFeeAmount,
+		LateFeePercent: req.LateFeePercent,
+	})
+	if err != nil {
+		respondDunningError(c, err, "Failed to update dunning policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// respondDunningError maps dunning errors to HTTP responses
+func respondDunningError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrYachtNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+	case errors.Is(err, services.ErrInvalidDunningPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ExchangeRateHandler handles the exchange rates reports convert with
+type ExchangeRateHandler struct {
+	exchangeRateService *services.ExchangeRateService
+}
+
+// NewExchangeRateHandler creates a new exchange rate handler
+func NewExchangeRateHandler(exchangeRateService *services.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		exchangeRateService: exchangeRateService,
+	}
+}
+
+// SetExchangeRateRequest represents the request body for an exchange rate
+type SetExchangeRateRequest struct {
+	Rate float64 `json:"rate" binding:"required"` // Units of the to currency for one of the from currency
+}
+
+// ListExchangeRates returns every exchange rate (manager only)
+// GET /api/v1/exchange-rates
+func (h *ExchangeRateHandler) ListExchangeRates(c *gin.Context) {
+	rates, err := h.exchangeRateService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetExchangeRate adds or replaces the rate from one currency to another
+// (manager only)
+// PUT /api/v1/exchange-rates/:from/:to
+func (h *ExchangeRateHandler) SetExchangeRate(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req SetExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.exchangeRateService.Set(c.Param("from"), c.Param("to"), req.Rate, uid)
+	if err != nil {
+		respondExchangeRateError(c, err, "Failed to save exchange rate")
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteExchangeRate removes the rate from one currency to another (manager
+// only)
+// DELETE /api/v1/exchange-rates/:from/:to
+func (h *ExchangeRateHandler) DeleteExchangeRate(c *gin.Context) {
+	if err := h.exchangeRateService.Delete(c.Param("from"), c.Param("to")); err != nil {
+		respondExchangeRateError(c, err, "Failed to delete exchange rate")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondExchangeRateError maps exchange rate errors to HTTP responses
+func respondExchangeRateError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrExchangeRateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+	case errors.Is(err, services.ErrInvalidExchangeRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
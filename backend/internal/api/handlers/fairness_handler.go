@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Rolling window used when no days parameter is given
+const defaultFairnessWindowDays = 365
+
+// FairnessHandler handles syndicate fairness reporting requests
+type FairnessHandler struct {
+	db               *gorm.DB
+	reportingService *services.ReportingService
+}
+
+// NewFairnessHandler creates a new fairness handler
+func NewFairnessHandler(db *gorm.DB, reportingService *services.ReportingService) *FairnessHandler {
+	return &FairnessHandler{
+		db:               db,
+		reportingService: reportingService,
+	}
+}
+
+// GetFairness returns each owner's fairness score and the syndicate's system
+// fairness and Gini coefficient over a rolling window. Current owners see
+// their own score alongside the syndicate measures; managers see every
+// shareholder.
+// GET /api/v1/yachts/:id/fairness?days={days}
+func (h *FairnessHandler) GetFairness(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	days := defaultFairnessWindowDays
+	if daysStr := c.Query("days"); daysStr != "" {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+			return
+		}
+	}
+
+	manager := isManagerRole(role)
+	if !manager {
+		var count int64
+		if err := h.db.Model(&models.SyndicateShare{}).Scopes(fairshare.HeldAt(time.Now())).
+			Where("yacht_id = ? AND user_id = ?", yachtID, uid).
+			Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shares"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+	}
+
+	report, err := h.reportingService.Fairness(yachtID, days, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrYachtNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+		case errors.Is(err, services.ErrInvalidReportWindow):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 1095"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate fairness"})
+		}
+		return
+	}
+
+	if !manager {
+		own := []fairshare.OwnerFairness{}
+		for _, o := range report.Owners {
+			if o.UserID == uid {
+				own = append(own, o)
+			}
+		}
+		report.Owners = own
+	}
+
+	c.JSON(http.StatusOK, report)
+}
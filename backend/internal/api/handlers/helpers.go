@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUser returns the authenticated user's ID and role from the request
+// context. It writes an error response and returns false if either is missing.
+func currentUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+
+	uid, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+		return uuid.Nil, "", false
+	}
+
+	role, _ := c.Get("user_role")
+	roleStr, _ := role.(string)
+
+	return uid, roleStr, true
+}
+
+// isManagerRole reports whether a role may act on other users' records
+func isManagerRole(role string) bool {
+	return role == string(models.RoleManager) || role == string(models.RoleAdmin)
+}
+
+// optionalUUID parses an optional UUID query parameter
+func optionalUUID(c *gin.Context, name string) (*uuid.UUID, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// optionalDate parses an optional YYYY-MM-DD query parameter
+func optionalDate(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// optionalMoney parses an optional decimal amount query parameter
+func optionalMoney(c *gin.Context, name string) (*money.Money, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	m, err := money.Parse(value, money.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// requestLocale returns the locale to write amounts for, from the request's
+// Accept-Language header
+func requestLocale(c *gin.Context) money.Locale {
+	return money.MatchLocale(c.GetHeader("Accept-Language"))
+}
@@ -1,25 +1,130 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type InvoiceHandler struct {
-	db *gorm.DB
+	db                  *gorm.DB
+	invoiceService      *services.InvoiceService
+	exchangeRateService *services.ExchangeRateService
 }
 
-func NewInvoiceHandler(db *gorm.DB) *InvoiceHandler {
-	return &InvoiceHandler{db: db}
+func NewInvoiceHandler(db *gorm.DB, invoiceService *services.InvoiceService, exchangeRateService *services.ExchangeRateService) *InvoiceHandler {
+	return &InvoiceHandler{db: db, invoiceService: invoiceService, exchangeRateService: exchangeRateService}
 }
 
-// GetInvoicesDashboard returns aggregated invoice data for the dashboard
+// ListInvoices returns a page of invoices. Owners only see their own
+// invoices; managers see every invoice in the fleet.
+//
+// Query parameters: yacht_id, user_id, status (comma separated),
+// due_from and due_to (YYYY-MM-DD, inclusive), currency, min_amount, max_amount,
+// sort (due_date, issued_date, amount, invoice_number or created_at,
+// prefixed with - for descending), limit and cursor (next_cursor of the
+// previous page).
+// GET /api/v1/invoices
+func (h *InvoiceHandler) ListInvoices(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var q services.InvoiceQuery
+	var err error
+
+	if q.YachtID, err = optionalUUID(c, "yacht_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht_id"})
+		return
+	}
+	if q.UserID, err = optionalUUID(c, "user_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+		return
+	}
+	if !isManagerRole(role) {
+		if q.UserID != nil && *q.UserID != uid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		q.UserID = &uid
+	}
+
+	if statuses := c.Query("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			switch st := models.InvoiceStatus(strings.TrimSpace(status)); st {
+			case models.InvoiceStatusDraft, models.InvoiceStatusSent, models.InvoiceStatusPartiallyPaid,
+				models.InvoiceStatusPaid, models.InvoiceStatusOverdue, models.InvoiceStatusCancelled:
+				q.Statuses = append(q.Statuses, st)
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + status})
+				return
+			}
+		}
+	}
+
+	if q.DueFrom, err = optionalDate(c, "due_from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_from, expected YYYY-MM-DD"})
+		return
+	}
+	if q.DueTo, err = optionalDate(c, "due_to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_to, expected YYYY-MM-DD"})
+		return
+	}
+	if currency := c.Query("currency"); currency != "" {
+		if q.Currency, err = money.ParseCurrency(currency); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency"})
+			return
+		}
+	}
+	if q.MinAmount, err = optionalMoney(c, "min_amount"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_amount"})
+		return
+	}
+	if q.MaxAmount, err = optionalMoney(c, "max_amount"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_amount"})
+		return
+	}
+
+	q.Sort = c.Query("sort")
+	if strings.HasPrefix(q.Sort, "-") {
+		q.Sort = q.Sort[1:]
+		q.Descending = true
+	}
+	q.Cursor = c.Query("cursor")
+	if limit := c.Query("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	page, err := h.invoiceService.List(q)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInvoiceQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetInvoicesDashboard returns aggregated invoice data for the dashboard.
+// Totals are in the currency query parameter, or the invoices' currency if
+// they share one, and amounts are written for the Accept-Language locale.
 func (h *InvoiceHandler) GetInvoicesDashboard(c *gin.Context) {
 	// Get authenticated user
 	userID, exists := c.Get("user_id")
@@ -59,8 +164,29 @@ func (h *InvoiceHandler) GetInvoicesDashboard(c *gin.Context) {
 		return
 	}
 
+	// Totals are reported in one currency, converting only at the rates
+	// managers have entered
+	currency, mixed := invoicesCurrency(invoices)
+	if code := c.Query("currency"); code != "" {
+		parsed, err := money.ParseCurrency(code)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency"})
+			return
+		}
+		mixed = mixed || parsed != currency
+		currency = parsed
+	}
+	var rates *money.Rates
+	if mixed {
+		var err error
+		if rates, err = h.exchangeRateService.Rates(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+			return
+		}
+	}
+
 	// Calculate statistics
-	stats := calculateInvoiceStats(invoices)
+	stats := calculateInvoiceStats(invoices, currency, rates)
 
 	// Build invoice info list
 	invoiceInfoList := make([]models.InvoiceInfo, len(invoices))
@@ -72,7 +198,10 @@ func (h *InvoiceHandler) GetInvoicesDashboard(c *gin.Context) {
 			ID:            inv.ID,
 			InvoiceNumber: inv.InvoiceNumber,
 			Description:   inv.Description,
+			Currency:      inv.Currency,
 			Amount:        inv.Amount,
+			AmountPaid:    inv.AmountPaid,
+			AmountDue:     inv.AmountDue,
 			DueDate:       inv.DueDate,
 			IssuedDate:    inv.IssuedDate,
 			Status:        string(inv.Status),
@@ -82,7 +211,7 @@ func (h *InvoiceHandler) GetInvoicesDashboard(c *gin.Context) {
 	}
 
 	// Get recent invoice activities (last 5)
-	activities := getRecentInvoiceActivities(h.db, uid, yachtID)
+	activities := getRecentInvoiceActivities(h.db, uid, yachtID, requestLocale(c))
 
 	// Build response
 	viewModel := models.InvoiceViewModel{
@@ -120,7 +249,9 @@ func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
 
 	// Fetch invoice from database
 	var invoice models.Invoice
-	if err := h.db.First(&invoice, invoiceID).Error; err != nil {
+	if err := h.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&invoice, invoiceID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
 		} else {
@@ -141,38 +272,184 @@ func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
 	c.JSON(http.StatusOK, invoice)
 }
 
-// calculateInvoiceStats computes statistics from a list of invoices
-func calculateInvoiceStats(invoices []models.Invoice) models.InvoiceStats {
-	stats := models.InvoiceStats{}
+// CreateCreditNoteRequest represents the request body for crediting an
+// invoice
+type CreateCreditNoteRequest struct {
+	Amount money.Money `json:"amount" binding:"required"`
+	Reason string      `json:"reason" binding:"required"`
+}
+
+// CreateCreditNote credits an amount against an invoice's balance (manager
+// only)
+// POST /api/v1/invoices/:id/credit-notes
+func (h *InvoiceHandler) CreateCreditNote(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	var req CreateCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.invoiceService.IssueCreditNote(invoiceID, uid, services.CreditNoteInput{
+		Amount: req.Amount,
+		Reason: req.Reason,
+	}, time.Now())
+	if err != nil {
+		respondCreditNoteError(c, err, "Failed to create credit note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// ListCreditNotes returns the credit notes on an invoice. Owners only see
+// those on their own invoices.
+// GET /api/v1/invoices/:id/credit-notes
+func (h *InvoiceHandler) ListCreditNotes(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
 
+	var ownerID *uuid.UUID
+	if !isManagerRole(role) {
+		ownerID = &uid
+	}
+
+	notes, err := h.invoiceService.CreditNotes(invoiceID, ownerID)
+	if err != nil {
+		respondCreditNoteError(c, err, "Failed to fetch credit notes")
+		return
+	}
+
+	c.JSON(http.StatusOK, notes)
+}
+
+// respondCreditNoteError maps credit note errors to HTTP responses
+func respondCreditNoteError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+	case errors.Is(err, services.ErrInvalidCreditNote):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// invoicesCurrency returns the currency invoices are in and whether some
+// are in others. An empty list is in the default currency.
+func invoicesCurrency(invoices []models.Invoice) (money.Currency, bool) {
+	if len(invoices) == 0 {
+		return money.DefaultCurrency, false
+	}
+	for _, inv := range invoices[1:] {
+		if inv.Currency != invoices[0].Currency {
+			return money.DefaultCurrency, true
+		}
+	}
+	return invoices[0].Currency, false
+}
+
+// calculateInvoiceStats computes statistics from a list of invoices. Totals
+// are kept for each currency, then converted to currency with rates; those
+// without a rate are listed as unconverted and left out.
+func calculateInvoiceStats(invoices []models.Invoice, currency money.Currency, rates *money.Rates) models.InvoiceStats {
+	stats := models.InvoiceStats{
+		Currency:         currency,
+		TotalOutstanding: money.Zero(currency),
+		TotalPaid:        money.Zero(currency),
+		ByCurrency:       []models.CurrencyTotals{},
+	}
+
+	totals := map[money.Currency]*models.CurrencyTotals{}
 	for _, inv := range invoices {
+		t, ok := totals[inv.Currency]
+		if !ok {
+			t = &models.CurrencyTotals{
+				Currency:    inv.Currency,
+				Outstanding: money.Zero(inv.Currency),
+				Paid:        money.Zero(inv.Currency),
+			}
+			totals[inv.Currency] = t
+		}
+
+		if inv.Status != models.InvoiceStatusDraft && inv.Status != models.InvoiceStatusCancelled {
+			t.Paid = t.Paid.Add(inv.AmountPaid)
+		}
+
 		switch inv.Status {
 		case models.InvoiceStatusPaid:
 			stats.PaidCount++
 		case models.InvoiceStatusDraft:
 			stats.DraftCount++
-		case models.InvoiceStatusSent:
+		case models.InvoiceStatusSent, models.InvoiceStatusPartiallyPaid:
+			if inv.Status == models.InvoiceStatusPartiallyPaid {
+				stats.PartiallyPaidCount++
+			}
 			// Check if sent invoice is overdue
 			if time.Now().After(inv.DueDate) {
 				// Treat as overdue, not pending
 				stats.OverdueCount++
-				stats.TotalOutstanding += inv.Amount
+				t.Outstanding = t.Outstanding.Add(inv.AmountDue)
 			} else {
 				// Not yet overdue - count as pending
 				stats.PendingCount++
-				stats.TotalOutstanding += inv.Amount
+				t.Outstanding = t.Outstanding.Add(inv.AmountDue)
 			}
 		case models.InvoiceStatusOverdue:
+			if inv.AmountPaid.IsPositive() {
+				stats.PartiallyPaidCount++
+			}
 			stats.OverdueCount++
-			stats.TotalOutstanding += inv.Amount
+			t.Outstanding = t.Outstanding.Add(inv.AmountDue)
+		}
+	}
+
+	codes := make([]string, 0, len(totals))
+	for code := range totals {
+		codes = append(codes, string(code))
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		t := totals[money.Currency(code)]
+		stats.ByCurrency = append(stats.ByCurrency, *t)
+
+		outstanding, err := rates.Convert(t.Outstanding, currency)
+		if err == nil {
+			var paid money.Money
+			if paid, err = rates.Convert(t.Paid, currency); err == nil {
+				stats.TotalOutstanding = stats.TotalOutstanding.Add(outstanding)
+				stats.TotalPaid = stats.TotalPaid.Add(paid)
+			}
+		}
+		if err != nil {
+			stats.Unconverted = append(stats.Unconverted, t.Currency)
 		}
 	}
 
 	return stats
 }
 
-// getRecentInvoiceActivities returns recent invoice-related activities
-func getRecentInvoiceActivities(db *gorm.DB, userID uuid.UUID, yachtID *uuid.UUID) []models.InvoiceActivity {
+// getRecentInvoiceActivities returns recent invoice-related activities, with
+// amounts written for the locale
+func getRecentInvoiceActivities(db *gorm.DB, userID uuid.UUID, yachtID *uuid.UUID, locale money.Locale) []models.InvoiceActivity {
 	// Get last 5 invoice updates
 	query := db.Where("user_id = ?", userID)
 	if yachtID != nil {
@@ -192,8 +469,13 @@ func getRecentInvoiceActivities(db *gorm.DB, userID uuid.UUID, yachtID *uuid.UUI
 		case models.InvoiceStatusPaid:
 			icon = "checkmark.circle.fill"
 			title = "Payment Received"
-			subtitle = inv.InvoiceNumber + " - $" + formatAmount(inv.Amount)
+			subtitle = inv.InvoiceNumber + " - " + inv.Amount.Format(locale)
 			color = "green"
+		case models.InvoiceStatusPartiallyPaid:
+			icon = "circle.lefthalf.filled"
+			title = "Part Payment Received"
+			subtitle = inv.InvoiceNumber + " - " + inv.AmountDue.Format(locale) + " remaining"
+			color = "teal"
 		case models.InvoiceStatusSent:
 			icon = "envelope.fill"
 			title = "Invoice Sent"
@@ -202,7 +484,7 @@ func getRecentInvoiceActivities(db *gorm.DB, userID uuid.UUID, yachtID *uuid.UUI
 		case models.InvoiceStatusOverdue:
 			icon = "exclamationmark.triangle.fill"
 			title = "Invoice Overdue"
-			subtitle = inv.InvoiceNumber + " - $" + formatAmount(inv.Amount)
+			subtitle = inv.InvoiceNumber + " - " + inv.AmountDue.Format(locale)
 			color = "orange"
 		case models.InvoiceStatusDraft:
 			icon = "doc.text"
@@ -224,8 +506,3 @@ func getRecentInvoiceActivities(db *gorm.DB, userID uuid.UUID, yachtID *uuid.UUI
 
 	return activities
 }
-
-// formatAmount formats a float as a currency string
-func formatAmount(amount float64) string {
-	return fmt.Sprintf("%.2f", amount)
-}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LevyHandler handles syndicate levy requests
+type LevyHandler struct {
+	levyService *services.LevyService
+}
+
+// NewLevyHandler creates a new levy handler
+func NewLevyHandler(levyService *services.LevyService) *LevyHandler {
+	return &LevyHandler{
+		levyService: levyService,
+	}
+}
+
+// CreateLevyRequest represents the request body for a levy
+type CreateLevyRequest struct {
+	Category    models.LevyCategory     `json:"category" binding:"required"`
+	Description string                  `json:"description"`
+	Amount      money.Money             `json:"amount" binding:"required"`
+	SplitRule   models.InvoiceSplitRule `json:"split_rule"`
+	PeriodStart time.Time               `json:"period_start" binding:"required"`
+	PeriodEnd   time.Time               `json:"period_end" binding:"required"`
+	IssuedDate  *time.Time              `json:"issued_date"`
+	DueDate     time.Time               `json:"due_date" binding:"required"`
+	Send        bool                    `json:"send"`
+}
+
+// ListLevies returns a yacht's levies and their invoices (manager only)
+// GET /api/v1/yachts/:id/levies
+func (h *LevyHandler) ListLevies(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	levies, err := h.levyService.Levies(yachtID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch levies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, levies)
+}
+
+// PreviewLevy shows how a cost would be split between the shareholders
+// without raising any invoices (manager only)
+// POST /api/v1/yachts/:id/levies/preview
+func (h *LevyHandler) PreviewLevy(c *gin.Context) {
+	h.handleLevy(c, true)
+}
+
+// CreateLevy splits a cost between the shareholders and raises an invoice
+// for each share (manager only)
+// POST /api/v1/yachts/:id/levies
+func (h *LevyHandler) CreateLevy(c *gin.Context) {
+	h.handleLevy(c, false)
+}
+
+func (h *LevyHandler) handleLevy(c *gin.Context, preview bool) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req CreateLevyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input := services.LevyInput{
+		Category:    req.Category,
+		Description: req.Description,
+		Amount:      req.Amount,
+		SplitRule:   req.SplitRule,
+		PeriodStart: req.PeriodStart,
+		PeriodEnd:   req.PeriodEnd,
+		DueDate:     req.DueDate,
+		Send:        req.Send,
+	}
+	if req.IssuedDate != nil {
+		input.IssuedDate = *req.IssuedDate
+	}
+
+	var plan *services.LevyPlan
+	if preview {
+		plan, err = h.levyService.Preview(yachtID, uid, input)
+	} else {
+		plan, err = h.levyService.Create(yachtID, uid, input)
+	}
+	if err != nil {
+		respondLevyError(c, err, "Failed to create levy")
+		return
+	}
+
+	status := http.StatusCreated
+	if preview {
+		status = http.StatusOK
+	}
+	c.JSON(status, plan)
+}
+
+// respondLevyError maps levy errors to HTTP responses
+func respondLevyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrYachtNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+	case errors.Is(err, services.ErrInvalidLevy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoShareholders):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/bitcoinbrisbane/yachtlife/internal/stripe"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PaymentHandler handles invoice payment requests
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+	publishableKey string
+}
+
+// NewPaymentHandler creates a new payment handler. publishableKey is passed
+// to the app to initialise Stripe.
+func NewPaymentHandler(paymentService *services.PaymentService, publishableKey string) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+		publishableKey: publishableKey,
+	}
+}
+
+// CreateInvoicePaymentRequest represents the request body for paying an
+// invoice
+type CreateInvoicePaymentRequest struct {
+	PaymentMethod models.PaymentMethod `json:"payment_method"` // card, apple_pay or google_pay; card if empty
+	Amount        *money.Money         `json:"amount"`         // Part payment; the whole balance if omitted
+}
+
+// CreateInvoicePaymentResponse is what the app needs to present Stripe's
+// payment sheet
+type CreateInvoicePaymentResponse struct {
+	services.PaymentCheckout
+	PublishableKey string `json:"publishable_key"`
+}
+
+// CreateInvoicePayment starts paying some or all of the remaining balance
+// of one of the user's invoices
+// POST /api/v1/invoices/:id/payments
+func (h *PaymentHandler) CreateInvoicePayment(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	var req CreateInvoicePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checkout, err := h.paymentService.StartPayment(c.Request.Context(), invoiceID, uid, req.PaymentMethod,
+		req.Amount, time.Now())
+	if err != nil {
+		respondPaymentError(c, err, "Failed to start payment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateInvoicePaymentResponse{
+		PaymentCheckout: *checkout,
+		PublishableKey:  h.publishableKey,
+	})
+}
+
+// RefundPaymentRequest represents the request body for refunding a payment
+type RefundPaymentRequest struct {
+	Amount *money.Money `json:"amount"` // Part refund; everything not yet refunded if omitted
+	Reason string       `json:"reason"`
+}
+
+// RefundPayment returns some or all of a payment to its owner (manager only)
+// POST /api/v1/payments/:id/refunds
+func (h *PaymentHandler) RefundPayment(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var req RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.paymentService.Refund(c.Request.Context(), paymentID, uid, services.RefundInput{
+		Amount: req.Amount,
+		Reason: req.Reason,
+	}, time.Now())
+	if err != nil {
+		respondPaymentError(c, err, "Failed to refund payment")
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// respondPaymentError maps payment errors to HTTP responses
+func respondPaymentError(c *gin.Context, err error, fallback string) {
+	var stripeErr *stripe.Error
+	switch {
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+	case errors.Is(err, services.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+	case errors.Is(err, services.ErrInvalidPayment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvoiceNotPayable), errors.Is(err, services.ErrPaymentNotRefundable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &stripeErr):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Payment provider error"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RecurringInvoiceHandler handles recurring invoice schedule requests
+type RecurringInvoiceHandler struct {
+	recurringService *services.RecurringInvoiceService
+}
+
+// NewRecurringInvoiceHandler creates a new recurring invoice handler
+func NewRecurringInvoiceHandler(recurringService *services.RecurringInvoiceService) *RecurringInvoiceHandler {
+	return &RecurringInvoiceHandler{
+		recurringService: recurringService,
+	}
+}
+
+// CreateRecurringInvoiceRequest represents the request body for a schedule
+type CreateRecurringInvoiceRequest struct {
+	Category    models.LevyCategory     `json:"category" binding:"required"`
+	Description string                  `json:"description" binding:"required"`
+	Amount      money.Money             `json:"amount" binding:"required"`
+	Cadence     models.InvoiceCadence   `json:"cadence" binding:"required"`
+	SplitRule   models.InvoiceSplitRule `json:"split_rule"`
+	DueDays     int                     `json:"due_days"`
+	Send        bool                    `json:"send"`
+	StartDate   time.Time               `json:"start_date" binding:"required"`
+	EndDate     *time.Time              `json:"end_date"`
+}
+
+// UpdateRecurringInvoiceRequest represents the request body for changing a
+// schedule. Omitted fields are left unchanged.
+type UpdateRecurringInvoiceRequest struct {
+	Description *string      `json:"description"`
+	Amount      *money.Money `json:"amount"`
+	DueDays     *int         `json:"due_days"`
+	Send        *bool        `json:"send"`
+	EndDate     *time.Time   `json:"end_date"`
+	Active      *bool        `json:"active"`
+}
+
+// ListRecurringInvoices returns a yacht's recurring invoice schedules
+// (manager only)
+// GET /api/v1/yachts/:id/recurring-invoices
+func (h *RecurringInvoiceHandler) ListRecurringInvoices(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	schedules, err := h.recurringService.Schedules(yachtID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateRecurringInvoice schedules a cost to be levied every period
+// (manager only)
+// POST /api/v1/yachts/:id/recurring-invoices
+func (h *RecurringInvoiceHandler) CreateRecurringInvoice(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req CreateRecurringInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.recurringService.Create(yachtID, uid, services.RecurringInvoiceInput{
+		Category:    req.Category,
+		Description: req.Description,
+		Amount:      req.Amount,
+		Cadence:     req.Cadence,
+		SplitRule:   req.SplitRule,
+		DueDays:     req.DueDays,
+		Send:        req.Send,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+	}, time.Now())
+	if err != nil {
+		respondRecurringInvoiceError(c, err, "Failed to create recurring invoice")
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// UpdateRecurringInvoice changes, pauses or resumes a schedule (manager only)
+// PATCH /api/v1/recurring-invoices/:id
+func (h *RecurringInvoiceHandler) UpdateRecurringInvoice(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring invoice ID"})
+		return
+	}
+
+	var req UpdateRecurringInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.recurringService.Update(scheduleID, services.RecurringInvoiceUpdate{
+		Description: req.Description,
+		Amount:      req.Amount,
+		DueDays:     req.DueDays,
+		Send:        req.Send,
+		EndDate:     req.EndDate,
+		Active:      req.Active,
+	}, time.Now())
+	if err != nil {
+		respondRecurringInvoiceError(c, err, "Failed to update recurring invoice")
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// respondRecurringInvoiceError maps recurring invoice errors to HTTP responses
+func respondRecurringInvoiceError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrYachtNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+	case errors.Is(err, services.ErrRecurringInvoiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring invoice not found"})
+	case errors.Is(err, services.ErrInvalidRecurringInvoice):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SettingsHandler handles per-syndicate fair share settings requests
+type SettingsHandler struct {
+	settingsService *services.SettingsService
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settingsService *services.SettingsService) *SettingsHandler {
+	return &SettingsHandler{
+		settingsService: settingsService,
+	}
+}
+
+// UpdateSettingsRequest represents the request body for changing syndicate
+// settings. Omitted fields keep their current value.
+type UpdateSettingsRequest struct {
+	StandardWeekdayWeight *float64            `json:"standard_weekday_weight"`
+	PeakWeekdayWeight     *float64            `json:"peak_weekday_weight"`
+	WeekendWeight         *float64            `json:"weekend_weight"`
+	PremiumWeekendWeight  *float64            `json:"premium_weekend_weight"`
+	HolidayWeight         *float64            `json:"holiday_weight"`
+	Seasons               *[]fairshare.Season `json:"seasons"`
+	RolloverDecay         *float64            `json:"rollover_decay"`
+	HolidayRegion         *string             `json:"holiday_region"`
+	HolidayState          *string             `json:"holiday_state"`
+
+	CollectionLeadDays     *int     `json:"collection_lead_days"`
+	MaxAdvanceDays         *int     `json:"max_advance_days"`
+	MaxConsecutiveDays     *int     `json:"max_consecutive_days"`
+	MinTripGapDays         *int     `json:"min_trip_gap_days"`
+	TurnaroundHours        *int     `json:"turnaround_hours"`
+	CancellationFeePercent *float64 `json:"cancellation_fee_percent"`
+	LateCancellationDays   *int     `json:"late_cancellation_days"`
+
+	DraftEnabled      *bool `json:"draft_enabled"`
+	DraftPickHours    *int  `json:"draft_pick_hours"`
+	StandbyOfferHours *int  `json:"standby_offer_hours"`
+	SwapLockDays      *int  `json:"swap_lock_days"`
+}
+
+// GetSettings returns a yacht's syndicate settings, or the defaults if none
+// have been saved (manager only)
+// GET /api/v1/yachts/:id/settings
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	settings, err := h.settingsService.Get(yachtID)
+	if err != nil {
+		respondSettingsError(c, err, "Failed to fetch settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings changes a yacht's syndicate settings (manager only)
+// PUT /api/v1/yachts/:id/settings
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.settingsService.Save(yachtID, uid, services.SettingsInput{
+		StandardWeekdayWeight:  req.StandardWeekdayWeight,
+		PeakWeekdayWeight:      req.PeakWeekdayWeight,
+		WeekendWeight:          req.WeekendWeight,
+		PremiumWeekendWeight:   req.PremiumWeekendWeight,
+		HolidayWeight:          req.HolidayWeight,
+		Seasons:                req.Seasons,
+		RolloverDecay:          req.RolloverDecay,
+		HolidayRegion:          req.HolidayRegion,
+		HolidayState:           req.HolidayState,
+		CollectionLeadDays:     req.CollectionLeadDays,
+		MaxAdvanceDays:         req.MaxAdvanceDays,
+		MaxConsecutiveDays:     req.MaxConsecutiveDays,
+		MinTripGapDays:         req.MinTripGapDays,
+		TurnaroundHours:        req.TurnaroundHours,
+		CancellationFeePercent: req.CancellationFeePercent,
+		LateCancellationDays:   req.LateCancellationDays,
+		DraftEnabled:           req.DraftEnabled,
+		DraftPickHours:         req.DraftPickHours,
+		StandbyOfferHours:      req.StandbyOfferHours,
+		SwapLockDays:           req.SwapLockDays,
+	})
+	if err != nil {
+		respondSettingsError(c, err, "Failed to update settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// ResetSettings discards a yacht's saved settings so the defaults apply
+// again (manager only)
+// DELETE /api/v1/yachts/:id/settings
+func (h *SettingsHandler) ResetSettings(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	if err := h.settingsService.Reset(yachtID); err != nil {
+		respondSettingsError(c, err, "Failed to reset settings")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondSettingsError maps settings errors to HTTP responses
+func respondSettingsError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrYachtNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+	case errors.Is(err, fairshare.ErrInvalidConfig):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ShareHandler handles syndicate share membership requests
+type ShareHandler struct {
+	shareService *services.ShareService
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(shareService *services.ShareService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+	}
+}
+
+// CreateShareRequest represents the request body for adding a share
+type CreateShareRequest struct {
+	UserID          uuid.UUID  `json:"user_id" binding:"required"`
+	SharePercentage float64    `json:"share_percentage" binding:"required"`
+	DaysPerYear     int        `json:"days_per_year"`
+	JoinedDate      *time.Time `json:"joined_date"`
+}
+
+// ExitShareRequest represents the request body for ending a share
+type ExitShareRequest struct {
+	ExitDate time.Time `json:"exit_date" binding:"required"`
+}
+
+// ListShares returns a yacht's shares with their pro-rated entitlement for a
+// year, defaulting to the current year (manager only)
+// GET /api/v1/yachts/:id/shares?year={year}
+func (h *ShareHandler) ListShares(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	year := time.Now().Year()
+	if yearStr := c.Query("year"); yearStr != "" {
+		year, err = strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+	}
+
+	shares, err := h.shareService.Shares(yachtID, year)
+	if err != nil {
+		respondShareError(c, err, "Failed to fetch shares")
+		return
+	}
+
+	c.JSON(http.StatusOK, shares)
+}
+
+// CreateShare adds an owner's share to a yacht, joining today unless a
+// joined_date is given (manager only)
+// POST /api/v1/yachts/:id/shares
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	joined := time.Now()
+	if req.JoinedDate != nil {
+		joined = *req.JoinedDate
+	}
+
+	share, err := h.shareService.Create(yachtID, services.ShareInput{
+		UserID:          req.UserID,
+		SharePercentage: req.SharePercentage,
+		DaysPerYear:     req.DaysPerYear,
+		JoinedDate:      joined,
+	})
+	if err != nil {
+		respondShareError(c, err, "Failed to create share")
+		return
+	}
+
+	c.JSON(http.StatusCreated, share)
+}
+
+// ExitShare ends a share, pro-rating the owner's credits and releasing their
+// bookings from the exit date (manager only)
+// POST /api/v1/shares/:id/exit
+func (h *ShareHandler) ExitShare(c *gin.Context) {
+	shareID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share ID"})
+		return
+	}
+
+	var req ExitShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.shareService.Exit(shareID, req.ExitDate)
+	if err != nil {
+		respondShareError(c, err, "Failed to end share")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// respondShareError maps share errors to HTTP responses
+func respondShareError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrYachtNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+	case errors.Is(err, services.ErrShareNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+	case errors.Is(err, services.ErrShareEnded):
+		c.JSON(http.StatusConflict, gin.H{"error": "Share has already ended"})
+	case errors.Is(err, services.ErrInvalidShare):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StandbyHandler handles standby list and standby offer requests
+type StandbyHandler struct {
+	db             *gorm.DB
+	bookingService *services.BookingService
+}
+
+// NewStandbyHandler creates a new standby handler
+func NewStandbyHandler(db *gorm.DB, bookingService *services.BookingService) *StandbyHandler {
+	return &StandbyHandler{
+		db:             db,
+		bookingService: bookingService,
+	}
+}
+
+// JoinStandbyRequest represents the request body for joining a standby list
+type JoinStandbyRequest struct {
+	StartDate time.Time `json:"start_date" binding:"required"`
+	EndDate   time.Time `json:"end_date" binding:"required"`
+	Notes     string    `json:"notes"`
+}
+
+// JoinStandby puts the caller on standby for dates on a yacht
+// POST /api/v1/yachts/:id/standby
+func (h *StandbyHandler) JoinStandby(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req JoinStandbyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.bookingService.JoinStandby(services.JoinStandbyInput{
+		YachtID:   yachtID,
+		UserID:    uid,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Notes:     req.Notes,
+	})
+	if err != nil {
+		respondStandbyError(c, err, "Failed to join standby")
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// ListStandby returns a yacht's standby list. Owners see their own entries;
+// managers see everyone's.
+// GET /api/v1/yachts/:id/standby
+func (h *StandbyHandler) ListStandby(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var owner *uuid.UUID
+	if !isManagerRole(role) {
+		owner = &uid
+	}
+
+	entries, err := h.bookingService.StandbyEntries(yachtID, owner)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch standby list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// ListYachtOffers returns every standby offer made for a yacht and its
+// outcome (manager only)
+// GET /api/v1/yachts/:id/standby/offers?status={status}
+func (h *StandbyHandler) ListYachtOffers(c *gin.Context) {
+	yachtID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	offers, err := h.bookingService.StandbyOffers(services.StandbyOffersFilter{
+		YachtID: &yachtID,
+		Status:  models.StandbyOfferStatus(c.Query("status")),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch standby offers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, offers)
+}
+
+// WithdrawStandby takes an entry off the standby list
+// DELETE /api/v1/standby/:id
+func (h *StandbyHandler) WithdrawStandby(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	entryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid standby entry ID"})
+		return
+	}
+
+	entry, err := h.bookingService.StandbyEntry(entryID)
+	if err != nil {
+		respondStandbyError(c, err, "Failed to fetch standby entry")
+		return
+	}
+	if entry.UserID != uid && !isManagerRole(role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	entry, err = h.bookingService.WithdrawStandby(entryID)
+	if err != nil {
+		respondStandbyError(c, err, "Failed to withdraw from standby")
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// ListMyOffers returns the standby offers made to the caller
+// GET /api/v1/standby/offers?status={status}
+func (h *StandbyHandler) ListMyOffers(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	offers, err := h.bookingService.StandbyOffers(services.StandbyOffersFilter{
+		UserID: &uid,
+		Status: models.StandbyOfferStatus(c.Query("status")),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch standby offers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, offers)
+}
+
+// AcceptOffer books the dates in a standby offer for the caller
+// POST /api/v1/standby/offers/:id/accept
+func (h *StandbyHandler) AcceptOffer(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	offerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
+		return
+	}
+
+	booking, err := h.bookingService.AcceptStandbyOffer(offerID, uid)
+	if err != nil {
+		respondStandbyError(c, err, "Failed to accept standby offer")
+		return
+	}
+
+	h.db.Preload("Yacht").Preload("User").First(booking, booking.ID)
+
+	c.JSON(http.StatusCreated, booking)
+}
+
+// DeclineOffer turns down a standby offer so it passes to the next owner
+// POST /api/v1/standby/offers/:id/decline
+func (h *StandbyHandler) DeclineOffer(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	offerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
+		return
+	}
+
+	offer, err := h.bookingService.DeclineStandbyOffer(offerID, uid)
+	if err != nil {
+		respondStandbyError(c, err, "Failed to decline standby offer")
+		return
+	}
+
+	c.JSON(http.StatusOK, offer)
+}
+
+// respondStandbyError maps standby errors to HTTP responses
+func respondStandbyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrStandbyEntryNotFound),
+		errors.Is(err, services.ErrStandbyOfferNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrStandbyNotWaiting),
+		errors.Is(err, services.ErrStandbyOfferClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrStandbyOfferExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		respondBookingError(c, err, fallback)
+	}
+}
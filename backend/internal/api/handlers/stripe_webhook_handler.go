@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/bitcoinbrisbane/yachtlife/internal/stripe"
+	"github.com/gin-gonic/gin"
+)
+
+// Largest webhook delivery accepted
+const maxStripeWebhookBytes = 1 << 20
+
+// StripeWebhookHandler handles Stripe webhook deliveries
+type StripeWebhookHandler struct {
+	webhookService *services.StripeWebhookService
+}
+
+// NewStripeWebhookHandler creates a new Stripe webhook handler
+func NewStripeWebhookHandler(webhookService *services.StripeWebhookService) *StripeWebhookHandler {
+	return &StripeWebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// ReceiveStripeWebhook verifies and applies a delivery. Stripe retries
+// anything but a 2xx, so a failure to apply the event returns a 500, and an
+// event that arrived ahead of the one it follows a 409.
+// POST /api/v1/webhooks/stripe
+func (h *StripeWebhookHandler) ReceiveStripeWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxStripeWebhookBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+
+	_, err = h.webhookService.Receive(body, c.GetHeader(stripe.SignatureHeader), time.Now())
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"received": true})
+	case errors.Is(err, services.ErrInvalidStripeSignature):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signature"})
+	case errors.Is(err, services.ErrInvalidStripeWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+	case errors.Is(err, services.ErrStripeEventEarly):
+		// Stripe retries the event once the one it follows has arrived
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process event"})
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SwapHandler handles owner-to-owner swap requests
+type SwapHandler struct {
+	swapService *services.SwapService
+}
+
+// NewSwapHandler creates a new swap handler
+func NewSwapHandler(swapService *services.SwapService) *SwapHandler {
+	return &SwapHandler{
+		swapService: swapService,
+	}
+}
+
+// ProposeSwapRequest represents the request body for proposing a swap
+type ProposeSwapRequest struct {
+	OfferedBookingID   string `json:"offered_booking_id" binding:"required"`
+	RequestedBookingID string `json:"requested_booking_id" binding:"required"`
+	Message            string `json:"message"`
+}
+
+// ProposeSwap offers one of the caller's bookings for another owner's booking
+// POST /api/v1/swaps
+func (h *SwapHandler) ProposeSwap(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req ProposeSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	offeredID, err := uuid.Parse(req.OfferedBookingID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offered booking ID"})
+		return
+	}
+	requestedID, err := uuid.Parse(req.RequestedBookingID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid requested booking ID"})
+		return
+	}
+
+	swap, err := h.swapService.Propose(services.ProposeSwapInput{
+		ProposerID:         uid,
+		OfferedBookingID:   offeredID,
+		RequestedBookingID: requestedID,
+		Message:            req.Message,
+	})
+	if err != nil {
+		respondSwapError(c, err, "Failed to propose swap")
+		return
+	}
+
+	c.JSON(http.StatusCreated, swap)
+}
+
+// ListSwaps returns swap offers. Owners see offers they made or received;
+// managers see every offer and may filter by yacht.
+// GET /api/v1/swaps?status={status}&yacht_id={yacht_id}
+func (h *SwapHandler) ListSwaps(c *gin.Context) {
+	uid, role, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	filter := services.SwapOffersFilter{Status: models.SwapOfferStatus(c.Query("status"))}
+	if !isManagerRole(role) {
+		filter.UserID = &uid
+	}
+	if yachtIDStr := c.Query("yacht_id"); yachtIDStr != "" {
+		yachtID, err := uuid.Parse(yachtIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+			return
+		}
+		filter.YachtID = &yachtID
+	}
+
+	swaps, err := h.swapService.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch swap offers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, swaps)
+}
+
+// AcceptSwap exchanges the bookings in a swap offer (recipient only)
+// POST /api/v1/swaps/:id/accept
+func (h *SwapHandler) AcceptSwap(c *gin.Context) {
+	h.respond(c, h.swapService.Accept, "Failed to accept swap")
+}
+
+// DeclineSwap turns down a swap offer (recipient only)
+// POST /api/v1/swaps/:id/decline
+func (h *SwapHandler) DeclineSwap(c *gin.Context) {
+	h.respond(c, h.swapService.Decline, "Failed to decline swap")
+}
+
+// WithdrawSwap cancels a swap offer before it is answered (proposer only)
+// POST /api/v1/swaps/:id/withdraw
+func (h *SwapHandler) WithdrawSwap(c *gin.Context) {
+	h.respond(c, h.swapService.Withdraw, "Failed to withdraw swap")
+}
+
+// respond runs a swap action for the caller on the swap in the URL
+func (h *SwapHandler) respond(c *gin.Context, action func(swapID, userID uuid.UUID) (*models.SwapOffer, error), fallback string) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	swapID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid swap ID"})
+		return
+	}
+
+	swap, err := action(swapID, uid)
+	if err != nil {
+		respondSwapError(c, err, fallback)
+		return
+	}
+
+	c.JSON(http.StatusOK, swap)
+}
+
+// respondSwapError maps swap service errors to HTTP responses
+func respondSwapError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrSwapNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Swap offer not found"})
+	case errors.Is(err, services.ErrSwapNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSwapClosed),
+		errors.Is(err, services.ErrSwapExists),
+		errors.Is(err, services.ErrSwapVoid),
+		errors.Is(err, services.ErrSwapRevalued),
+		errors.Is(err, services.ErrSwapLocked),
+		errors.Is(err, services.ErrSwapNotSwappable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSwapDifferentYacht):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		respondBookingError(c, err, fallback)
+	}
+}
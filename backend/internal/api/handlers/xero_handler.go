@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// XeroHandler handles the Xero connection and sync requests
+type XeroHandler struct {
+	syncService *services.XeroSyncService
+}
+
+// NewXeroHandler creates a new Xero handler
+func NewXeroHandler(syncService *services.XeroSyncService) *XeroHandler {
+	return &XeroHandler{
+		syncService: syncService,
+	}
+}
+
+// ConnectXero returns the Xero URL to approve the connection at (manager
+// only)
+// GET /api/v1/xero/connect
+func (h *XeroHandler) ConnectXero(c *gin.Context) {
+	uid, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": h.syncService.ConnectURL(uid, time.Now())})
+}
+
+// XeroCallback completes the connection when Xero redirects back. The
+// signed state parameter stands in for the manager's session.
+// GET /api/v1/xero/callback
+func (h *XeroHandler) XeroCallback(c *gin.Context) {
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Xero connection was not approved: " + reason})
+		return
+	}
+
+	conn, err := h.syncService.Connect(c.Request.Context(), c.Query("code"), c.Query("state"), time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidXeroState) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired connection request"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect to Xero"})
+		return
+	}
+
+	c.JSON(http.StatusOK, conn)
+}
+
+// GetXeroStatus returns the connection and the records waiting to sync
+// (manager only)
+// GET /api/v1/xero/status
+func (h *XeroHandler) GetXeroStatus(c *gin.Context) {
+	status, err := h.syncService.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Xero status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SyncXero pushes pending invoices and payments to Xero and pulls status
+// changes now rather than waiting for the scheduler (manager only)
+// POST /api/v1/invoices/sync
+func (h *XeroHandler) SyncXero(c *gin.Context) {
+	result, err := h.syncService.Sync(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrXeroNotConnected) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Xero is not connected"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Xero sync failed: " + err.Error(), "result": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/bitcoinbrisbane/yachtlife/internal/xero"
+	"github.com/gin-gonic/gin"
+)
+
+// Largest webhook delivery accepted
+const maxXeroWebhookBytes = 1 << 20
+
+// XeroWebhookHandler handles Xero webhook deliveries
+type XeroWebhookHandler struct {
+	webhookService *services.XeroWebhookService
+}
+
+// NewXeroWebhookHandler creates a new Xero webhook handler
+func NewXeroWebhookHandler(webhookService *services.XeroWebhookService) *XeroWebhookHandler {
+	return &XeroWebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// ReceiveXeroWebhook verifies and queues a delivery. Xero expects an empty
+// 200 for a correctly signed delivery and an empty 401 otherwise, which is
+// also how it checks the endpoint before enabling it.
+// POST /api/v1/webhooks/xero
+func (h *XeroWebhookHandler) ReceiveXeroWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxXeroWebhookBytes))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.webhookService.Receive(body, c.GetHeader(xero.SignatureHeader), time.Now())
+	switch {
+	case err == nil:
+		c.Status(http.StatusOK)
+	case errors.Is(err, services.ErrInvalidXeroSignature):
+		c.Status(http.StatusUnauthorized)
+	case errors.Is(err, services.ErrInvalidXeroWebhook):
+		c.Status(http.StatusBadRequest)
+	default:
+		// Xero retries deliveries that fail
+		c.Status(http.StatusInternalServerError)
+	}
+}
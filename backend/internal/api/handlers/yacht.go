@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -11,12 +13,13 @@ import (
 
 // YachtHandler handles yacht requests
 type YachtHandler struct {
-	db *gorm.DB
+	db           *gorm.DB
+	yachtService *services.YachtService
 }
 
 // NewYachtHandler creates a new yacht handler
-func NewYachtHandler(db *gorm.DB) *YachtHandler {
-	return &YachtHandler{db: db}
+func NewYachtHandler(db *gorm.DB, yachtService *services.YachtService) *YachtHandler {
+	return &YachtHandler{db: db, yachtService: yachtService}
 }
 
 // ListYachts returns all yachts
@@ -59,3 +62,40 @@ func (h *YachtHandler) GetYacht(c *gin.Context) {
 
 	c.JSON(http.StatusOK, yacht)
 }
+
+// UpdateYachtCurrencyRequest represents the request body for changing the
+// currency a syndicate is invoiced in
+type UpdateYachtCurrencyRequest struct {
+	Currency string `json:"currency" binding:"required"` // ISO 4217 code, such as AUD or NZD
+}
+
+// UpdateYachtCurrency changes the currency a yacht's syndicate is invoiced
+// in, before any invoices have been raised (manager only)
+// PUT /api/v1/yachts/:id/currency
+func (h *YachtHandler) UpdateYachtCurrency(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yacht ID"})
+		return
+	}
+
+	var req UpdateYachtCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	yacht, err := h.yachtService.SetCurrency(id, req.Currency)
+	switch {
+	case errors.Is(err, services.ErrYachtNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Yacht not found"})
+	case errors.Is(err, services.ErrInvalidCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCurrencyInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update yacht currency"})
+	default:
+		c.JSON(http.StatusOK, yacht)
+	}
+}
@@ -6,26 +6,104 @@ import (
 	"github.com/bitcoinbrisbane/yachtlife/internal/api/handlers"
 	"github.com/bitcoinbrisbane/yachtlife/internal/api/middleware"
 	"github.com/bitcoinbrisbane/yachtlife/internal/config"
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/jobs"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
 	"github.com/bitcoinbrisbane/yachtlife/internal/services"
+	"github.com/bitcoinbrisbane/yachtlife/internal/stripe"
+	"github.com/bitcoinbrisbane/yachtlife/internal/xero"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// SetupRoutes configures all API routes
-func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+// SetupRoutes configures all API routes and registers background jobs
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config, runner *jobs.Runner) {
 	// Initialize services
 	jwtService := services.NewJWTService(cfg.JWTSecret, 24*time.Hour)
 	appleSignInService := services.NewAppleSignInService(cfg.AppleClientID, cfg.AppleTeamID)
+	ledger := fairshare.NewLedger(db)
+	bookingService := services.NewBookingService(db, ledger)
+	draftService := services.NewDraftService(db, bookingService, ledger)
+	swapService := services.NewSwapService(db, bookingService, ledger)
+	settingsService := services.NewSettingsService(db, ledger)
+	calendarService := services.NewCalendarService(db)
+	reportingService := services.NewReportingService(db, ledger)
+	shareService := services.NewShareService(db, bookingService, ledger)
+	calendarFeedService := services.NewCalendarFeedService(db)
+	invoiceService := services.NewInvoiceService(db)
+	levyService := services.NewLevyService(db)
+	recurringInvoiceService := services.NewRecurringInvoiceService(db, levyService)
+	exchangeRateService := services.NewExchangeRateService(db)
+	yachtService := services.NewYachtService(db)
+	dunningService := services.NewDunningService(db)
+
+	// Xero sync is only enabled once the app is registered with Xero
+	var xeroSyncService *services.XeroSyncService
+	if cfg.XeroClientID != "" {
+		xeroClient := xero.NewClient(xero.Config{
+			ClientID:     cfg.XeroClientID,
+			ClientSecret: cfg.XeroClientSecret,
+			RedirectURI:  cfg.XeroRedirectURI,
+		}, services.NewXeroTokenStore(db), nil)
+		xeroSyncService = services.NewXeroSyncService(db, xeroClient, services.XeroAccounts{
+			Sales:    cfg.XeroSalesAccountCode,
+			Payments: cfg.XeroPaymentAccountCode,
+		}, cfg.JWTSecret)
+	}
+	var xeroWebhookService *services.XeroWebhookService
+	if xeroSyncService != nil && cfg.XeroWebhookKey != "" {
+		xeroWebhookService = services.NewXeroWebhookService(db, xeroSyncService, cfg.XeroWebhookKey)
+	}
+
+	// Card and Apple Pay payments are only enabled once Stripe is configured
+	var paymentService *services.PaymentService
+	if cfg.StripeSecretKey != "" {
+		stripeClient := stripe.NewClient(stripe.Config{SecretKey: cfg.StripeSecretKey}, nil)
+		paymentService = services.NewPaymentService(db, stripeClient)
+	}
+	var stripeWebhookService *services.StripeWebhookService
+	if paymentService != nil && cfg.StripeWebhookSecret != "" {
+		stripeWebhookService = services.NewStripeWebhookService(db, cfg.StripeWebhookSecret)
+	}
+
+	// Background jobs
+	runner.Register(jobs.Job{Name: "draft-auto-pick", Interval: time.Minute, Run: draftService.AdvanceDue})
+	runner.Register(jobs.Job{Name: "standby-offer-expiry", Interval: time.Minute, Run: bookingService.ExpireStandbyOffers})
+	runner.Register(jobs.Job{Name: "recurring-invoices", Interval: time.Hour, Run: recurringInvoiceService.IssueDue})
+	runner.Register(jobs.Job{Name: "overdue-invoices", Interval: time.Hour, Run: dunningService.MarkOverdue})
+	runner.Register(jobs.Job{Name: "invoice-reminders", Interval: time.Hour, Run: dunningService.SendReminders})
+	if xeroSyncService != nil {
+		runner.Register(jobs.Job{Name: "xero-sync", Interval: 15 * time.Minute, Run: xeroSyncService.SyncDue})
+	}
+	if xeroWebhookService != nil {
+		runner.Register(jobs.Job{Name: "xero-webhook-events", Interval: time.Minute, Run: xeroWebhookService.ProcessDue})
+	}
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(db, jwtService, appleSignInService)
-	yachtHandler := handlers.NewYachtHandler(db)
+	yachtHandler := handlers.NewYachtHandler(db, yachtService)
 	userHandler := handlers.NewUserHandler(db)
 	logbookHandler := handlers.NewLogbookHandler(db)
-	bookingHandler := handlers.NewBookingHandler(db)
+	bookingHandler := handlers.NewBookingHandler(db, bookingService)
 	activityHandler := handlers.NewActivityHandler(db)
-	dashboardHandler := handlers.NewDashboardHandler(db)
-	invoiceHandler := handlers.NewInvoiceHandler(db)
+	dashboardHandler := handlers.NewDashboardHandler(db, reportingService)
+	invoiceHandler := handlers.NewInvoiceHandler(db, invoiceService, exchangeRateService)
+	creditHandler := handlers.NewCreditHandler(db, ledger)
+	draftHandler := handlers.NewDraftHandler(db, draftService)
+	standbyHandler := handlers.NewStandbyHandler(db, bookingService)
+	swapHandler := handlers.NewSwapHandler(swapService)
+	settingsHandler := handlers.NewSettingsHandler(settingsService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	fairnessHandler := handlers.NewFairnessHandler(db, reportingService)
+	shareHandler := handlers.NewShareHandler(shareService)
+	calendarFeedHandler := handlers.NewCalendarFeedHandler(calendarFeedService)
+	levyHandler := handlers.NewLevyHandler(levyService)
+	recurringInvoiceHandler := handlers.NewRecurringInvoiceHandler(recurringInvoiceService)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateService)
+	dunningHandler := handlers.NewDunningHandler(dunningService)
+
+	// iCalendar feeds, authenticated by the secret token in the URL
+	router.GET("/calendar/:token", calendarFeedHandler.GetCalendarFeed)
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
@@ -38,6 +116,24 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			auth.POST("/refresh", authHandler.RefreshToken)
 		}
 
+		// Xero redirects here after a manager approves the connection
+		if xeroSyncService != nil {
+			xeroHandler := handlers.NewXeroHandler(xeroSyncService)
+			v1.GET("/xero/callback", xeroHandler.XeroCallback)
+		}
+
+		// Xero change notifications, authenticated by their signature
+		if xeroWebhookService != nil {
+			xeroWebhookHandler := handlers.NewXeroWebhookHandler(xeroWebhookService)
+			v1.POST("/webhooks/xero", xeroWebhookHandler.ReceiveXeroWebhook)
+		}
+
+		// Stripe payment events, authenticated by their signature
+		if stripeWebhookService != nil {
+			stripeWebhookHandler := handlers.NewStripeWebhookHandler(stripeWebhookService)
+			v1.POST("/webhooks/stripe", stripeWebhookHandler.ReceiveStripeWebhook)
+		}
+
 		// Protected routes (require authentication)
 		protected := v1.Group("")
 		protected.Use(middleware.AuthMiddleware(jwtService))
@@ -48,11 +144,186 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			// Dashboard route
 			protected.GET("/dashboard", dashboardHandler.GetDashboard)
 
+			// Invoice list, scoped to the user's own invoices for owners
+			protected.GET("/invoices", invoiceHandler.ListInvoices)
+
+			// Syndicate levies split into invoices by ownership (manager only)
+			protected.GET("/yachts/:id/levies",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				levyHandler.ListLevies)
+			protected.POST("/yachts/:id/levies",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				levyHandler.CreateLevy)
+			protected.POST("/yachts/:id/levies/preview",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				levyHandler.PreviewLevy)
+
+			// Recurring levy schedules (manager only)
+			protected.GET("/yachts/:id/recurring-invoices",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				recurringInvoiceHandler.ListRecurringInvoices)
+			protected.POST("/yachts/:id/recurring-invoices",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				recurringInvoiceHandler.CreateRecurringInvoice)
+			protected.PATCH("/recurring-invoices/:id",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				recurringInvoiceHandler.UpdateRecurringInvoice)
+
+			// Reminders and late fees for unpaid invoices (manager only)
+			protected.GET("/yachts/:id/dunning",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				dunningHandler.GetDunningPolicy)
+			protected.PUT("/yachts/:id/dunning",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				dunningHandler.UpdateDunningPolicy)
+
+			// Syndicate invoicing currency, fixed once anything is billed (manager only)
+			protected.PUT("/yachts/:id/currency",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				yachtHandler.UpdateYachtCurrency)
+
+			// Exchange rates for reporting across currencies (manager only)
+			protected.GET("/exchange-rates",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				exchangeRateHandler.ListExchangeRates)
+			protected.PUT("/exchange-rates/:from/:to",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				exchangeRateHandler.SetExchangeRate)
+			protected.DELETE("/exchange-rates/:from/:to",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				exchangeRateHandler.DeleteExchangeRate)
+
 			// Invoice dashboard route
 			protected.GET("/invoices/dashboard", invoiceHandler.GetInvoicesDashboard)
 
+			// Xero connection and sync (manager only)
+			if xeroSyncService != nil {
+				xeroHandler := handlers.NewXeroHandler(xeroSyncService)
+				protected.GET("/xero/connect",
+					middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+					xeroHandler.ConnectXero)
+				protected.GET("/xero/status",
+					middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+					xeroHandler.GetXeroStatus)
+				protected.POST("/invoices/sync",
+					middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+					xeroHandler.SyncXero)
+			}
+
 			// Invoice detail route
 			protected.GET("/invoices/:id", invoiceHandler.GetInvoice)
+
+			// Credit notes against an invoice's balance (issuing is manager only)
+			protected.GET("/invoices/:id/credit-notes", invoiceHandler.ListCreditNotes)
+			protected.POST("/invoices/:id/credit-notes",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				invoiceHandler.CreateCreditNote)
+
+			// Paying an invoice through Stripe, and refunds (manager only)
+			if paymentService != nil {
+				paymentHandler := handlers.NewPaymentHandler(paymentService, cfg.StripePublishableKey)
+				protected.POST("/invoices/:id/payments", paymentHandler.CreateInvoicePayment)
+				protected.POST("/payments/:id/refunds",
+					middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+					paymentHandler.RefundPayment)
+			}
+
+			// Fair share credit balances
+			protected.GET("/yachts/:id/credits", creditHandler.GetCredits)
+			protected.GET("/yachts/:id/fairness", fairnessHandler.GetFairness)
+
+			// Per-syndicate fair share settings (manager only)
+			protected.GET("/yachts/:id/settings",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				settingsHandler.GetSettings)
+			protected.PUT("/yachts/:id/settings",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				settingsHandler.UpdateSettings)
+			protected.DELETE("/yachts/:id/settings",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				settingsHandler.ResetSettings)
+
+			// Syndicate shares joining and leaving (manager only)
+			protected.GET("/yachts/:id/shares",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				shareHandler.ListShares)
+			protected.POST("/yachts/:id/shares",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				shareHandler.CreateShare)
+			protected.POST("/shares/:id/exit",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				shareHandler.ExitShare)
+
+			// Personal iCalendar feed URLs
+			protected.GET("/calendar-feeds", calendarFeedHandler.ListCalendarFeeds)
+			protected.POST("/calendar-feeds", calendarFeedHandler.CreateCalendarFeed)
+			protected.DELETE("/calendar-feeds/:id", calendarFeedHandler.RevokeCalendarFeed)
+
+			// Holiday calendars used for slot valuation
+			protected.GET("/calendars", calendarHandler.ListRegions)
+			protected.GET("/calendars/:region", calendarHandler.GetCalendar)
+			protected.GET("/yachts/:id/holidays", calendarHandler.ListCustomHolidays)
+			protected.POST("/yachts/:id/holidays",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				calendarHandler.AddCustomHoliday)
+			protected.DELETE("/holidays/:id",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				calendarHandler.DeleteCustomHoliday)
+
+			// Priority resolution of collected booking requests (manager only)
+			protected.POST("/yachts/:id/booking-requests/resolve",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				bookingHandler.ResolveBookingRequests)
+
+			// Snake drafts for premium season allocation
+			protected.GET("/yachts/:id/drafts", draftHandler.ListDrafts)
+			protected.POST("/yachts/:id/drafts",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				draftHandler.OpenDraft)
+			protected.GET("/drafts/:id", draftHandler.GetDraft)
+			protected.POST("/drafts/:id/picks", draftHandler.MakePick)
+			protected.GET("/drafts/:id/wishlist", draftHandler.GetWishlist)
+			protected.PUT("/drafts/:id/wishlist", draftHandler.SetWishlist)
+
+			// Standby list and offers of cancelled dates
+			protected.POST("/yachts/:id/standby", standbyHandler.JoinStandby)
+			protected.GET("/yachts/:id/standby", standbyHandler.ListStandby)
+			protected.GET("/yachts/:id/standby/offers",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				standbyHandler.ListYachtOffers)
+			protected.DELETE("/standby/:id", standbyHandler.WithdrawStandby)
+			protected.GET("/standby/offers", standbyHandler.ListMyOffers)
+			protected.POST("/standby/offers/:id/accept", standbyHandler.AcceptOffer)
+			protected.POST("/standby/offers/:id/decline", standbyHandler.DeclineOffer)
+
+			// Owner-to-owner swap marketplace
+			protected.POST("/swaps", swapHandler.ProposeSwap)
+			protected.GET("/swaps", swapHandler.ListSwaps)
+			protected.POST("/swaps/:id/accept", swapHandler.AcceptSwap)
+			protected.POST("/swaps/:id/decline", swapHandler.DeclineSwap)
+			protected.POST("/swaps/:id/withdraw", swapHandler.WithdrawSwap)
+
+			// Phased booking windows
+			protected.GET("/yachts/:id/booking-windows", bookingHandler.ListBookingWindows)
+			protected.GET("/yachts/:id/booking-windows/availability", bookingHandler.GetWindowAvailability)
+			protected.POST("/yachts/:id/booking-windows",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				bookingHandler.CreateBookingWindow)
+			protected.PUT("/booking-windows/:id",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				bookingHandler.UpdateBookingWindow)
+			protected.DELETE("/booking-windows/:id",
+				middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)),
+				bookingHandler.DeleteBookingWindow)
+
+			// Booking change request review (manager only)
+			changeRequests := protected.Group("/booking-change-requests")
+			changeRequests.Use(middleware.RequireRole(string(models.RoleManager), string(models.RoleAdmin)))
+			{
+				changeRequests.GET("", bookingHandler.ListChangeRequests)
+				changeRequests.POST("/:id/approve", bookingHandler.ApproveChangeRequest)
+				changeRequests.POST("/:id/reject", bookingHandler.RejectChangeRequest)
+			}
 		}
 
 		// Yacht routes (public - no authentication required for browsing)
@@ -92,12 +363,15 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			bookings.GET("/:id/detail", bookingHandler.GetBookingDetail)
 		}
 
-		// Invoice routes (to be implemented)
-		invoices := v1.Group("/invoices")
+		// Protected booking routes - require authentication
+		protectedBookings := v1.Group("/bookings")
+		protectedBookings.Use(middleware.AuthMiddleware(jwtService))
 		{
-			invoices.GET("", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "List Invoices - Not Implemented"})
-			})
+			protectedBookings.POST("", bookingHandler.CreateBooking)
+			protectedBookings.PATCH("/:id", bookingHandler.UpdateBooking)
+			protectedBookings.POST("/:id/cancel", bookingHandler.CancelBooking)
+			protectedBookings.POST("/:id/change-requests", bookingHandler.CreateChangeRequest)
+			protectedBookings.GET("/:id/change-requests", bookingHandler.ListBookingChangeRequests)
 		}
 
 		// Maintenance routes (to be implemented)
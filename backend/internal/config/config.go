@@ -45,6 +45,10 @@ type Config struct {
 	XeroRedirectURI  string
 	XeroWebhookKey   string
 
+	// Xero chart of accounts codes for invoice lines and received payments
+	XeroSalesAccountCode   string
+	XeroPaymentAccountCode string
+
 	// S3/MinIO
 	S3Endpoint  string
 	S3AccessKey string
@@ -118,6 +122,9 @@ func Load() (*Config, error) {
 		XeroRedirectURI:  getEnv("XERO_REDIRECT_URI", ""),
 		XeroWebhookKey:   getEnv("XERO_WEBHOOK_KEY", ""),
 
+		XeroSalesAccountCode:   getEnv("XERO_SALES_ACCOUNT_CODE", "200"),
+		XeroPaymentAccountCode: getEnv("XERO_PAYMENT_ACCOUNT_CODE", "090"),
+
 		S3Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
 		S3AccessKey: getEnv("S3_ACCESS_KEY", "yachtlife"),
 		S3SecretKey: getEnv("S3_SECRET_KEY", "yachtlife_minio_password"),
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/bitcoinbrisbane/yachtlife/internal/config"
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
 	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -56,12 +58,44 @@ func RunMigrations(db *gorm.DB) error {
 		&models.BookingChangeRequest{},
 		&models.Invoice{},
 		&models.Payment{},
+		&models.CreditNote{},
+		&models.InvoiceLine{},
+		&models.InvoiceReminder{},
+		&models.DunningPolicy{},
+		&models.Levy{},
+		&models.InvoiceSequence{},
+		&models.RecurringInvoice{},
+		&models.XeroConnection{},
+		&models.XeroWebhookEvent{},
+		&models.StripeEvent{},
+		&models.ExchangeRate{},
 		&models.LogbookEntry{},
 		&models.Checklist{},
 		&models.Vote{},
 		&models.VoteResponse{},
 		&models.MaintenanceRequest{},
 		&models.Notification{},
+		&models.CreditLedgerEntry{},
+		&models.SyndicateSettings{},
+		&models.CustomHoliday{},
+		&models.CalendarFeed{},
+		&models.Draft{},
+		&models.DraftParticipant{},
+		&models.DraftSlot{},
+		&models.DraftPick{},
+		&models.DraftWishlistItem{},
+		&models.StandbyEntry{},
+		&models.StandbyOffer{},
+		&models.SwapOffer{},
+		&models.BookingWindow{},
+	}
+
+	if err := addTripRuleColumns(db); err != nil {
+		return fmt.Errorf("failed to add trip rule settings: %w", err)
+	}
+
+	if err := addSwapOfferDates(db); err != nil {
+		return fmt.Errorf("failed to add swap offer dates: %w", err)
 	}
 
 	// Auto-migrate all models
@@ -69,10 +103,133 @@ func RunMigrations(db *gorm.DB) error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// Constraints that GORM tags cannot express
+	if err := createBookingConstraints(db); err != nil {
+		return fmt.Errorf("failed to create booking constraints: %w", err)
+	}
+
+	// Invoices created in the app have no Xero ID until they are pushed, so
+	// the ID is only unique once set
+	if err := db.Exec("DROP INDEX IF EXISTS idx_invoices_xero_invoice_id").Error; err != nil {
+		return fmt.Errorf("failed to drop invoice index: %w", err)
+	}
+
+	if err := backfillInvoiceBalances(db); err != nil {
+		return fmt.Errorf("failed to backfill invoice balances: %w", err)
+	}
+
 	log.Println("✅ Database migrations completed")
 	return nil
 }
 
+// addTripRuleColumns adds the trip rule settings to syndicates saved before
+// they existed, with the default rules. The columns are left without a
+// default so a manager's zero is stored as zero.
+func addTripRuleColumns(db *gorm.DB) error {
+	defaults := fairshare.DefaultConfig()
+	columns := []struct {
+		name  string
+		value int
+	}{
+		{"min_trip_gap_days", defaults.MinTripGapDays},
+		{"turnaround_hours", defaults.TurnaroundHours},
+	}
+
+	for _, column := range columns {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE IF EXISTS syndicate_settings ADD COLUMN IF NOT EXISTS %s bigint NOT NULL DEFAULT %d",
+			column.name, column.value)).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE IF EXISTS syndicate_settings ALTER COLUMN %s DROP DEFAULT",
+			column.name)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSwapOfferDates records the booking dates on swap offers made before
+// they were stored, taking the bookings' current dates
+func addSwapOfferDates(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.SwapOffer{}) {
+		return nil
+	}
+
+	columns := []struct {
+		name, booking, date string
+	}{
+		{"offered_start_date", "offered_booking_id", "start_date"},
+		{"offered_end_date", "offered_booking_id", "end_date"},
+		{"requested_start_date", "requested_booking_id", "start_date"},
+		{"requested_end_date", "requested_booking_id", "end_date"},
+	}
+
+	for _, column := range columns {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE swap_offers ADD COLUMN IF NOT EXISTS %s timestamptz",
+			column.name)).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(fmt.Sprintf("UPDATE swap_offers SET %s = bookings.%s FROM bookings WHERE bookings.id = swap_offers.%s AND swap_offers.%s IS NULL",
+			column.name, column.date, column.booking, column.name)).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE swap_offers ALTER COLUMN %s SET NOT NULL",
+			column.name)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillInvoiceBalances sets the balance of invoices from before balances
+// were tracked: unpaid invoices owe their full amount, paid ones nothing
+func backfillInvoiceBalances(db *gorm.DB) error {
+	untracked := "amount_paid = 0 AND amount_credited = 0 AND amount_due = 0"
+	if err := db.Exec("UPDATE invoices SET amount_due = amount WHERE "+untracked+" AND status NOT IN ?",
+		[]models.InvoiceStatus{models.InvoiceStatusPaid, models.InvoiceStatusCancelled}).Error; err != nil {
+		return err
+	}
+	return db.Exec("UPDATE invoices SET amount_paid = amount WHERE "+untracked+" AND status = ?",
+		models.InvoiceStatusPaid).Error
+}
+
+// createBookingConstraints prevents two live bookings for the same yacht from
+// overlapping. The check lives in the database so concurrent requests cannot
+// both pass an application-level pre-check. Requests still awaiting priority
+// resolution are allowed to overlap until they are resolved.
+func createBookingConstraints(db *gorm.DB) error {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS btree_gist").Error; err != nil {
+		return err
+	}
+
+	if err := addConstraint(db, "bookings", "bookings_valid_range", "CHECK (end_date > start_date)"); err != nil {
+		return err
+	}
+
+	// Superseded by bookings_no_live_overlap, which exempts unresolved requests
+	if err := db.Exec("ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap").Error; err != nil {
+		return err
+	}
+
+	return addConstraint(db, "bookings", "bookings_no_live_overlap", `EXCLUDE USING gist (
+		yacht_id WITH =,
+		tstzrange(start_date, end_date, '[)') WITH &&
+	) WHERE (status IN ('pending', 'confirmed') AND NOT awaiting_resolution)`)
+}
+
+// addConstraint adds a named table constraint if it does not already exist
+func addConstraint(db *gorm.DB, table, name, definition string) error {
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM pg_constraint WHERE conname = ?", name).Scan(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return db.Exec(fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", table, name, definition)).Error
+}
+
 // SeedData seeds the database with test data
 func SeedData(db *gorm.DB) error {
 	log.Println("🌱 Seeding database with test data...")
@@ -258,6 +415,25 @@ func SeedData(db *gorm.DB) error {
 		return fmt.Errorf("failed to find test owner: %w", err)
 	}
 
+	// Give the test owner a share in Neptune's Pride so they can book it
+	var existingShare models.SyndicateShare
+	shareResult := db.Where("yacht_id = ? AND user_id = ?", neptunesPride.ID, testOwnerUser.ID).First(&existingShare)
+	if shareResult.Error != nil {
+		share := models.SyndicateShare{
+			YachtID:         neptunesPride.ID,
+			UserID:          testOwnerUser.ID,
+			SharePercentage: 25.00,
+			DaysPerYear:     90,
+			JoinedDate:      time.Date(time.Now().Year(), time.January, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		if err := db.Create(&share).Error; err != nil {
+			return fmt.Errorf("failed to create syndicate share: %w", err)
+		}
+		log.Printf("✅ Created syndicate share: %s holds %.2f%% of %s\n",
+			testOwnerUser.Email, share.SharePercentage, neptunesPride.Name)
+	}
+
 	// Create seed bookings for Neptune's Pride
 	// Set times to 10am for start dates and 7pm for end dates
 	now := time.Now()
@@ -318,7 +494,9 @@ func SeedData(db *gorm.DB) error {
 			UserID:        testOwnerUser.ID,
 			InvoiceNumber: "YL-2024-001",
 			Description:   "November 2024 - Marina berth fees and maintenance",
-			Amount:        2450.00,
+			Currency:      money.AUD,
+			Amount:        money.New(245000, money.AUD),
+			AmountPaid:    money.New(245000, money.AUD),
 			IssuedDate:    time.Now().AddDate(0, -2, -5), // Issued 2 months 5 days ago
 			DueDate:       time.Now().AddDate(0, -1, -20), // Due 1 month 20 days ago
 			Status:        models.InvoiceStatusPaid,
@@ -330,7 +508,9 @@ func SeedData(db *gorm.DB) error {
 			UserID:        testOwnerUser.ID,
 			InvoiceNumber: "YL-2024-002",
 			Description:   "December 2024 - Fuel, cleaning and syndicate fees",
-			Amount:        3890.50,
+			Currency:      money.AUD,
+			Amount:        money.New(389050, money.AUD),
+			AmountDue:     money.New(389050, money.AUD),
 			IssuedDate:    time.Now().AddDate(0, -1, -10), // Issued 1 month 10 days ago
 			DueDate:       time.Now().AddDate(0, 0, -5),   // Overdue by 5 days
 			Status:        models.InvoiceStatusOverdue,
@@ -341,7 +521,9 @@ func SeedData(db *gorm.DB) error {
 			UserID:        testOwnerUser.ID,
 			InvoiceNumber: "YL-2025-001",
 			Description:   "January 2025 - Monthly ownership costs and insurance",
-			Amount:        4120.75,
+			Currency:      money.AUD,
+			Amount:        money.New(412075, money.AUD),
+			AmountDue:     money.New(412075, money.AUD),
 			IssuedDate:    time.Now().AddDate(0, 0, -3), // Issued 3 days ago
 			DueDate:       time.Now().AddDate(0, 0, 12), // Due in 12 days
 			Status:        models.InvoiceStatusSent,
@@ -352,7 +534,9 @@ func SeedData(db *gorm.DB) error {
 			UserID:        testOwnerUser.ID,
 			InvoiceNumber: "YL-2025-002",
 			Description:   "February 2025 - Upcoming maintenance and berth fees",
-			Amount:        2850.00,
+			Currency:      money.AUD,
+			Amount:        money.New(285000, money.AUD),
+			AmountDue:     money.New(285000, money.AUD),
 			IssuedDate:    time.Now(),
 			DueDate:       time.Now().AddDate(0, 1, 0), // Due in 1 month
 			Status:        models.InvoiceStatusDraft,
@@ -370,10 +554,10 @@ func SeedData(db *gorm.DB) error {
 		if err := db.Create(&invoice).Error; err != nil {
 			return fmt.Errorf("failed to create invoice %d: %w", i+1, err)
 		}
-		log.Printf("✅ Created invoice: %s - %s ($%.2f, %s)\n",
+		log.Printf("✅ Created invoice: %s - %s ($%s, %s)\n",
 			invoice.InvoiceNumber,
 			invoice.Description,
-			invoice.Amount,
+			invoice.Amount.Decimal(),
 			invoice.Status)
 	}
 
@@ -0,0 +1,49 @@
+package fairshare
+
+import "github.com/google/uuid"
+
+// DraftOrder returns the first-round pick order for a new draft. The previous
+// draft's order is rotated by one so its first pick moves to the back; owners
+// who have since left are dropped and owners new to the draft join at the end
+// in the order given. Over n years every owner holds every position once.
+func DraftOrder(previous, owners []uuid.UUID) []uuid.UUID {
+	current := make(map[uuid.UUID]bool, len(owners))
+	for _, id := range owners {
+		current[id] = true
+	}
+
+	var rotated []uuid.UUID
+	if len(previous) > 0 {
+		rotated = append(rotated, previous[1:]...)
+		rotated = append(rotated, previous[0])
+	}
+
+	order := make([]uuid.UUID, 0, len(owners))
+	placed := make(map[uuid.UUID]bool, len(owners))
+	for _, id := range rotated {
+		if current[id] && !placed[id] {
+			order = append(order, id)
+			placed[id] = true
+		}
+	}
+	for _, id := range owners {
+		if !placed[id] {
+			order = append(order, id)
+			placed[id] = true
+		}
+	}
+
+	return order
+}
+
+// SnakeTurn maps a zero-based pick number to its 1-based round and the index
+// of the picking owner in the first-round order. Odd rounds run forwards and
+// even rounds run backwards.
+func SnakeTurn(pick, participants int) (round, index int) {
+	round = pick/participants + 1
+	index = pick % participants
+	if round%2 == 0 {
+		index = participants - 1 - index
+	}
+	return round, index
+}
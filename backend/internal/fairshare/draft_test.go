@@ -0,0 +1,34 @@
+package fairshare
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDraftOrder tests that the first-round order rotates between drafts
+func TestDraftOrder(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	// First draft follows the owners' join order
+	assert.Equal(t, []uuid.UUID{a, b, c}, DraftOrder(nil, []uuid.UUID{a, b, c}))
+
+	// Last year's first pick moves to the back
+	assert.Equal(t, []uuid.UUID{b, c, a}, DraftOrder([]uuid.UUID{a, b, c}, []uuid.UUID{a, b, c}))
+
+	// Leavers are dropped and new owners join at the end
+	assert.Equal(t, []uuid.UUID{c, a, d}, DraftOrder([]uuid.UUID{a, b, c}, []uuid.UUID{a, c, d}))
+}
+
+// TestSnakeTurn tests that even rounds reverse the pick order
+func TestSnakeTurn(t *testing.T) {
+	var indexes []int
+	for pick := 0; pick < 9; pick++ {
+		round, index := SnakeTurn(pick, 3)
+		assert.Equal(t, pick/3+1, round)
+		indexes = append(indexes, index)
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 2, 1, 0, 0, 1, 2}, indexes)
+}
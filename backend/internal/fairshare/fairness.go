@@ -0,0 +1,101 @@
+package fairshare
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// OwnerFairness compares the slot value an owner used with their entitlement
+type OwnerFairness struct {
+	UserID          uuid.UUID `json:"user_id"`
+	SharePercentage float64   `json:"share_percentage"`
+	Days            int       `json:"days"`
+	PremiumDays     int       `json:"premium_days"`
+	Used            float64   `json:"used"`
+	Entitled        float64   `json:"entitled"` // Syndicate usage × ownership share
+	Score           float64   `json:"fairness_score"`
+}
+
+// Fairness summarises how evenly a syndicate's usage is spread
+type Fairness struct {
+	Owners         []OwnerFairness `json:"owners"`
+	TotalUsed      float64         `json:"total_used"`
+	SystemFairness float64         `json:"system_fairness"` // 1 when every owner scores 100
+	Gini           float64         `json:"gini"`            // 0 when usage is proportional to ownership
+}
+
+// ScoreFairness fills in each owner's entitlement and fairness score
+//
+//	F_i = U_i / (ω_i × U_total) × 100
+//
+// and the syndicate measures
+//
+//	F_sys = 1 − Σ|F_i − 100| / (n × 100)
+//	Gini  = Σ_i Σ_j ω_i ω_j |x_i − x_j| / (2 × Σ_i ω_i x_i)
+//
+// where ω is the normalised ownership share and x_i = U_i / ω_i is usage per
+// unit of ownership. With no usage every owner scores 100. Owners are returned
+// lowest score first.
+func ScoreFairness(owners []OwnerFairness) Fairness {
+	f := Fairness{Owners: owners, SystemFairness: 1}
+	if len(owners) == 0 {
+		return f
+	}
+
+	totalShare := 0.0
+	for i := range owners {
+		owners[i].Used = round2(owners[i].Used)
+		f.TotalUsed += owners[i].Used
+		totalShare += owners[i].SharePercentage
+	}
+	f.TotalUsed = round2(f.TotalUsed)
+
+	deviation := 0.0
+	weights := make([]float64, len(owners))
+	perShare := make([]float64, len(owners))
+	for i := range owners {
+		o := &owners[i]
+		if totalShare > 0 {
+			weights[i] = o.SharePercentage / totalShare
+		}
+		o.Entitled = round2(f.TotalUsed * weights[i])
+
+		switch {
+		case f.TotalUsed == 0:
+			o.Score = 100
+		case o.Entitled == 0:
+			o.Score = 0
+		default:
+			o.Score = round2(o.Used / (f.TotalUsed * weights[i]) * 100)
+		}
+		deviation += math.Abs(o.Score - 100)
+
+		if weights[i] > 0 {
+			perShare[i] = o.Used / weights[i]
+		}
+	}
+	f.SystemFairness = round4(math.Max(0, 1-deviation/(float64(len(owners))*100)))
+
+	mean := 0.0
+	for i := range owners {
+		mean += weights[i] * perShare[i]
+	}
+	if mean > 0 {
+		spread := 0.0
+		for i := range owners {
+			for j := range owners {
+				spread += weights[i] * weights[j] * math.Abs(perShare[i]-perShare[j])
+			}
+		}
+		f.Gini = round4(spread / (2 * mean))
+	}
+
+	sort.SliceStable(f.Owners, func(i, j int) bool { return f.Owners[i].Score < f.Owners[j].Score })
+	return f
+}
+
+func round4(x float64) float64 {
+	return math.Round(x*10000) / 10000
+}
@@ -0,0 +1,42 @@
+package fairshare
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScoreFairness tests individual fairness scores and syndicate measures
+func TestScoreFairness(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	// Usage exactly proportional to ownership
+	f := ScoreFairness([]OwnerFairness{
+		{UserID: a, SharePercentage: 50, Used: 50},
+		{UserID: b, SharePercentage: 25, Used: 25},
+		{UserID: c, SharePercentage: 25, Used: 25},
+	})
+	for _, o := range f.Owners {
+		assert.Equal(t, 100.0, o.Score)
+	}
+	assert.Equal(t, 1.0, f.SystemFairness)
+	assert.Equal(t, 0.0, f.Gini)
+
+	// Equal shares, one owner has used nothing
+	f = ScoreFairness([]OwnerFairness{
+		{UserID: a, SharePercentage: 50, Used: 30},
+		{UserID: b, SharePercentage: 50, Used: 0},
+	})
+	assert.Equal(t, b, f.Owners[0].UserID, "lowest score first")
+	assert.Equal(t, 0.0, f.Owners[0].Score)
+	assert.Equal(t, 15.0, f.Owners[0].Entitled)
+	assert.Equal(t, 200.0, f.Owners[1].Score)
+	assert.Equal(t, 0.0, f.SystemFairness)
+	assert.Equal(t, 0.5, f.Gini)
+
+	// No usage yet is perfectly fair
+	f = ScoreFairness([]OwnerFairness{{UserID: a, SharePercentage: 100}})
+	assert.Equal(t, 100.0, f.Owners[0].Score)
+	assert.Equal(t, 1.0, f.SystemFairness)
+}
@@ -0,0 +1,413 @@
+package fairshare
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/holidays"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ledger maintains per-owner fair share credit balances
+type Ledger struct {
+	db *gorm.DB
+}
+
+// Balance summarises an owner's credits for a yacht
+type Balance struct {
+	UserID          uuid.UUID `json:"user_id"`
+	SharePercentage float64   `json:"share_percentage"`
+	Allocated       float64   `json:"allocated"` // Annual allocations net of rollover decay
+	Used            float64   `json:"used"`      // Booking debits net of refunds
+	Balance         float64   `json:"balance"`
+}
+
+// NewLedger creates a new credit ledger
+func NewLedger(db *gorm.DB) *Ledger {
+	return &Ledger{db: db}
+}
+
+// Config returns the fair share configuration for a yacht: its syndicate
+// settings, or the defaults if none have been saved
+func (l *Ledger) Config(tx *gorm.DB, yachtID uuid.UUID) (Config, error) {
+	var settings models.SyndicateSettings
+	err := tx.Where("yacht_id = ?", yachtID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	return ConfigFromSettings(settings)
+}
+
+// Calendar returns a yacht's holiday calendar: the bundled holidays for its
+// configured region and state plus the custom dates added by managers
+func (l *Ledger) Calendar(tx *gorm.DB, yachtID uuid.UUID, cfg Config) (*holidays.Calendar, error) {
+	calendar, err := holidays.NewCalendar(cfg.HolidayRegion, cfg.HolidayState)
+	if err != nil {
+		return nil, err
+	}
+
+	var custom []models.CustomHoliday
+	if err := tx.Where("yacht_id = ?", yachtID).Find(&custom).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range custom {
+		calendar.Add(holidays.Holiday{
+			Name:  h.Name,
+			Kind:  holidays.Kind(h.Kind),
+			Start: h.StartDate,
+			End:   h.EndDate,
+		})
+	}
+
+	return calendar, nil
+}
+
+// Valuer returns a slot valuer configured for a yacht
+func (l *Ledger) Valuer(tx *gorm.DB, yachtID uuid.UUID) (*Valuer, error) {
+	cfg, err := l.Config(tx, yachtID)
+	if err != nil {
+		return nil, err
+	}
+	return l.valuer(tx, yachtID, cfg)
+}
+
+func (l *Ledger) valuer(tx *gorm.DB, yachtID uuid.UUID, cfg Config) (*Valuer, error) {
+	calendar, err := l.Calendar(tx, yachtID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewValuer(cfg, calendar), nil
+}
+
+// EnsureAllocations grants each shareholder their annual credits for a year:
+//
+//	OwnerCredits = TotalAnnualSlotValue × OwnershipPercentage
+//
+// Shares joined or ended partway through the year are pro-rated. If the
+// owner was allocated credits the previous year, a positive balance is first
+// decayed so only RolloverDecay of it carries over. Safe to call repeatedly;
+// existing allocations are left untouched.
+func (l *Ledger) EnsureAllocations(tx *gorm.DB, yachtID uuid.UUID, year int) error {
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var shares []models.SyndicateShare
+	if err := tx.Scopes(HeldBetween(yearStart, yearStart.AddDate(1, 0, 0))).
+		Where("yacht_id = ?", yachtID).Find(&shares).Error; err != nil {
+		return err
+	}
+	if len(shares) == 0 {
+		return nil
+	}
+
+	cfg, err := l.Config(tx, yachtID)
+	if err != nil {
+		return err
+	}
+	valuer, err := l.valuer(tx, yachtID, cfg)
+	if err != nil {
+		return err
+	}
+	annualValue := valuer.AnnualValue(year, time.Local)
+
+	// An owner may hold more than one share in the same yacht
+	percentages := make(map[uuid.UUID]float64)
+	credits := make(map[uuid.UUID]float64)
+	proRated := make(map[uuid.UUID]bool)
+	var owners []uuid.UUID
+	for _, share := range shares {
+		if _, seen := percentages[share.UserID]; !seen {
+			owners = append(owners, share.UserID)
+		}
+		fraction := YearFraction(share, year)
+		percentages[share.UserID] += share.SharePercentage
+		credits[share.UserID] += annualValue * share.SharePercentage / 100 * fraction
+		if fraction < 1 {
+			proRated[share.UserID] = true
+		}
+	}
+
+	for _, userID := range owners {
+		var allocated int64
+		if err := tx.Model(&models.CreditLedgerEntry{}).
+			Where("yacht_id = ? AND user_id = ? AND year = ? AND entry_type = ?",
+				yachtID, userID, year, models.CreditEntryAllocation).
+			Count(&allocated).Error; err != nil {
+			return err
+		}
+		if allocated > 0 {
+			continue
+		}
+
+		var entries []models.CreditLedgerEntry
+
+		rollover, err := l.rolloverAmount(tx, yachtID, userID, year, cfg.RolloverDecay)
+		if err != nil {
+			return err
+		}
+		if rollover != 0 {
+			entries = append(entries, models.CreditLedgerEntry{
+				YachtID:     yachtID,
+				UserID:      userID,
+				EntryType:   models.CreditEntryRollover,
+				Year:        year,
+				Amount:      rollover,
+				Description: fmt.Sprintf("Rollover decay of %d balance", year-1),
+			})
+		}
+
+		description := fmt.Sprintf("%d annual allocation (%.2f%% share)", year, percentages[userID])
+		if proRated[userID] {
+			description = fmt.Sprintf("%d annual allocation (%.2f%% share, pro-rated)", year, percentages[userID])
+		}
+		entries = append(entries, models.CreditLedgerEntry{
+			YachtID:     yachtID,
+			UserID:      userID,
+			EntryType:   models.CreditEntryAllocation,
+			Year:        year,
+			Amount:      round2(credits[userID]),
+			Description: description,
+		})
+
+		// A concurrent request may have allocated in the meantime
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rolloverAmount returns the (negative) decay entry for the balance carried
+// into year, or zero if the owner had no allocation the previous year
+func (l *Ledger) rolloverAmount(tx *gorm.DB, yachtID, userID uuid.UUID, year int, decay float64) (float64, error) {
+	var previous int64
+	if err := tx.Model(&models.CreditLedgerEntry{}).
+		Where("yacht_id = ? AND user_id = ? AND year = ? AND entry_type = ?",
+			yachtID, userID, year-1, models.CreditEntryAllocation).
+		Count(&previous).Error; err != nil {
+		return 0, err
+	}
+	if previous == 0 {
+		return 0, nil
+	}
+
+	var balance float64
+	if err := tx.Model(&models.CreditLedgerEntry{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("yacht_id = ? AND user_id = ? AND year < ?", yachtID, userID, year).
+		Scan(&balance).Error; err != nil {
+		return 0, err
+	}
+
+	// Over-users carry their deficit forward in full
+	if balance <= 0 {
+		return 0, nil
+	}
+	return round2(-(1 - decay) * balance), nil
+}
+
+// SyncBooking posts whatever debit or refund is needed so the credits charged
+// for a booking match its current state: confirmed and completed bookings cost
+// their slot value, anything else costs nothing. Charges held by a previous
+// owner of the booking are refunded to them, and charges in another year to
+// the year they were made.
+func (l *Ledger) SyncBooking(tx *gorm.DB, booking *models.Booking) error {
+	year := booking.StartDate.Year()
+	if err := l.EnsureAllocations(tx, booking.YachtID, year); err != nil {
+		return err
+	}
+
+	desired := 0.0
+	if booking.Status == models.BookingStatusConfirmed || booking.Status == models.BookingStatusCompleted {
+		valuer, err := l.Valuer(tx, booking.YachtID)
+		if err != nil {
+			return err
+		}
+		desired = valuer.RangeValue(booking.StartDate, booking.EndDate)
+	}
+
+	var charges []bookingCharge
+	if err := tx.Model(&models.CreditLedgerEntry{}).
+		Select("user_id, year, -COALESCE(SUM(amount), 0) AS amount").
+		Where("booking_id = ? AND entry_type IN ?", booking.ID,
+			[]models.CreditEntryType{models.CreditEntryBookingDebit, models.CreditEntryBookingRefund}).
+		Group("user_id, year").
+		Scan(&charges).Error; err != nil {
+		return err
+	}
+
+	entries := bookingSyncEntries(booking, desired, charges)
+	if len(entries) == 0 {
+		return nil
+	}
+	return tx.Create(&entries).Error
+}
+
+// bookingCharge is the net credit a booking has charged an owner in a year
+type bookingCharge struct {
+	UserID uuid.UUID
+	Year   int
+	Amount float64
+}
+
+// bookingSyncEntries returns the entries that bring a booking's charges to
+// desired, charged to its owner in the year it starts. Charges to previous
+// owners, or in another year after the booking moved, are refunded in full
+// in the year they were made so each year's balances stay right.
+func bookingSyncEntries(booking *models.Booking, desired float64, charges []bookingCharge) []models.CreditLedgerEntry {
+	year := booking.StartDate.Year()
+	description := fmt.Sprintf("Booking %s – %s",
+		booking.StartDate.Format("2 Jan 2006"), booking.EndDate.Format("2 Jan 2006"))
+
+	var entries []models.CreditLedgerEntry
+	newEntry := func(userID uuid.UUID, entryType models.CreditEntryType, year int, amount float64) models.CreditLedgerEntry {
+		return models.CreditLedgerEntry{
+			YachtID:     booking.YachtID,
+			UserID:      userID,
+			BookingID:   &booking.ID,
+			EntryType:   entryType,
+			Year:        year,
+			Amount:      round2(amount),
+			Description: description,
+		}
+	}
+
+	current := 0.0
+	for _, c := range charges {
+		if c.UserID == booking.UserID && c.Year == year {
+			current = c.Amount
+			continue
+		}
+		if round2(c.Amount) != 0 {
+			entries = append(entries, newEntry(c.UserID, models.CreditEntryBookingRefund, c.Year, c.Amount))
+		}
+	}
+
+	diff := round2(desired - current)
+	switch {
+	case diff > 0:
+		entries = append(entries, newEntry(booking.UserID, models.CreditEntryBookingDebit, year, -diff))
+	case diff < 0:
+		entries = append(entries, newEntry(booking.UserID, models.CreditEntryBookingRefund, year, -diff))
+	}
+	return entries
+}
+
+// ChargeCancellationFee keeps part of a cancelled booking's value when it was
+// cancelled within the syndicate's late cancellation window
+func (l *Ledger) ChargeCancellationFee(tx *gorm.DB, booking *models.Booking, now time.Time) error {
+	cfg, err := l.Config(tx, booking.YachtID)
+	if err != nil {
+		return err
+	}
+	if cfg.CancellationFeePercent == 0 || !booking.StartDate.Before(now.AddDate(0, 0, cfg.LateCancellationDays)) {
+		return nil
+	}
+
+	valuer, err := l.valuer(tx, booking.YachtID, cfg)
+	if err != nil {
+		return err
+	}
+	value := valuer.RangeValue(booking.StartDate, booking.EndDate)
+	fee := round2(value * cfg.CancellationFeePercent / 100)
+	if fee == 0 {
+		return nil
+	}
+
+	return tx.Create(&models.CreditLedgerEntry{
+		YachtID:   booking.YachtID,
+		UserID:    booking.UserID,
+		BookingID: &booking.ID,
+		EntryType: models.CreditEntryCancellationFee,
+		Year:      booking.StartDate.Year(),
+		Amount:    -fee,
+		Description: fmt.Sprintf("%.0f%% late cancellation fee for %s – %s", cfg.CancellationFeePercent,
+			booking.StartDate.Format("2 Jan 2006"), booking.EndDate.Format("2 Jan 2006")),
+	}).Error
+}
+
+// Balances returns every shareholder's credit balance as at the end of year
+func (l *Ledger) Balances(yachtID uuid.UUID, year int) ([]Balance, error) {
+	var balances []Balance
+
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		if err := l.EnsureAllocations(tx, yachtID, year); err != nil {
+			return err
+		}
+
+		yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		var shares []models.SyndicateShare
+		if err := tx.Scopes(HeldBetween(yearStart, yearStart.AddDate(1, 0, 0))).
+			Where("yacht_id = ?", yachtID).Order("joined_date ASC").Find(&shares).Error; err != nil {
+			return err
+		}
+
+		var totals []struct {
+			UserID    uuid.UUID
+			EntryType models.CreditEntryType
+			Total     float64
+		}
+		if err := tx.Model(&models.CreditLedgerEntry{}).
+			Select("user_id, entry_type, COALESCE(SUM(amount), 0) AS total").
+			Where("yacht_id = ? AND year <= ?", yachtID, year).
+			Group("user_id, entry_type").
+			Scan(&totals).Error; err != nil {
+			return err
+		}
+
+		index := make(map[uuid.UUID]int)
+		for _, share := range shares {
+			if i, ok := index[share.UserID]; ok {
+				balances[i].SharePercentage += share.SharePercentage
+				continue
+			}
+			index[share.UserID] = len(balances)
+			balances = append(balances, Balance{UserID: share.UserID, SharePercentage: share.SharePercentage})
+		}
+
+		for _, t := range totals {
+			i, ok := index[t.UserID]
+			if !ok {
+				// Former owner with ledger history
+				index[t.UserID] = len(balances)
+				i = len(balances)
+				balances = append(balances, Balance{UserID: t.UserID})
+			}
+
+			switch t.EntryType {
+			case models.CreditEntryAllocation, models.CreditEntryRollover, models.CreditEntryProRata:
+				balances[i].Allocated += t.Total
+			default:
+				balances[i].Used -= t.Total
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range balances {
+		balances[i].Allocated = round2(balances[i].Allocated)
+		balances[i].Used = round2(balances[i].Used)
+		balances[i].Balance = round2(balances[i].Allocated - balances[i].Used)
+	}
+
+	return balances, nil
+}
+
+// Entries returns an owner's ledger entries for a yacht up to the end of year
+func (l *Ledger) Entries(yachtID, userID uuid.UUID, year int) ([]models.CreditLedgerEntry, error) {
+	var entries []models.CreditLedgerEntry
+	err := l.db.Where("yacht_id = ? AND user_id = ? AND year <= ?", yachtID, userID, year).
+		Order("created_at DESC").
+		Find(&entries).Error
+	return entries, err
+}
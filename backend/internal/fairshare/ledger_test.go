@@ -0,0 +1,87 @@
+package fairshare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBookingSyncEntries tests the ledger entries posted when a booking
+// changes value, owner or year
+func TestBookingSyncEntries(t *testing.T) {
+	owner, previous := uuid.New(), uuid.New()
+	booking := &models.Booking{
+		ID:        uuid.New(),
+		YachtID:   uuid.New(),
+		UserID:    owner,
+		StartDate: time.Date(2026, time.January, 2, 10, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, time.January, 4, 10, 0, 0, 0, time.UTC),
+	}
+
+	type posted struct {
+		UserID uuid.UUID
+		Type   models.CreditEntryType
+		Year   int
+		Amount float64
+	}
+	tests := []struct {
+		name    string
+		desired float64
+		charges []bookingCharge
+		want    []posted
+	}{
+		{
+			name:    "new booking",
+			desired: 300,
+			want:    []posted{{owner, models.CreditEntryBookingDebit, 2026, -300}},
+		},
+		{
+			name:    "already charged",
+			desired: 300,
+			charges: []bookingCharge{{owner, 2026, 300}},
+		},
+		{
+			name:    "shortened",
+			desired: 200,
+			charges: []bookingCharge{{owner, 2026, 300}},
+			want:    []posted{{owner, models.CreditEntryBookingRefund, 2026, 100}},
+		},
+		{
+			name:    "cancelled",
+			charges: []bookingCharge{{owner, 2026, 300}},
+			want:    []posted{{owner, models.CreditEntryBookingRefund, 2026, 300}},
+		},
+		{
+			name:    "moved from December into January",
+			desired: 300,
+			charges: []bookingCharge{{owner, 2025, 250}},
+			want: []posted{
+				{owner, models.CreditEntryBookingRefund, 2025, 250},
+				{owner, models.CreditEntryBookingDebit, 2026, -300},
+			},
+		},
+		{
+			name:    "swapped to a new owner",
+			desired: 300,
+			charges: []bookingCharge{{previous, 2026, 300}},
+			want: []posted{
+				{previous, models.CreditEntryBookingRefund, 2026, 300},
+				{owner, models.CreditEntryBookingDebit, 2026, -300},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []posted
+			for _, e := range bookingSyncEntries(booking, tt.desired, tt.charges) {
+				assert.Equal(t, booking.ID, *e.BookingID)
+				got = append(got, posted{e.UserID, e.EntryType, e.Year, e.Amount})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package fairshare
+
+import (
+	"math"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PriorityWeights is the coefficient vector α of the priority function
+type PriorityWeights struct {
+	Ownership   float64 // α1, applied to the fractional ownership stake
+	Balance     float64 // α2, applied to the balance relative to the syndicate mean
+	Premium     float64 // α3, applied to the premium slot deficit
+	Recency     float64 // α4, applied to the days-since-last-booking bonus
+	Consecutive float64 // α5, applied to the number of consecutive days requested
+}
+
+// DefaultPriorityWeights returns α = (100, 20, 5, 1, 2)
+func DefaultPriorityWeights() PriorityWeights {
+	return PriorityWeights{
+		Ownership:   100,
+		Balance:     20,
+		Premium:     5,
+		Recency:     1,
+		Consecutive: 2,
+	}
+}
+
+const (
+	recencyBonusPerDay = 0.5
+	recencyBonusCap    = 10.0
+)
+
+// IsPremium reports whether a slot type counts towards premium slot fairness
+func IsPremium(slotType SlotType) bool {
+	return slotType == SlotPremiumWeekend || slotType == SlotPublicHoliday
+}
+
+// Standing captures the owner state that feeds the priority score
+type Standing struct {
+	UserID          uuid.UUID
+	SharePercentage float64
+	Balance         float64
+	PremiumSlotsYTD int
+	LastBookingEnd  *time.Time
+}
+
+// SyndicateStanding is the standing of every shareholder in a yacht
+type SyndicateStanding struct {
+	Owners          map[uuid.UUID]Standing
+	MeanBalance     float64
+	PremiumSlotsYTD int // Premium slots booked by all owners this year
+	Weights         PriorityWeights
+}
+
+// Score calculates the priority of an owner's request for a number of
+// consecutive days:
+//
+//	Π = α1·ω + α2·B̂ + α3·Δp + α4·ρ − α5·κ
+//
+// Unknown owners score zero.
+func (s SyndicateStanding) Score(userID uuid.UUID, requestedDays int, now time.Time) float64 {
+	owner, ok := s.Owners[userID]
+	if !ok {
+		return 0
+	}
+	w := s.Weights
+	ownership := owner.SharePercentage / 100
+
+	normalisedBalance := 1.0
+	if s.MeanBalance > 0 {
+		normalisedBalance = owner.Balance / s.MeanBalance
+	}
+
+	premiumDeficit := ownership*float64(s.PremiumSlotsYTD) - float64(owner.PremiumSlotsYTD)
+
+	recency := recencyBonusCap
+	if owner.LastBookingEnd != nil {
+		days := now.Sub(*owner.LastBookingEnd).Hours() / 24
+		recency = math.Max(0, math.Min(days*recencyBonusPerDay, recencyBonusCap))
+	}
+
+	score := w.Ownership*ownership +
+		w.Balance*normalisedBalance +
+		w.Premium*premiumDeficit +
+		w.Recency*recency -
+		w.Consecutive*float64(requestedDays)
+
+	return round2(score)
+}
+
+// Standing gathers the priority inputs for every owner holding a share in a
+// yacht over [start, end), the dates being competed for
+func (l *Ledger) Standing(tx *gorm.DB, yachtID uuid.UUID, start, end, now time.Time) (SyndicateStanding, error) {
+	standing := SyndicateStanding{
+		Owners:  make(map[uuid.UUID]Standing),
+		Weights: DefaultPriorityWeights(),
+	}
+
+	if err := l.EnsureAllocations(tx, yachtID, now.Year()); err != nil {
+		return standing, err
+	}
+
+	var shares []models.SyndicateShare
+	if err := tx.Scopes(HeldBetween(start, end)).Where("yacht_id = ?", yachtID).Find(&shares).Error; err != nil {
+		return standing, err
+	}
+	for _, share := range shares {
+		owner := standing.Owners[share.UserID]
+		owner.UserID = share.UserID
+		owner.SharePercentage += share.SharePercentage
+		standing.Owners[share.UserID] = owner
+	}
+	if len(standing.Owners) == 0 {
+		return standing, nil
+	}
+
+	var balances []struct {
+		UserID uuid.UUID
+		Total  float64
+	}
+	if err := tx.Model(&models.CreditLedgerEntry{}).
+		Select("user_id, COALESCE(SUM(amount), 0) AS total").
+		Where("yacht_id = ? AND year <= ?", yachtID, now.Year()).
+		Group("user_id").
+		Scan(&balances).Error; err != nil {
+		return standing, err
+	}
+	for _, b := range balances {
+		if owner, ok := standing.Owners[b.UserID]; ok {
+			owner.Balance = b.Total
+			standing.Owners[b.UserID] = owner
+		}
+	}
+
+	total := 0.0
+	for _, owner := range standing.Owners {
+		total += owner.Balance
+	}
+	standing.MeanBalance = total / float64(len(standing.Owners))
+
+	valuer, err := l.Valuer(tx, yachtID)
+	if err != nil {
+		return standing, err
+	}
+
+	liveStatuses := []models.BookingStatus{models.BookingStatusConfirmed, models.BookingStatusCompleted}
+
+	yearStart := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+	var bookings []models.Booking
+	if err := tx.Where("yacht_id = ? AND status IN ? AND end_date > ? AND start_date < ?",
+		yachtID, liveStatuses, yearStart, yearStart.AddDate(1, 0, 0)).
+		Find(&bookings).Error; err != nil {
+		return standing, err
+	}
+
+	for _, b := range bookings {
+		owner, ok := standing.Owners[b.UserID]
+		if !ok {
+			continue
+		}
+		for _, slot := range valuer.Slots(b.StartDate, b.EndDate) {
+			if slot.Date.Year() == now.Year() && IsPremium(slot.Type) {
+				owner.PremiumSlotsYTD++
+				standing.PremiumSlotsYTD++
+			}
+		}
+		standing.Owners[b.UserID] = owner
+	}
+
+	var lastBookings []struct {
+		UserID  uuid.UUID
+		LastEnd time.Time
+	}
+	if err := tx.Model(&models.Booking{}).
+		Select("user_id, MAX(end_date) AS last_end").
+		Where("yacht_id = ? AND status IN ? AND end_date < ?", yachtID, liveStatuses, now).
+		Group("user_id").
+		Scan(&lastBookings).Error; err != nil {
+		return standing, err
+	}
+	for _, lb := range lastBookings {
+		if owner, ok := standing.Owners[lb.UserID]; ok {
+			lastEnd := lb.LastEnd
+			owner.LastBookingEnd = &lastEnd
+			standing.Owners[lb.UserID] = owner
+		}
+	}
+
+	return standing, nil
+}
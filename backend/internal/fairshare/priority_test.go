@@ -0,0 +1,38 @@
+package fairshare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPriorityScore tests the weighted priority function
+func TestPriorityScore(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -4)
+
+	owed := uuid.New()
+	ahead := uuid.New()
+	standing := SyndicateStanding{
+		Owners: map[uuid.UUID]Standing{
+			// 50% owner with a full balance who has never booked
+			owed: {UserID: owed, SharePercentage: 50, Balance: 150},
+			// 50% owner who has taken every premium slot and sailed 4 days ago
+			ahead: {UserID: ahead, SharePercentage: 50, Balance: 50, PremiumSlotsYTD: 4, LastBookingEnd: &recent},
+		},
+		MeanBalance:     100,
+		PremiumSlotsYTD: 4,
+		Weights:         DefaultPriorityWeights(),
+	}
+
+	// 100·0.5 + 20·1.5 + 5·(2 − 0) + 1·10 − 2·2
+	assert.Equal(t, 96.0, standing.Score(owed, 2, now))
+
+	// 100·0.5 + 20·0.5 + 5·(2 − 4) + 1·2 − 2·2
+	assert.Equal(t, 48.0, standing.Score(ahead, 2, now))
+
+	// Non-shareholders have no priority
+	assert.Equal(t, 0.0, standing.Score(uuid.New(), 2, now))
+}
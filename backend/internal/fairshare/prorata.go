@@ -0,0 +1,125 @@
+package fairshare
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"gorm.io/gorm"
+)
+
+// HeldBetween limits a syndicate share query to shares held at some point in
+// [start, end). Shares without a joined date are treated as always held.
+func HeldBetween(start, end time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(joined_date IS NULL OR joined_date < ?) AND (ended_date IS NULL OR ended_date > ?)", end, start)
+	}
+}
+
+// HeldAt limits a syndicate share query to shares that have not ended by t
+func HeldAt(t time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(ended_date IS NULL OR ended_date > ?)", t)
+	}
+}
+
+// ShareFraction returns the fraction of the days in [start, end) during which
+// a share was held: from its joined date up to, but not including, the day it
+// ended
+func ShareFraction(share models.SyndicateShare, start, end time.Time) float64 {
+	start, end = dayOf(start), dayOf(end)
+	total := daysBetween(start, end)
+	if total <= 0 {
+		return 0
+	}
+
+	from, to := start, end
+	if !share.JoinedDate.IsZero() && dayOf(share.JoinedDate).After(from) {
+		from = dayOf(share.JoinedDate)
+	}
+	if share.EndedDate != nil && dayOf(*share.EndedDate).Before(to) {
+		to = dayOf(*share.EndedDate)
+	}
+
+	held := daysBetween(from, to)
+	if held <= 0 {
+		return 0
+	}
+	return float64(held) / float64(total)
+}
+
+// YearFraction returns the fraction of a calendar year a share was held
+func YearFraction(share models.SyndicateShare, year int) float64 {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return ShareFraction(share, start, start.AddDate(1, 0, 0))
+}
+
+// ProRatedDays returns a share's DaysPerYear entitlement for the part of the
+// year it was held
+func ProRatedDays(share models.SyndicateShare, year int) int {
+	return int(math.Round(float64(share.DaysPerYear) * YearFraction(share, year)))
+}
+
+// ProRateShare posts credit adjustments for years already allocated when a
+// share's holding period changes: a share added after its owner's allocation,
+// or an owner exiting partway through a year. previous is the share as it was
+// when allocated, or nil for a new share.
+func (l *Ledger) ProRateShare(tx *gorm.DB, previous *models.SyndicateShare, current models.SyndicateShare) error {
+	var years []int
+	if err := tx.Model(&models.CreditLedgerEntry{}).
+		Where("yacht_id = ? AND user_id = ? AND entry_type = ?",
+			current.YachtID, current.UserID, models.CreditEntryAllocation).
+		Distinct().
+		Order("year ASC").
+		Pluck("year", &years).Error; err != nil {
+		return err
+	}
+	if len(years) == 0 {
+		return nil
+	}
+
+	valuer, err := l.Valuer(tx, current.YachtID)
+	if err != nil {
+		return err
+	}
+
+	for _, year := range years {
+		delta := YearFraction(current, year)
+		if previous != nil {
+			delta -= YearFraction(*previous, year)
+		}
+		amount := round2(valuer.AnnualValue(year, time.Local) * current.SharePercentage / 100 * delta)
+		if amount == 0 {
+			continue
+		}
+
+		description := fmt.Sprintf("%d pro-rata adjustment for %.2f%% share", year, current.SharePercentage)
+		if current.EndedDate != nil {
+			description += " ending " + current.EndedDate.Format("2 Jan 2006")
+		} else if !current.JoinedDate.IsZero() {
+			description += " from " + current.JoinedDate.Format("2 Jan 2006")
+		}
+
+		if err := tx.Create(&models.CreditLedgerEntry{
+			YachtID:     current.YachtID,
+			UserID:      current.UserID,
+			EntryType:   models.CreditEntryProRata,
+			Year:        year,
+			Amount:      amount,
+			Description: description,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(start, end time.Time) int {
+	return int(end.Sub(start).Hours() / 24)
+}
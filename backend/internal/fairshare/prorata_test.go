@@ -0,0 +1,36 @@
+package fairshare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShareFraction tests pro-rating entitlements by the part of a period a
+// share was held
+func TestShareFraction(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	full := models.SyndicateShare{DaysPerYear: 30, JoinedDate: date(2020, time.January, 1)}
+	assert.Equal(t, 1.0, YearFraction(full, 2025))
+	assert.Equal(t, 30, ProRatedDays(full, 2025))
+
+	// Joined 1 July: 184 of 365 days
+	joined := models.SyndicateShare{DaysPerYear: 30, JoinedDate: date(2025, time.July, 1)}
+	assert.InDelta(t, 184.0/365, YearFraction(joined, 2025), 1e-9)
+	assert.Equal(t, 15, ProRatedDays(joined, 2025))
+	assert.Equal(t, 0.0, YearFraction(joined, 2024))
+
+	// Ended 1 April: held 1 January to 31 March
+	ended := date(2025, time.April, 1)
+	exited := models.SyndicateShare{DaysPerYear: 30, JoinedDate: date(2020, time.January, 1), EndedDate: &ended}
+	assert.InDelta(t, 90.0/365, YearFraction(exited, 2025), 1e-9)
+	assert.Equal(t, 0.0, YearFraction(exited, 2026))
+
+	// A quarterly levy for an owner who joined halfway through the quarter
+	mid := models.SyndicateShare{JoinedDate: date(2025, time.February, 15)}
+	assert.Equal(t, 0.5, ShareFraction(mid, date(2025, time.January, 1), date(2025, time.April, 1)))
+	assert.Equal(t, 1.0, ShareFraction(full, date(2025, time.January, 1), date(2025, time.April, 1)))
+}
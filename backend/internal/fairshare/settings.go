@@ -0,0 +1,150 @@
+package fairshare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/holidays"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ErrInvalidConfig is returned when syndicate settings fail validation
+var ErrInvalidConfig = errors.New("invalid syndicate settings")
+
+// Validate checks the configuration is usable
+func (c Config) Validate() error {
+	for _, slotType := range []SlotType{SlotStandardWeekday, SlotPeakWeekday, SlotStandardWeekend, SlotPremiumWeekend, SlotPublicHoliday} {
+		if c.Weights[slotType] <= 0 {
+			return fmt.Errorf("%w: %s weight must be positive", ErrInvalidConfig, slotType)
+		}
+	}
+
+	seen := make(map[time.Month]string)
+	for _, s := range c.Seasons {
+		if s.Name == "" {
+			return fmt.Errorf("%w: every season needs a name", ErrInvalidConfig)
+		}
+		if s.Multiplier <= 0 {
+			return fmt.Errorf("%w: season %s multiplier must be positive", ErrInvalidConfig, s.Name)
+		}
+		for _, m := range s.Months {
+			if m < time.January || m > time.December {
+				return fmt.Errorf("%w: season %s has an invalid month %d", ErrInvalidConfig, s.Name, m)
+			}
+			if other, ok := seen[m]; ok {
+				return fmt.Errorf("%w: %s is in both %s and %s", ErrInvalidConfig, m, other, s.Name)
+			}
+			seen[m] = s.Name
+		}
+	}
+
+	if _, err := holidays.NewCalendar(c.HolidayRegion, c.HolidayState); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	switch {
+	case c.RolloverDecay < 0 || c.RolloverDecay > 1:
+		return fmt.Errorf("%w: rollover_decay must be between 0 and 1", ErrInvalidConfig)
+	case c.MaxAdvanceDays < 1:
+		return fmt.Errorf("%w: max_advance_days must be at least 1", ErrInvalidConfig)
+	case c.CollectionLeadDays < 0 || c.CollectionLeadDays > c.MaxAdvanceDays:
+		return fmt.Errorf("%w: collection_lead_days must be between 0 and max_advance_days", ErrInvalidConfig)
+	case c.MaxConsecutiveDays < 1:
+		return fmt.Errorf("%w: max_consecutive_days must be at least 1", ErrInvalidConfig)
+	case c.MinTripGapDays < 0 || c.MinTripGapDays > 365:
+		return fmt.Errorf("%w: min_trip_gap_days must be between 0 and 365", ErrInvalidConfig)
+	case c.TurnaroundHours < 0 || c.TurnaroundHours > 168:
+		return fmt.Errorf("%w: turnaround_hours must be between 0 and 168", ErrInvalidConfig)
+	case c.CancellationFeePercent < 0 || c.CancellationFeePercent > 100:
+		return fmt.Errorf("%w: cancellation_fee_percent must be between 0 and 100", ErrInvalidConfig)
+	case c.LateCancellationDays < 0:
+		return fmt.Errorf("%w: late_cancellation_days cannot be negative", ErrInvalidConfig)
+	case c.DraftPickHours < 1:
+		return fmt.Errorf("%w: draft_pick_hours must be at least 1", ErrInvalidConfig)
+	case c.StandbyOfferHours < 1:
+		return fmt.Errorf("%w: standby_offer_hours must be at least 1", ErrInvalidConfig)
+	case c.SwapLockDays < 0:
+		return fmt.Errorf("%w: swap_lock_days cannot be negative", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// ConfigFromSettings builds the configuration stored for a syndicate
+func ConfigFromSettings(s models.SyndicateSettings) (Config, error) {
+	cfg := Config{
+		Weights: map[SlotType]float64{
+			SlotStandardWeekday: s.StandardWeekdayWeight,
+			SlotPeakWeekday:     s.PeakWeekdayWeight,
+			SlotStandardWeekend: s.WeekendWeight,
+			SlotPremiumWeekend:  s.PremiumWeekendWeight,
+			SlotPublicHoliday:   s.HolidayWeight,
+		},
+		RolloverDecay:      s.RolloverDecay,
+		HolidayRegion:      s.HolidayRegion,
+		HolidayState:       s.HolidayState,
+		CollectionLeadDays: s.CollectionLeadDays,
+
+		MaxAdvanceDays:         s.MaxAdvanceDays,
+		MaxConsecutiveDays:     s.MaxConsecutiveDays,
+		MinTripGapDays:         s.MinTripGapDays,
+		TurnaroundHours:        s.TurnaroundHours,
+		CancellationFeePercent: s.CancellationFeePercent,
+		LateCancellationDays:   s.LateCancellationDays,
+
+		DraftEnabled:      s.DraftEnabled,
+		DraftPickHours:    s.DraftPickHours,
+		StandbyOfferHours: s.StandbyOfferHours,
+		SwapLockDays:      s.SwapLockDays,
+	}
+
+	if len(s.Seasons) > 0 {
+		if err := json.Unmarshal(s.Seasons, &cfg.Seasons); err != nil {
+			return cfg, fmt.Errorf("%w: seasons: %v", ErrInvalidConfig, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Settings converts the configuration to the stored form for a yacht
+func (c Config) Settings(yachtID uuid.UUID) (models.SyndicateSettings, error) {
+	seasons := c.Seasons
+	if seasons == nil {
+		seasons = []Season{}
+	}
+	seasonsJSON, err := json.Marshal(seasons)
+	if err != nil {
+		return models.SyndicateSettings{}, err
+	}
+
+	return models.SyndicateSettings{
+		YachtID:               yachtID,
+		StandardWeekdayWeight: c.Weights[SlotStandardWeekday],
+		PeakWeekdayWeight:     c.Weights[SlotPeakWeekday],
+		WeekendWeight:         c.Weights[SlotStandardWeekend],
+		PremiumWeekendWeight:  c.Weights[SlotPremiumWeekend],
+		HolidayWeight:         c.Weights[SlotPublicHoliday],
+		Seasons:               datatypes.JSON(seasonsJSON),
+		RolloverDecay:         c.RolloverDecay,
+		HolidayRegion:         c.HolidayRegion,
+		HolidayState:          c.HolidayState,
+		CollectionLeadDays:    c.CollectionLeadDays,
+
+		MaxAdvanceDays:         c.MaxAdvanceDays,
+		MaxConsecutiveDays:     c.MaxConsecutiveDays,
+		MinTripGapDays:         c.MinTripGapDays,
+		TurnaroundHours:        c.TurnaroundHours,
+		CancellationFeePercent: c.CancellationFeePercent,
+		LateCancellationDays:   c.LateCancellationDays,
+
+		DraftEnabled:      c.DraftEnabled,
+		DraftPickHours:    c.DraftPickHours,
+		StandbyOfferHours: c.StandbyOfferHours,
+		SwapLockDays:      c.SwapLockDays,
+	}, nil
+}
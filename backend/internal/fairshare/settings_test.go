@@ -0,0 +1,45 @@
+package fairshare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigValidate tests rejection of unusable syndicate settings
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	cfg := DefaultConfig()
+	cfg.Seasons = append(cfg.Seasons, Season{Name: "Winter", Months: []time.Month{time.July}, Multiplier: 0.8})
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidConfig)
+
+	cfg = DefaultConfig()
+	cfg.RolloverDecay = 1.5
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidConfig)
+
+	cfg = DefaultConfig()
+	cfg.CollectionLeadDays = cfg.MaxAdvanceDays + 1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidConfig)
+
+	cfg = DefaultConfig()
+	cfg.TurnaroundHours = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidConfig)
+}
+
+// TestConfigSettingsRoundTrip tests storing and loading syndicate settings
+func TestConfigSettingsRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxConsecutiveDays = 10
+	cfg.DraftEnabled = false
+
+	settings, err := cfg.Settings(uuid.New())
+	require.NoError(t, err)
+
+	loaded, err := ConfigFromSettings(settings)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, loaded)
+}
@@ -0,0 +1,76 @@
+package fairshare
+
+import (
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Usage compares the slot value an owner used in a year with their
+// proportional share of everything the syndicate used
+type Usage struct {
+	UserID          uuid.UUID `json:"user_id"`
+	SharePercentage float64   `json:"share_percentage"`
+	Used            float64   `json:"used"`
+	Expected        float64   `json:"expected"` // Syndicate usage × ownership share
+}
+
+// AtOrBelowFairShare reports whether the owner has used no more than their
+// proportional share
+func (u Usage) AtOrBelowFairShare() bool {
+	return u.Used <= u.Expected
+}
+
+// Usage returns each current shareholder's usage for a year
+func (l *Ledger) Usage(tx *gorm.DB, yachtID uuid.UUID, year int) (map[uuid.UUID]Usage, error) {
+	usage := make(map[uuid.UUID]Usage)
+
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var shares []models.SyndicateShare
+	if err := tx.Scopes(HeldBetween(yearStart, yearStart.AddDate(1, 0, 0))).
+		Where("yacht_id = ?", yachtID).Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	totalShare := 0.0
+	for _, share := range shares {
+		u := usage[share.UserID]
+		u.UserID = share.UserID
+		u.SharePercentage += share.SharePercentage
+		usage[share.UserID] = u
+		totalShare += share.SharePercentage
+	}
+	if len(usage) == 0 {
+		return usage, nil
+	}
+
+	var used []struct {
+		UserID uuid.UUID
+		Total  float64
+	}
+	if err := tx.Model(&models.CreditLedgerEntry{}).
+		Select("user_id, COALESCE(-SUM(amount), 0) AS total").
+		Where("yacht_id = ? AND year = ? AND entry_type IN ?", yachtID, year,
+			[]models.CreditEntryType{models.CreditEntryBookingDebit, models.CreditEntryBookingRefund}).
+		Group("user_id").
+		Scan(&used).Error; err != nil {
+		return nil, err
+	}
+
+	totalUsed := 0.0
+	for _, u := range used {
+		totalUsed += u.Total
+		if owner, ok := usage[u.UserID]; ok {
+			owner.Used = round2(u.Total)
+			usage[u.UserID] = owner
+		}
+	}
+
+	for id, owner := range usage {
+		owner.Expected = round2(totalUsed * owner.SharePercentage / totalShare)
+		usage[id] = owner
+	}
+
+	return usage, nil
+}
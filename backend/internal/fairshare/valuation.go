@@ -0,0 +1,216 @@
+package fairshare
+
+import (
+	"math"
+	"time"
+)
+
+// SlotType classifies a day by desirability
+type SlotType string
+
+const (
+	SlotStandardWeekday SlotType = "standard_weekday"
+	SlotPeakWeekday     SlotType = "peak_weekday"
+	SlotStandardWeekend SlotType = "standard_weekend"
+	SlotPremiumWeekend  SlotType = "premium_weekend"
+	SlotPublicHoliday   SlotType = "public_holiday"
+)
+
+// Season applies a value multiplier to a set of months. Weekends in a peak
+// season are valued as premium weekends.
+type Season struct {
+	Name       string       `json:"name"`
+	Months     []time.Month `json:"months"`
+	Multiplier float64      `json:"multiplier"`
+	Peak       bool         `json:"peak"`
+}
+
+// Config holds the tunable parameters of the fair share algorithm and the
+// booking rules of a syndicate
+type Config struct {
+	Weights       map[SlotType]float64
+	Seasons       []Season
+	RolloverDecay float64 // Share of a positive balance carried into the next year
+
+	HolidayRegion string // Bundled holiday calendar, e.g. AU
+	HolidayState  string // State within the region; empty for national holidays only
+
+	// Requests for dates at least this many days away are collected and
+	// allocated by priority instead of first-come-first-served
+	CollectionLeadDays int
+
+	MaxAdvanceDays         int     // Furthest ahead a booking may start
+	MaxConsecutiveDays     int     // Longest single trip, including back-to-back bookings
+	MinTripGapDays         int     // Shortest gap between the end of an owner's trip and their next
+	TurnaroundHours        int     // Cleaning time kept free between different owners' bookings
+	CancellationFeePercent float64 // Share of a booking's value kept on late cancellation
+	LateCancellationDays   int     // Cancelling closer than this to departure incurs the fee
+
+	DraftEnabled      bool // Premium periods are allocated by snake draft
+	DraftPickHours    int  // Time each owner has to make a draft pick
+	StandbyOfferHours int  // Time an owner on standby has to accept freed dates
+	SwapLockDays      int  // Bookings starting this soon cannot be swapped
+}
+
+// DefaultConfig returns the weights and seasons from the fair share
+// specification (Australian seasons)
+func DefaultConfig() Config {
+	return Config{
+		Weights: map[SlotType]float64{
+			SlotStandardWeekday: 1.0,
+			SlotPeakWeekday:     1.5,
+			SlotStandardWeekend: 2.0,
+			SlotPremiumWeekend:  3.0,
+			SlotPublicHoliday:   3.5,
+		},
+		Seasons: []Season{
+			{Name: "Peak Summer", Months: []time.Month{time.December, time.January, time.February}, Multiplier: 1.5, Peak: true},
+			{Name: "Shoulder", Months: []time.Month{time.March, time.April, time.October, time.November}, Multiplier: 1.2},
+			{Name: "Off-Peak", Months: []time.Month{time.May, time.June, time.July, time.August, time.September}, Multiplier: 1.0},
+		},
+		RolloverDecay:      0.25,
+		HolidayRegion:      "AU",
+		CollectionLeadDays: 45,
+
+		MaxAdvanceDays:         365,
+		MaxConsecutiveDays:     14,
+		MinTripGapDays:         1,
+		TurnaroundHours:        4,
+		CancellationFeePercent: 10,
+		LateCancellationDays:   7,
+
+		DraftEnabled:      true,
+		DraftPickHours:    24,
+		StandbyOfferHours: 12,
+		SwapLockDays:      7,
+	}
+}
+
+// Calendar reports which dates are public or school holidays
+type Calendar interface {
+	IsPublicHoliday(date time.Time) bool
+	IsSchoolHoliday(date time.Time) bool
+}
+
+// NoHolidays is a Calendar with no holidays
+type NoHolidays struct{}
+
+func (NoHolidays) IsPublicHoliday(time.Time) bool { return false }
+func (NoHolidays) IsSchoolHoliday(time.Time) bool { return false }
+
+// Slot is the valuation of a single day
+type Slot struct {
+	Date       time.Time `json:"date"`
+	Type       SlotType  `json:"slot_type"`
+	BaseWeight float64   `json:"base_weight"`
+	Multiplier float64   `json:"season_multiplier"`
+	Value      float64   `json:"value"`
+}
+
+// Valuer values days and date ranges for a yacht
+type Valuer struct {
+	config   Config
+	calendar Calendar
+}
+
+// NewValuer creates a valuer. A nil calendar means no holidays.
+func NewValuer(config Config, calendar Calendar) *Valuer {
+	if calendar == nil {
+		calendar = NoHolidays{}
+	}
+	return &Valuer{config: config, calendar: calendar}
+}
+
+// season returns the season containing the date's month, if any
+func (v *Valuer) season(date time.Time) (Season, bool) {
+	for _, s := range v.config.Seasons {
+		for _, m := range s.Months {
+			if m == date.Month() {
+				return s, true
+			}
+		}
+	}
+	return Season{}, false
+}
+
+// Classify returns the slot type of a date
+func (v *Valuer) Classify(date time.Time) SlotType {
+	if v.calendar.IsPublicHoliday(date) {
+		return SlotPublicHoliday
+	}
+
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		if s, ok := v.season(date); ok && s.Peak {
+			return SlotPremiumWeekend
+		}
+		return SlotStandardWeekend
+	}
+
+	if v.calendar.IsSchoolHoliday(date) {
+		return SlotPeakWeekday
+	}
+	return SlotStandardWeekday
+}
+
+// Slot values a single date: SlotValue = BaseWeight × SeasonMultiplier
+func (v *Valuer) Slot(date time.Time) Slot {
+	day := truncateToDay(date)
+	slotType := v.Classify(day)
+
+	multiplier := 1.0
+	if s, ok := v.season(day); ok {
+		multiplier = s.Multiplier
+	}
+
+	weight := v.config.Weights[slotType]
+	return Slot{
+		Date:       day,
+		Type:       slotType,
+		BaseWeight: weight,
+		Multiplier: multiplier,
+		Value:      round2(weight * multiplier),
+	}
+}
+
+// Slots values every calendar day touched by [start, end). An end time of
+// exactly midnight does not occupy that day.
+func (v *Valuer) Slots(start, end time.Time) []Slot {
+	if !end.After(start) {
+		return nil
+	}
+
+	first := truncateToDay(start)
+	last := truncateToDay(end)
+	if last.Equal(end) {
+		last = last.AddDate(0, 0, -1)
+	}
+
+	var slots []Slot
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		slots = append(slots, v.Slot(day))
+	}
+	return slots
+}
+
+// RangeValue returns the total slot value of [start, end)
+func (v *Valuer) RangeValue(start, end time.Time) float64 {
+	total := 0.0
+	for _, s := range v.Slots(start, end) {
+		total += s.Value
+	}
+	return round2(total)
+}
+
+// AnnualValue returns the total slot value of a calendar year
+func (v *Valuer) AnnualValue(year int, loc *time.Location) float64 {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	return v.RangeValue(start, start.AddDate(1, 0, 0))
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,69 @@
+package fairshare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedCalendar is a Calendar backed by date sets
+type fixedCalendar struct {
+	public map[string]bool
+	school map[string]bool
+}
+
+func (c fixedCalendar) IsPublicHoliday(d time.Time) bool { return c.public[d.Format("2006-01-02")] }
+func (c fixedCalendar) IsSchoolHoliday(d time.Time) bool { return c.school[d.Format("2006-01-02")] }
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// TestSlotValue tests slot classification and seasonal multipliers
+func TestSlotValue(t *testing.T) {
+	calendar := fixedCalendar{
+		public: map[string]bool{"2026-01-26": true},
+		school: map[string]bool{"2026-07-06": true},
+	}
+	valuer := NewValuer(DefaultConfig(), calendar)
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		slotType SlotType
+		value    float64
+	}{
+		{"Saturday in January", date(2026, time.January, 10), SlotPremiumWeekend, 4.5},
+		{"Australia Day", date(2026, time.January, 26), SlotPublicHoliday, 5.25},
+		{"Weekday in summer", date(2026, time.January, 14), SlotStandardWeekday, 1.5},
+		{"Saturday in March", date(2026, time.March, 14), SlotStandardWeekend, 2.4},
+		{"School holiday in July", date(2026, time.July, 6), SlotPeakWeekday, 1.5},
+		{"Weekday in June", date(2026, time.June, 10), SlotStandardWeekday, 1.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot := valuer.Slot(tt.date)
+			assert.Equal(t, tt.slotType, slot.Type)
+			assert.Equal(t, tt.value, slot.Value)
+		})
+	}
+}
+
+// TestRangeValue tests that a range is valued per calendar day touched
+func TestRangeValue(t *testing.T) {
+	valuer := NewValuer(DefaultConfig(), nil)
+
+	// Fri 10am to Sun 7pm in June: 1.0 + 2.0 + 2.0
+	start := time.Date(2026, time.June, 12, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2026, time.June, 14, 19, 0, 0, 0, time.UTC)
+	assert.Len(t, valuer.Slots(start, end), 3)
+	assert.Equal(t, 5.0, valuer.RangeValue(start, end))
+
+	// Ending exactly at midnight does not occupy the next day
+	assert.Equal(t, 1.0, valuer.RangeValue(date(2026, time.June, 12), date(2026, time.June, 13)))
+
+	// Empty and inverted ranges are worth nothing
+	assert.Equal(t, 0.0, valuer.RangeValue(end, start))
+}
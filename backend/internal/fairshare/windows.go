@@ -0,0 +1,54 @@
+package fairshare
+
+import "time"
+
+// WindowRule opens dates for booking a number of days ahead. A date covered
+// by at least one rule can only be booked once a rule the owner is eligible
+// for has opened it; dates no rule covers are always open.
+type WindowRule struct {
+	Name               string
+	OpensDaysAhead     int
+	PremiumOnly        bool // Applies to premium weekends and public holidays only
+	BelowFairShareOnly bool // Only owners at or below their fair share are eligible
+}
+
+// Covers reports whether the rule applies to a slot type
+func (r WindowRule) Covers(slotType SlotType) bool {
+	return !r.PremiumOnly || IsPremium(slotType)
+}
+
+// OpensAt returns when the rule opens a date
+func (r WindowRule) OpensAt(day time.Time) time.Time {
+	return truncateToDay(day).AddDate(0, 0, -r.OpensDaysAhead)
+}
+
+// WindowRules is the set of booking window rules for a yacht
+type WindowRules []WindowRule
+
+// OpensAt returns the earliest time a date opens to an owner and the rule
+// that opens it. restricted is false when no rule covers the date. A nil rule
+// on a restricted date means no rule will ever open it to the owner.
+func (rules WindowRules) OpensAt(day time.Time, slotType SlotType, belowFairShare bool) (opensAt time.Time, rule *WindowRule, restricted bool) {
+	for i, r := range rules {
+		if !r.Covers(slotType) {
+			continue
+		}
+		restricted = true
+		if r.BelowFairShareOnly && !belowFairShare {
+			continue
+		}
+		if at := r.OpensAt(day); rule == nil || at.Before(opensAt) {
+			opensAt, rule = at, &rules[i]
+		}
+	}
+	return opensAt, rule, restricted
+}
+
+// IsOpen reports whether a date is open to an owner at a point in time
+func (rules WindowRules) IsOpen(day time.Time, slotType SlotType, belowFairShare bool, now time.Time) bool {
+	opensAt, rule, restricted := rules.OpensAt(day, slotType, belowFairShare)
+	if !restricted {
+		return true
+	}
+	return rule != nil && !now.Before(opensAt)
+}
@@ -0,0 +1,37 @@
+package fairshare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWindowRules tests phased release of premium dates
+func TestWindowRules(t *testing.T) {
+	rules := WindowRules{
+		{Name: "Priority release", OpensDaysAhead: 180, PremiumOnly: true, BelowFairShareOnly: true},
+		{Name: "General release", OpensDaysAhead: 90, PremiumOnly: true},
+	}
+	day := time.Date(2026, time.December, 26, 0, 0, 0, 0, time.UTC)
+
+	// Owners below their fair share get the earlier window
+	opensAt, rule, restricted := rules.OpensAt(day, SlotPremiumWeekend, true)
+	assert.True(t, restricted)
+	assert.Equal(t, "Priority release", rule.Name)
+	assert.Equal(t, day.AddDate(0, 0, -180), opensAt)
+
+	// Everyone else waits for the general release
+	opensAt, rule, _ = rules.OpensAt(day, SlotPremiumWeekend, false)
+	assert.Equal(t, "General release", rule.Name)
+	assert.Equal(t, day.AddDate(0, 0, -90), opensAt)
+
+	now := day.AddDate(0, 0, -100)
+	assert.True(t, rules.IsOpen(day, SlotPremiumWeekend, true, now))
+	assert.False(t, rules.IsOpen(day, SlotPremiumWeekend, false, now))
+
+	// Dates no rule covers are always open
+	_, _, restricted = rules.OpensAt(day, SlotStandardWeekday, false)
+	assert.False(t, restricted)
+	assert.True(t, rules.IsOpen(day, SlotStandardWeekday, false, day.AddDate(-1, 0, 0)))
+}
@@ -0,0 +1,123 @@
+package holidays
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calendar answers holiday lookups for one state of a region plus any custom
+// dates. It satisfies fairshare.Calendar and is safe for concurrent use.
+type Calendar struct {
+	region *Region // Nil for custom dates only
+	state  string
+	custom []Holiday
+
+	mu    sync.Mutex
+	years map[int]map[string]map[Kind]bool // Date key to kinds, built on first lookup
+}
+
+// NewCalendar returns the calendar for a region and state. An empty region
+// code gives a calendar with no bundled holidays.
+func NewCalendar(regionCode, state string) (*Calendar, error) {
+	c := &Calendar{state: strings.ToUpper(state), years: make(map[int]map[string]map[Kind]bool)}
+	if regionCode == "" {
+		return c, nil
+	}
+
+	region, err := Lookup(regionCode)
+	if err != nil {
+		return nil, err
+	}
+	if !region.HasState(state) {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnknownState, region.Code, state)
+	}
+	c.region = region
+	return c, nil
+}
+
+// Add includes custom holidays, such as a regatta weekend, in the calendar
+func (c *Calendar) Add(holidays ...Holiday) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, h := range holidays {
+		h.Start = dateOnly(h.Start)
+		h.End = dateOnly(h.End)
+		h.Custom = true
+		c.custom = append(c.custom, h)
+	}
+	c.years = make(map[int]map[string]map[Kind]bool)
+}
+
+// Holidays returns the year's holidays ordered by start date
+func (c *Calendar) Holidays(year int) ([]Holiday, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.holidays(year)
+}
+
+func (c *Calendar) holidays(year int) ([]Holiday, error) {
+	var list []Holiday
+	if c.region != nil {
+		var err error
+		list, err = c.region.Holidays(year, c.state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, h := range c.custom {
+		if h.Start.Year() <= year && h.End.Year() >= year {
+			list = append(list, h)
+		}
+	}
+
+	sortHolidays(list)
+	return list, nil
+}
+
+// IsPublicHoliday reports whether a date is a public holiday
+func (c *Calendar) IsPublicHoliday(date time.Time) bool {
+	return c.is(date, KindPublic)
+}
+
+// IsSchoolHoliday reports whether a date falls in school holidays
+func (c *Calendar) IsSchoolHoliday(date time.Time) bool {
+	return c.is(date, KindSchool)
+}
+
+func (c *Calendar) is(date time.Time, kind Kind) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	days, ok := c.years[date.Year()]
+	if !ok {
+		days = make(map[string]map[Kind]bool)
+		// Bundled data is checked by tests, so a lookup error only means a
+		// year without holidays
+		list, _ := c.holidays(date.Year())
+		for _, h := range list {
+			for d := h.Start; !d.After(h.End); d = d.AddDate(0, 0, 1) {
+				key := dateKey(d)
+				if days[key] == nil {
+					days[key] = make(map[Kind]bool)
+				}
+				days[key][h.Kind] = true
+			}
+		}
+		c.years[date.Year()] = days
+	}
+
+	return days[dateKey(date)][kind]
+}
+
+// dateKey identifies a calendar day regardless of time zone
+func dateKey(d time.Time) string {
+	return d.Format("2006-01-02")
+}
+
+func dateOnly(d time.Time) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+}
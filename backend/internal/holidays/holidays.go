@@ -0,0 +1,357 @@
+// Package holidays provides public and school holiday calendars for the
+// regions YachtLife operates in. Holiday sets are loaded from the data files
+// bundled with the binary.
+package holidays
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed data/*.json
+var dataFiles embed.FS
+
+var (
+	ErrUnknownRegion = errors.New("unknown holiday region")
+	ErrUnknownState  = errors.New("unknown state for holiday region")
+)
+
+// Kind distinguishes public holidays from school holidays
+type Kind string
+
+const (
+	KindPublic Kind = "public"
+	KindSchool Kind = "school"
+)
+
+// Holiday is a named holiday spanning Start to End inclusive
+type Holiday struct {
+	Name   string    `json:"name"`
+	Kind   Kind      `json:"kind"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Custom bool      `json:"custom,omitempty"` // Added by a manager rather than bundled
+}
+
+// Substitution policies for public holidays falling on a weekend
+const (
+	substituteNextWeekday    = "next_weekday"    // Following Monday, or the next free weekday (AU, NZ, UK)
+	substituteNearestWeekday = "nearest_weekday" // Friday for Saturday, Monday for Sunday (US)
+)
+
+// Region is a country's holiday data
+type Region struct {
+	Code   string            `json:"code"`
+	Name   string            `json:"name"`
+	States map[string]string `json:"states"` // State or region code to name
+	Notes  string            `json:"notes,omitempty"`
+
+	substitute string
+	public     []rule
+	school     []schoolBreak
+}
+
+// rule describes how to find a public holiday's date in a given year. Exactly
+// one of Dates, Easter, Weekday or Month/Day is used.
+type rule struct {
+	Name       string     `json:"name"`
+	Month      time.Month `json:"month"`
+	Day        int        `json:"day"`
+	Weekday    string     `json:"weekday"` // With Month: the Nth such weekday on or after Day
+	Nth        int        `json:"nth"`     // -1 for the last in the month
+	Easter     *int       `json:"easter"`  // Days after Easter Sunday
+	Dates      []string   `json:"dates"`   // Holidays with no rule, e.g. Matariki
+	Substitute bool       `json:"substitute"`
+	States     []string   `json:"states"` // Empty for national holidays
+}
+
+type schoolBreak struct {
+	Name   string   `json:"name"`
+	Start  string   `json:"start"`
+	End    string   `json:"end"`
+	States []string `json:"states"`
+}
+
+type regionFile struct {
+	Code           string            `json:"code"`
+	Name           string            `json:"name"`
+	Substitute     string            `json:"substitute"`
+	States         map[string]string `json:"states"`
+	Notes          string            `json:"notes"`
+	PublicHolidays []rule            `json:"public_holidays"`
+	SchoolHolidays []schoolBreak     `json:"school_holidays"`
+}
+
+var (
+	loadOnce sync.Once
+	regions  map[string]*Region
+	loadErr  error
+)
+
+func load() {
+	regions = make(map[string]*Region)
+	entries, err := dataFiles.ReadDir("data")
+	if err != nil {
+		loadErr = err
+		return
+	}
+	for _, entry := range entries {
+		raw, err := dataFiles.ReadFile(path.Join("data", entry.Name()))
+		if err != nil {
+			loadErr = err
+			return
+		}
+		var f regionFile
+		if err := json.Unmarshal(raw, &f); err != nil {
+			loadErr = fmt.Errorf("holidays: %s: %w", entry.Name(), err)
+			return
+		}
+		regions[f.Code] = &Region{
+			Code:       f.Code,
+			Name:       f.Name,
+			States:     f.States,
+			Notes:      f.Notes,
+			substitute: f.Substitute,
+			public:     f.PublicHolidays,
+			school:     f.SchoolHolidays,
+		}
+	}
+}
+
+// Regions returns every bundled region ordered by code
+func Regions() ([]*Region, error) {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	list := make([]*Region, 0, len(regions))
+	for _, r := range regions {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list, nil
+}
+
+// Lookup returns a region by its code, e.g. "AU"
+func Lookup(code string) (*Region, error) {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	r, ok := regions[strings.ToUpper(code)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRegion, code)
+	}
+	return r, nil
+}
+
+// HasState reports whether the region has holiday data for a state. The
+// empty state, meaning national holidays only, is always valid.
+func (r *Region) HasState(state string) bool {
+	if state == "" {
+		return true
+	}
+	_, ok := r.States[strings.ToUpper(state)]
+	return ok
+}
+
+// Holidays returns the public and school holidays observed in a state during
+// a year, ordered by start date. An empty state returns national holidays
+// only. Public holidays falling on a weekend are listed on the day and again
+// on the weekday they are observed.
+func (r *Region) Holidays(year int, state string) ([]Holiday, error) {
+	if !r.HasState(state) {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnknownState, r.Code, state)
+	}
+	state = strings.ToUpper(state)
+
+	var list []Holiday
+
+	// Observed days can cross into the neighbouring year
+	for y := year - 1; y <= year+1; y++ {
+		for _, h := range r.publicHolidays(y, state) {
+			if h.Start.Year() == year {
+				list = append(list, h)
+			}
+		}
+	}
+
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	for _, b := range r.school {
+		if !appliesTo(b.States, state) {
+			continue
+		}
+		start, err := parseDate(b.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseDate(b.End)
+		if err != nil {
+			return nil, err
+		}
+		if end.Before(first) || start.After(last) {
+			continue
+		}
+		list = append(list, Holiday{Name: b.Name, Kind: KindSchool, Start: start, End: end})
+	}
+
+	sortHolidays(list)
+	return list, nil
+}
+
+// publicHolidays returns a year's public holidays and their observed days
+func (r *Region) publicHolidays(year int, state string) []Holiday {
+	var list []Holiday
+	taken := make(map[time.Time]bool)
+	var weekend []Holiday
+
+	for _, rl := range r.public {
+		if !appliesTo(rl.States, state) {
+			continue
+		}
+		for _, d := range rl.dates(year) {
+			h := Holiday{Name: rl.Name, Kind: KindPublic, Start: d, End: d}
+			list = append(list, h)
+			taken[d] = true
+			if rl.Substitute && isWeekend(d) {
+				weekend = append(weekend, h)
+			}
+		}
+	}
+
+	sortHolidays(weekend)
+	for _, h := range weekend {
+		observed := r.observedDay(h.Start, taken)
+		taken[observed] = true
+		list = append(list, Holiday{Name: h.Name + " (observed)", Kind: KindPublic, Start: observed, End: observed})
+	}
+
+	return list
+}
+
+// observedDay returns the weekday a weekend public holiday moves to
+func (r *Region) observedDay(d time.Time, taken map[time.Time]bool) time.Time {
+	if r.substitute == substituteNearestWeekday {
+		if d.Weekday() == time.Saturday {
+			return d.AddDate(0, 0, -1)
+		}
+		return d.AddDate(0, 0, 1)
+	}
+
+	for d = d.AddDate(0, 0, 1); isWeekend(d) || taken[d]; d = d.AddDate(0, 0, 1) {
+	}
+	return d
+}
+
+// dates returns the days a rule falls on in a year
+func (rl rule) dates(year int) []time.Time {
+	switch {
+	case len(rl.Dates) > 0:
+		var days []time.Time
+		for _, s := range rl.Dates {
+			if d, err := parseDate(s); err == nil && d.Year() == year {
+				days = append(days, d)
+			}
+		}
+		return days
+	case rl.Easter != nil:
+		return []time.Time{EasterSunday(year).AddDate(0, 0, *rl.Easter)}
+	case rl.Weekday != "":
+		weekday, ok := parseWeekday(rl.Weekday)
+		if !ok {
+			return nil
+		}
+		return []time.Time{nthWeekday(year, rl.Month, rl.Day, weekday, rl.Nth)}
+	default:
+		return []time.Time{time.Date(year, rl.Month, rl.Day, 0, 0, 0, 0, time.UTC)}
+	}
+}
+
+// nthWeekday returns the nth weekday of a month counting from a day (the
+// 1st if zero), or the last one if nth is negative
+func nthWeekday(year int, month time.Month, from int, weekday time.Weekday, nth int) time.Time {
+	if nth < 0 {
+		d := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+		for d.Weekday() != weekday {
+			d = d.AddDate(0, 0, -1)
+		}
+		return d
+	}
+
+	if from < 1 {
+		from = 1
+	}
+	d := time.Date(year, month, from, 0, 0, 0, 0, time.UTC)
+	for d.Weekday() != weekday {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d.AddDate(0, 0, 7*(nth-1))
+}
+
+// EasterSunday returns the date of Western Easter (anonymous Gregorian
+// algorithm)
+func EasterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+func appliesTo(states []string, state string) bool {
+	if len(states) == 0 {
+		return true
+	}
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), s) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func parseDate(s string) (time.Time, error) {
+	return time.Parse("2006-01-02", s)
+}
+
+func isWeekend(d time.Time) bool {
+	return d.Weekday() == time.Saturday || d.Weekday() == time.Sunday
+}
+
+func sortHolidays(list []Holiday) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if !list[i].Start.Equal(list[j].Start) {
+			return list[i].Start.Before(list[j].Start)
+		}
+		return list[i].Kind < list[j].Kind
+	})
+}
@@ -0,0 +1,84 @@
+package holidays
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// TestBundledData tests every bundled region loads and lists holidays for
+// each of its states
+func TestBundledData(t *testing.T) {
+	regions, err := Regions()
+	require.NoError(t, err)
+
+	codes := make([]string, len(regions))
+	for i, r := range regions {
+		codes[i] = r.Code
+		for state := range r.States {
+			list, err := r.Holidays(2026, state)
+			require.NoError(t, err, "%s %s", r.Code, state)
+			assert.NotEmpty(t, list, "%s %s", r.Code, state)
+			for _, h := range list {
+				assert.False(t, h.End.Before(h.Start), "%s %s %s", r.Code, state, h.Name)
+			}
+		}
+	}
+	assert.Equal(t, []string{"AU", "NZ", "UK", "US"}, codes)
+
+	_, err = Lookup("xx")
+	assert.ErrorIs(t, err, ErrUnknownRegion)
+	_, err = NewCalendar("AU", "XX")
+	assert.ErrorIs(t, err, ErrUnknownState)
+}
+
+// TestEasterSunday tests the Easter calculation
+func TestEasterSunday(t *testing.T) {
+	assert.Equal(t, date(2025, time.April, 20), EasterSunday(2025))
+	assert.Equal(t, date(2026, time.April, 5), EasterSunday(2026))
+	assert.Equal(t, date(2027, time.March, 28), EasterSunday(2027))
+}
+
+// TestCalendarLookup tests rule-based dates, weekend substitution and
+// custom dates
+func TestCalendarLookup(t *testing.T) {
+	qld, err := NewCalendar("AU", "qld")
+	require.NoError(t, err)
+
+	assert.True(t, qld.IsPublicHoliday(date(2026, time.October, 5)), "QLD King's Birthday")
+	assert.False(t, qld.IsPublicHoliday(date(2026, time.June, 8)), "King's Birthday elsewhere")
+	assert.True(t, qld.IsPublicHoliday(date(2026, time.April, 3)), "Good Friday")
+	assert.True(t, qld.IsSchoolHoliday(date(2026, time.July, 1)))
+	assert.False(t, qld.IsSchoolHoliday(date(2026, time.August, 1)))
+
+	// Christmas 2027 is a Saturday and Boxing Day a Sunday
+	assert.True(t, qld.IsPublicHoliday(date(2027, time.December, 27)))
+	assert.True(t, qld.IsPublicHoliday(date(2027, time.December, 28)))
+	assert.False(t, qld.IsPublicHoliday(date(2027, time.December, 29)))
+
+	// US holidays on a Saturday are observed on the Friday, even across years
+	us, err := NewCalendar("US", "")
+	require.NoError(t, err)
+	assert.True(t, us.IsPublicHoliday(date(2026, time.July, 3)))
+	assert.True(t, us.IsPublicHoliday(date(2027, time.December, 31)))
+
+	// A regatta weekend added by a manager
+	qld.Add(Holiday{Name: "Brisbane to Gladstone", Kind: KindPublic,
+		Start: date(2027, time.April, 2), End: date(2027, time.April, 4)})
+	assert.True(t, qld.IsPublicHoliday(date(2027, time.April, 3)))
+	list, err := qld.Holidays(2027)
+	require.NoError(t, err)
+	var custom int
+	for _, h := range list {
+		if h.Custom {
+			custom++
+		}
+	}
+	assert.Equal(t, 1, custom)
+}
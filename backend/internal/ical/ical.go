@@ -0,0 +1,122 @@
+// Package ical renders iCalendar (RFC 5545) feeds
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event statuses
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+// Longest content line in octets, excluding the line break
+const maxLineOctets = 75
+
+// Calendar is a VCALENDAR of events
+type Calendar struct {
+	ProdID string // e.g. -//YachtLife//Bookings//EN
+	Name   string // Shown by clients that support X-WR-CALNAME
+	Events []Event
+}
+
+// Event is a VEVENT. UID must stay the same for the life of the underlying
+// record, and Sequence must increase whenever it changes, so clients update
+// or remove their copy rather than adding a new one.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time // Exclusive; for all-day events the day after the last
+	AllDay       bool
+	Status       string
+	Sequence     int
+	Stamp        time.Time
+	LastModified time.Time
+}
+
+// Bytes renders the calendar with CRLF line endings and long lines folded
+func (c *Calendar) Bytes() []byte {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeFolded(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", Escape(c.Name))
+	}
+
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", formatUTC(e.Stamp))
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE", e.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE", e.End.Format("20060102"))
+		} else {
+			line("DTSTART", formatUTC(e.Start))
+			line("DTEND", formatUTC(e.End))
+		}
+		line("SUMMARY", Escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", Escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", Escape(e.Location))
+		}
+		if e.Status != "" {
+			line("STATUS", e.Status)
+		}
+		line("SEQUENCE", fmt.Sprint(e.Sequence))
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED", formatUTC(e.LastModified))
+		}
+		line("TRANSP", "OPAQUE")
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+// Escape escapes a TEXT property value
+func Escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+	return r.Replace(s)
+}
+
+// writeFolded writes a content line, folding it onto continuation lines
+// starting with a space so no line exceeds 75 octets. Lines are only broken
+// between UTF-8 characters.
+func writeFolded(buf *bytes.Buffer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // Allow for the leading space
+	}
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,64 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCalendarBytes tests rendering events as RFC 5545 content lines
+func TestCalendarBytes(t *testing.T) {
+	stamp := time.Date(2026, time.March, 1, 9, 30, 0, 0, time.UTC)
+	brisbane := time.FixedZone("AEST", 10*60*60)
+
+	cal := Calendar{
+		ProdID: "-//YachtLife//Bookings//EN",
+		Name:   "Sea Breeze",
+		Events: []Event{
+			{
+				UID:     "booking-1@yachtlife",
+				Summary: "Sea Breeze; weekend, family",
+				Start:   time.Date(2026, time.March, 6, 10, 0, 0, 0, brisbane),
+				End:     time.Date(2026, time.March, 8, 16, 0, 0, 0, brisbane),
+				Status:  StatusConfirmed,
+				Stamp:   stamp,
+			},
+			{
+				UID:         "maintenance-1@yachtlife",
+				Summary:     "Antifouling",
+				Description: strings.Repeat("Haul out and antifoul the hull. ", 4),
+				Start:       time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+				End:         time.Date(2026, time.April, 4, 0, 0, 0, 0, time.UTC),
+				AllDay:      true,
+				Sequence:    2,
+				Stamp:       stamp,
+			},
+		},
+	}
+
+	out := string(cal.Bytes())
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "X-WR-CALNAME:Sea Breeze\r\n")
+
+	// Timed events are converted to UTC
+	assert.Contains(t, out, "DTSTART:20260306T000000Z\r\n")
+	assert.Contains(t, out, "DTEND:20260308T060000Z\r\n")
+	assert.Contains(t, out, `SUMMARY:Sea Breeze\; weekend\, family`+"\r\n")
+
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20260401\r\n")
+	assert.Contains(t, out, "DTEND;VALUE=DATE:20260404\r\n")
+	assert.Contains(t, out, "SEQUENCE:2\r\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+	assert.Contains(t, strings.ReplaceAll(out, "\r\n ", ""), "DESCRIPTION:"+strings.Repeat("Haul out and antifoul the hull. ", 4))
+}
+
+// TestEscape tests escaping TEXT values
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a\\b\;c\,d\ne`, Escape("a\\b;c,d\ne"))
+}
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job is a unit of background work run on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner runs registered jobs until its context is cancelled
+type Runner struct {
+	jobs []Job
+}
+
+// NewRunner creates a new job runner
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Register adds a job to the runner. Jobs must be registered before Start.
+func (r *Runner) Register(job Job) {
+	r.jobs = append(r.jobs, job)
+}
+
+// Start launches every registered job in its own goroutine
+func (r *Runner) Start(ctx context.Context) {
+	for _, job := range r.jobs {
+		go r.loop(ctx, job)
+	}
+	log.Printf("⏱️  Started %d background jobs", len(r.jobs))
+}
+
+func (r *Runner) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil {
+			log.Printf("❌ Job %s failed: %v", job.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
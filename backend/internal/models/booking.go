@@ -13,6 +13,8 @@ const (
 	BookingStatusConfirmed BookingStatus = "confirmed"
 	BookingStatusCancelled BookingStatus = "cancelled"
 	BookingStatusCompleted BookingStatus = "completed"
+	BookingStatusStandby   BookingStatus = "standby"  // Lost a priority resolution, waiting for the slot to free up
+	BookingStatusRejected  BookingStatus = "rejected" // Lost a priority resolution
 )
 
 type Booking struct {
@@ -24,8 +26,16 @@ type Booking struct {
 	Status      BookingStatus `gorm:"type:varchar(20);not null;index;default:'pending'" json:"status"`
 	Notes       string        `gorm:"type:text" json:"notes,omitempty"`
 	CancelledAt *time.Time    `json:"cancelled_at,omitempty"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
+
+	// Priority resolution of competing requests
+	AwaitingResolution bool       `gorm:"not null;default:false;index" json:"awaiting_resolution"`
+	StandbyIfOutranked bool       `gorm:"not null;default:false" json:"standby_if_outranked"`
+	PriorityScore      *float64   `gorm:"type:decimal(10,2)" json:"priority_score,omitempty"`
+	ResolutionNote     string     `gorm:"type:text" json:"resolution_note,omitempty"`
+	ResolvedAt         *time.Time `json:"resolved_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 
 	// Relationships
 	Yacht Yacht `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"yacht,omitempty"`
@@ -45,19 +55,25 @@ const (
 )
 
 type BookingChangeRequest struct {
-	ID                 uuid.UUID                  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	BookingID          uuid.UUID                  `gorm:"type:uuid;not null;index" json:"booking_id"`
-	RequestedBy        uuid.UUID                  `gorm:"type:uuid;not null" json:"requested_by"`
-	ProposedStartDate  time.Time                  `json:"proposed_start_date"`
-	ProposedEndDate    time.Time                  `json:"proposed_end_date"`
-	Reason             string                     `gorm:"type:text" json:"reason"`
-	Status             BookingChangeRequestStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
-	CreatedAt          time.Time                  `json:"created_at"`
-	UpdatedAt          time.Time                  `json:"updated_at"`
+	ID                uuid.UUID                  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BookingID         uuid.UUID                  `gorm:"type:uuid;not null;index" json:"booking_id"`
+	RequestedBy       uuid.UUID                  `gorm:"type:uuid;not null" json:"requested_by"`
+	ProposedStartDate time.Time                  `json:"proposed_start_date"`
+	ProposedEndDate   time.Time                  `json:"proposed_end_date"`
+	Reason            string                     `gorm:"type:text" json:"reason"`
+	Status            BookingChangeRequestStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	PreviousStartDate *time.Time                 `json:"previous_start_date,omitempty"` // Booking dates replaced on approval
+	PreviousEndDate   *time.Time                 `json:"previous_end_date,omitempty"`
+	DecidedBy         *uuid.UUID                 `gorm:"type:uuid" json:"decided_by,omitempty"`
+	DecidedAt         *time.Time                 `json:"decided_at,omitempty"`
+	DecisionReason    string                     `gorm:"type:text" json:"decision_reason,omitempty"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	UpdatedAt         time.Time                  `json:"updated_at"`
 
 	// Relationships
-	Booking     Booking `gorm:"foreignKey:BookingID;constraint:OnDelete:CASCADE" json:"booking,omitempty"`
-	RequestedByUser User `gorm:"foreignKey:RequestedBy" json:"requested_by_user,omitempty"`
+	Booking         Booking `gorm:"foreignKey:BookingID;constraint:OnDelete:CASCADE" json:"booking,omitempty"`
+	RequestedByUser User    `gorm:"foreignKey:RequestedBy" json:"requested_by_user,omitempty"`
+	DecidedByUser   *User   `gorm:"foreignKey:DecidedBy" json:"decided_by_user,omitempty"`
 }
 
 func (BookingChangeRequest) TableName() string {
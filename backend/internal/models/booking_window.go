@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BookingWindowDates string
+type BookingWindowEligibility string
+
+const (
+	BookingWindowAllDates     BookingWindowDates = "all"
+	BookingWindowPremiumDates BookingWindowDates = "premium" // Premium weekends and public holidays
+
+	BookingWindowAllOwners      BookingWindowEligibility = "all_owners"
+	BookingWindowBelowFairShare BookingWindowEligibility = "below_fair_share" // Owners who have not used more than their share this year
+)
+
+// BookingWindow is a phased release rule: dates open for booking a number of
+// days ahead, optionally only to owners below their fair share
+type BookingWindow struct {
+	ID             uuid.UUID                `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID        uuid.UUID                `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	Name           string                   `gorm:"size:255;not null" json:"name"`
+	Dates          BookingWindowDates       `gorm:"type:varchar(20);not null;default:'all'" json:"dates"`
+	Eligibility    BookingWindowEligibility `gorm:"type:varchar(20);not null;default:'all_owners'" json:"eligibility"`
+	OpensDaysAhead int                      `gorm:"not null" json:"opens_days_ahead"`
+	Active         bool                     `gorm:"not null" json:"active"`
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+
+	// Relationships
+	Yacht Yacht `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (BookingWindow) TableName() string {
+	return "booking_windows"
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// insertedValues returns the columns and values GORM would insert for a
+// record, without a database
+func insertedValues(t *testing.T, value interface{}) map[string]interface{} {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	require.NoError(t, err)
+
+	result := db.Create(value)
+	require.NoError(t, result.Error)
+	stmt := result.Statement
+	values, ok := stmt.Clauses["VALUES"].Expression.(clause.Values)
+	require.True(t, ok, "no VALUES clause in %s", stmt.SQL.String())
+	require.Len(t, values.Values, 1)
+
+	inserted := make(map[string]interface{}, len(values.Columns))
+	for i, column := range values.Columns {
+		inserted[column.Name] = values.Values[0][i]
+	}
+	return inserted
+}
+
+// TestBookingWindowCreateInactive tests that a window created inactive is
+// stored inactive rather than taking a column default
+func TestBookingWindowCreateInactive(t *testing.T) {
+	window := BookingWindow{
+		YachtID:        uuid.New(),
+		Name:           "Summer ballot",
+		Dates:          BookingWindowPremiumDates,
+		Eligibility:    BookingWindowAllOwners,
+		OpensDaysAhead: 90,
+		Active:         false,
+	}
+
+	inserted := insertedValues(t, &window)
+	require.Contains(t, inserted, "active")
+	assert.Equal(t, false, inserted["active"])
+	assert.False(t, window.Active)
+}
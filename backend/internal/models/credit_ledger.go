@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreditEntryType string
+
+const (
+	CreditEntryAllocation    CreditEntryType = "allocation"     // Annual credit grant
+	CreditEntryRollover      CreditEntryType = "rollover"       // Decay applied to the previous year's balance
+	CreditEntryBookingDebit  CreditEntryType = "booking_debit"  // Booking confirmed
+	CreditEntryBookingRefund CreditEntryType = "booking_refund" // Booking cancelled or shortened
+
+	CreditEntryCancellationFee CreditEntryType = "cancellation_fee" // Late cancellation
+	CreditEntryProRata         CreditEntryType = "pro_rata"         // Share added or ended after the annual allocation
+)
+
+// CreditLedgerEntry is an append-only movement of fair share credits.
+// An owner's balance is the sum of their entries for a yacht.
+type CreditLedgerEntry struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID     uuid.UUID       `gorm:"type:uuid;not null;index;uniqueIndex:idx_credit_ledger_annual,where:entry_type IN ('allocation','rollover')" json:"yacht_id"`
+	UserID      uuid.UUID       `gorm:"type:uuid;not null;index;uniqueIndex:idx_credit_ledger_annual" json:"user_id"`
+	BookingID   *uuid.UUID      `gorm:"type:uuid;index" json:"booking_id,omitempty"`
+	EntryType   CreditEntryType `gorm:"type:varchar(20);not null;uniqueIndex:idx_credit_ledger_annual" json:"entry_type"`
+	Year        int             `gorm:"not null;uniqueIndex:idx_credit_ledger_annual" json:"year"`
+	Amount      float64         `gorm:"type:decimal(10,2);not null" json:"amount"` // Positive = credit, negative = debit
+	Description string          `gorm:"size:255" json:"description"`
+	CreatedAt   time.Time       `gorm:"index" json:"created_at"`
+
+	// Relationships
+	Yacht   Yacht    `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"-"`
+	User    User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Booking *Booking `gorm:"foreignKey:BookingID;constraint:OnDelete:SET NULL" json:"-"`
+}
+
+func (CreditLedgerEntry) TableName() string {
+	return "credit_ledger_entries"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreditNote reduces what is owed on an invoice without a payment, such as
+// a goodwill credit or a correction to a levy
+type CreditNote struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InvoiceID uuid.UUID      `gorm:"type:uuid;not null;index" json:"invoice_id"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`                // The invoice's owner
+	Currency  money.Currency `gorm:"type:varchar(3);not null;default:'AUD'" json:"currency"` // The invoice's
+	Amount    money.Money    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Reason    string         `gorm:"type:text;not null" json:"reason"`
+	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+
+	// Relationships
+	Invoice Invoice `gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (CreditNote) TableName() string {
+	return "credit_notes"
+}
+
+// AfterFind puts the credit's amount in its currency
+func (n *CreditNote) AfterFind(tx *gorm.DB) error {
+	n.Amount = n.Amount.In(n.Currency)
+	return nil
+}
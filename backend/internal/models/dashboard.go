@@ -28,6 +28,9 @@ type DashboardViewModel struct {
 
 	// Recent activity (last 5)
 	RecentActivities []ActivityInfo `json:"recent_activities"`
+
+	// Fair share usage over the last year
+	Fairness *FairnessInfo `json:"fairness,omitempty"`
 }
 
 type VesselInfo struct {
@@ -47,6 +50,17 @@ type BookingInfo struct {
 	Notes     string    `json:"notes"`
 }
 
+type FairnessInfo struct {
+	Score          float64 `json:"score"` // 100 = proportional, <100 = owed time, >100 = ahead
+	Used           float64 `json:"used"`
+	Entitled       float64 `json:"entitled"`
+	Days           int     `json:"days"`
+	PremiumDays    int     `json:"premium_days"`
+	SystemFairness float64 `json:"system_fairness"`
+	Gini           float64 `json:"gini"`
+	WindowDays     int     `json:"window_days"`
+}
+
 type ActivityInfo struct {
 	Icon     string    `json:"icon"`
 	Title    string    `json:"title"`
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DraftStatus string
+
+const (
+	DraftStatusOpen      DraftStatus = "open"
+	DraftStatusCompleted DraftStatus = "completed"
+	DraftStatusCancelled DraftStatus = "cancelled"
+)
+
+// Draft is a snake draft allocating a season's premium slots for a yacht
+type Draft struct {
+	ID                   uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID              uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_drafts_yacht_season" json:"yacht_id"`
+	Season               int         `gorm:"not null;uniqueIndex:idx_drafts_yacht_season" json:"season"`
+	Name                 string      `gorm:"size:255;not null" json:"name"`
+	SeasonStart          time.Time   `gorm:"not null" json:"season_start"`
+	SeasonEnd            time.Time   `gorm:"not null" json:"season_end"`
+	Rounds               int         `gorm:"not null" json:"rounds"`
+	PickTimeLimitMinutes int         `gorm:"not null" json:"pick_time_limit_minutes"`
+	Status               DraftStatus `gorm:"type:varchar(20);not null;index;default:'open'" json:"status"`
+	CurrentPick          int         `gorm:"not null;default:0" json:"current_pick"` // Zero-based index into the pick sequence
+	PickDeadline         *time.Time  `gorm:"index" json:"pick_deadline,omitempty"`
+	CreatedBy            uuid.UUID   `gorm:"type:uuid;not null" json:"created_by"`
+	CompletedAt          *time.Time  `json:"completed_at,omitempty"`
+	CreatedAt            time.Time   `json:"created_at"`
+	UpdatedAt            time.Time   `json:"updated_at"`
+
+	// Relationships
+	Yacht        Yacht              `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"yacht,omitempty"`
+	Participants []DraftParticipant `gorm:"foreignKey:DraftID" json:"participants,omitempty"`
+	Slots        []DraftSlot        `gorm:"foreignKey:DraftID" json:"slots,omitempty"`
+	Picks        []DraftPick        `gorm:"foreignKey:DraftID" json:"picks,omitempty"`
+}
+
+func (Draft) TableName() string {
+	return "drafts"
+}
+
+// DraftParticipant is an owner's first-round draft position
+type DraftParticipant struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DraftID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_draft_participants_user;uniqueIndex:idx_draft_participants_position" json:"draft_id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_draft_participants_user" json:"user_id"`
+	Position int       `gorm:"not null;uniqueIndex:idx_draft_participants_position" json:"position"` // 1-based
+
+	// Relationships
+	Draft Draft `gorm:"foreignKey:DraftID;constraint:OnDelete:CASCADE" json:"-"`
+	User  User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+func (DraftParticipant) TableName() string {
+	return "draft_participants"
+}
+
+// DraftSlot is a premium period that can be picked in a draft
+type DraftSlot struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DraftID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"draft_id"`
+	Label     string     `gorm:"size:255" json:"label"`
+	StartDate time.Time  `gorm:"not null" json:"start_date"`
+	EndDate   time.Time  `gorm:"not null" json:"end_date"`
+	Value     float64    `gorm:"type:decimal(10,2);not null" json:"value"`
+	PickedBy  *uuid.UUID `gorm:"type:uuid" json:"picked_by,omitempty"`
+	BookingID *uuid.UUID `gorm:"type:uuid" json:"booking_id,omitempty"`
+
+	// Relationships
+	Draft Draft `gorm:"foreignKey:DraftID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (DraftSlot) TableName() string {
+	return "draft_slots"
+}
+
+// DraftPick records one turn of a draft. SlotID is nil when the owner ran out
+// of time with nothing available on their wishlist.
+type DraftPick struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DraftID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_draft_picks_number" json:"draft_id"`
+	PickNumber int        `gorm:"not null;uniqueIndex:idx_draft_picks_number" json:"pick_number"` // 1-based across all rounds
+	Round      int        `gorm:"not null" json:"round"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	SlotID     *uuid.UUID `gorm:"type:uuid" json:"slot_id,omitempty"`
+	BookingID  *uuid.UUID `gorm:"type:uuid" json:"booking_id,omitempty"`
+	AutoPicked bool       `gorm:"not null;default:false" json:"auto_picked"`
+	PickedAt   time.Time  `json:"picked_at"`
+
+	// Relationships
+	Draft Draft `gorm:"foreignKey:DraftID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (DraftPick) TableName() string {
+	return "draft_picks"
+}
+
+// DraftWishlistItem is an owner's ranked preference used for auto-picks
+type DraftWishlistItem struct {
+	ID      uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DraftID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_draft_wishlist_slot" json:"draft_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_draft_wishlist_slot" json:"user_id"`
+	SlotID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_draft_wishlist_slot" json:"slot_id"`
+	Rank    int       `gorm:"not null" json:"rank"` // 1 = most wanted
+
+	// Relationships
+	Draft Draft     `gorm:"foreignKey:DraftID;constraint:OnDelete:CASCADE" json:"-"`
+	Slot  DraftSlot `gorm:"foreignKey:SlotID;constraint:OnDelete:CASCADE" json:"slot,omitempty"`
+}
+
+func (DraftWishlistItem) TableName() string {
+	return "draft_wishlist_items"
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// DunningStep is a point in the sequence of reminders sent about an unpaid
+// invoice, in days from its due date: negative before it, zero on the day
+// and positive once it is overdue
+type DunningStep struct {
+	Days    int  `json:"days"`
+	LateFee bool `json:"late_fee"` // Charge the policy's late fee when the step is reached
+}
+
+// DefaultDunningSteps remind owners before an invoice is due, on the day,
+// and a week, a fortnight and a month after
+var DefaultDunningSteps = []DunningStep{{Days: -3}, {Days: 0}, {Days: 7}, {Days: 14}, {Days: 30}}
+
+// DunningPolicy is how a yacht's syndicate chases unpaid invoices. Yachts
+// without a row use the default steps with no late fees.
+type DunningPolicy struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID        uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex" json:"yacht_id"`
+	Enabled        bool           `gorm:"not null" json:"enabled"`
+	Steps          datatypes.JSON `gorm:"type:jsonb;not null" json:"steps"`                             // Array of {days, late_fee}, in order
+	LateFeeAmount  money.Money    `gorm:"type:decimal(10,2);not null;default:0" json:"late_fee_amount"` // Fixed part, in the yacht's currency
+	LateFeePercent float64        `gorm:"type:decimal(5,2);not null;default:0" json:"late_fee_percent"` // Of the balance when it is charged
+	UpdatedBy      *uuid.UUID     `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	// Relationships
+	Yacht Yacht `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (DunningPolicy) TableName() string {
+	return "dunning_policies"
+}
+
+// DefaultDunningPolicy returns the policy for a yacht that has not saved one
+func DefaultDunningPolicy(yachtID uuid.UUID) DunningPolicy {
+	steps, _ := json.Marshal(DefaultDunningSteps)
+	return DunningPolicy{
+		YachtID: yachtID,
+		Enabled: true,
+		Steps:   datatypes.JSON(steps),
+	}
+}
+
+// Sequence decodes the policy's steps
+func (p DunningPolicy) Sequence() ([]DunningStep, error) {
+	var steps []DunningStep
+	if len(p.Steps) == 0 {
+		return steps, nil
+	}
+	if err := json.Unmarshal(p.Steps, &steps); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// LateFee returns the fee charged on a balance, in the balance's currency
+func (p DunningPolicy) LateFee(balance money.Money) money.Money {
+	return p.LateFeeAmount.In(balance.Currency()).Add(balance.Scale(p.LateFeePercent / 100))
+}
+
+// DunningStepsReached returns the steps reached by today for an invoice
+// issued and due on the given dates, earliest first. Steps that fell before
+// the invoice was issued are never reached, so an invoice due within days of
+// being raised is not sent a reminder straight away.
+func DunningStepsReached(steps []DunningStep, issued, due, today time.Time) []DunningStep {
+	var reached []DunningStep
+	for _, step := range steps {
+		date := due.AddDate(0, 0, step.Days)
+		if date.After(today) || date.Before(issued) {
+			continue
+		}
+		reached = append(reached, step)
+	}
+	sort.SliceStable(reached, func(i, j int) bool { return reached[i].Days < reached[j].Days })
+	return reached
+}
+
+// InvoiceReminder records a dunning step sent for an invoice, so each step
+// is sent at most once
+type InvoiceReminder struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InvoiceID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_invoice_reminders_step" json:"invoice_id"`
+	Days             int        `gorm:"not null;uniqueIndex:idx_invoice_reminders_step" json:"days"` // The step, from the due date
+	LateFeeLineID    *uuid.UUID `gorm:"type:uuid" json:"late_fee_line_id,omitempty"`
+	LateFeeInvoiceID *uuid.UUID `gorm:"type:uuid" json:"late_fee_invoice_id,omitempty"` // Fee raised on its own once the invoice could not be changed in Xero
+	SentAt           time.Time  `gorm:"not null" json:"sent_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+
+	// Relationships
+	Invoice Invoice `gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (InvoiceReminder) TableName() string {
+	return "invoice_reminders"
+}
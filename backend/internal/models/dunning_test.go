@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDefaultDunningPolicy tests the steps of a yacht without a saved policy
+func TestDefaultDunningPolicy(t *testing.T) {
+	policy := DefaultDunningPolicy(uuid.New())
+	assert.True(t, policy.Enabled)
+
+	steps, err := policy.Sequence()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultDunningSteps, steps)
+	assert.True(t, policy.LateFee(money.New(100000, money.AUD)).IsZero())
+}
+
+// TestDunningStepsReached tests choosing the steps an invoice has reached
+func TestDunningStepsReached(t *testing.T) {
+	steps := []DunningStep{{Days: -3}, {Days: 0}, {Days: 7}, {Days: 14, LateFee: true}, {Days: 30}}
+	issued := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		issued   time.Time
+		today    time.Time
+		wantDays []int
+	}{
+		{"before any step", issued, time.Date(2026, 3, 27, 0, 0, 0, 0, time.UTC), nil},
+		{"before due", issued, time.Date(2026, 3, 28, 0, 0, 0, 0, time.UTC), []int{-3}},
+		{"due today", issued, due, []int{-3, 0}},
+		{"between steps", issued, time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC), []int{-3, 0, 7}},
+		{"late fee step", issued, time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC), []int{-3, 0, 7, 14}},
+		{"after the last step", issued, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), []int{-3, 0, 7, 14, 30}},
+		{"issued after the reminder", time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC), nil},
+		{"issued late", time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC), []int{7, 14}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var days []int
+			for _, step := range DunningStepsReached(steps, tt.issued, due, tt.today) {
+				days = append(days, step.Days)
+				assert.Equal(t, step.Days == 14, step.LateFee)
+			}
+			assert.Equal(t, tt.wantDays, days)
+		})
+	}
+}
+
+// TestDunningLateFee tests late fees made up of a fixed amount and a
+// percentage of the balance
+func TestDunningLateFee(t *testing.T) {
+	policy := DunningPolicy{LateFeeAmount: money.New(2500, money.AUD), LateFeePercent: 1.5}
+	assert.Equal(t, money.New(4375, money.AUD), policy.LateFee(money.New(125000, money.AUD)))
+
+	// Rounded to the cent, in the invoice's currency
+	policy = DunningPolicy{LateFeePercent: 2}
+	assert.Equal(t, money.New(2, money.NZD), policy.LateFee(money.New(99, money.NZD)))
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+)
+
+// ExchangeRate converts one currency to another in reports that span
+// currencies. Managers maintain them; amounts are never converted at a rate
+// that isn't recorded here.
+type ExchangeRate struct {
+	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FromCurrency money.Currency `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_pair" json:"from_currency"`
+	ToCurrency   money.Currency `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_pair" json:"to_currency"`
+	Rate         float64        `gorm:"type:decimal(18,8);not null" json:"rate"` // Units of ToCurrency for one of FromCurrency
+	UpdatedBy    uuid.UUID      `gorm:"type:uuid;not null" json:"updated_by"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
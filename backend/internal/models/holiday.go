@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HolidayKind string
+
+const (
+	HolidayKindPublic HolidayKind = "public" // Valued as a public holiday
+	HolidayKindSchool HolidayKind = "school" // Valued as a school holiday
+)
+
+// CustomHoliday is a date range a manager adds to a yacht's holiday calendar,
+// such as a regatta weekend
+type CustomHoliday struct {
+	ID        uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID   uuid.UUID   `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	Name      string      `gorm:"size:255;not null" json:"name"`
+	Kind      HolidayKind `gorm:"type:varchar(20);not null;default:'public'" json:"kind"`
+	StartDate time.Time   `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time   `gorm:"type:date;not null" json:"end_date"` // Inclusive
+	CreatedBy uuid.UUID   `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	// Relationships
+	Yacht Yacht `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (CustomHoliday) TableName() string {
+	return "custom_holidays"
+}
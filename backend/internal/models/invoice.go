@@ -3,45 +3,98 @@ package models
 import (
 	"time"
 
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type InvoiceStatus string
 
 const (
-	InvoiceStatusDraft     InvoiceStatus = "draft"
-	InvoiceStatusSent      InvoiceStatus = "sent"
-	InvoiceStatusPaid      InvoiceStatus = "paid"
-	InvoiceStatusOverdue   InvoiceStatus = "overdue"
-	InvoiceStatusCancelled InvoiceStatus = "cancelled"
+	InvoiceStatusDraft         InvoiceStatus = "draft"
+	InvoiceStatusSent          InvoiceStatus = "sent"
+	InvoiceStatusPartiallyPaid InvoiceStatus = "partially_paid"
+	InvoiceStatusPaid          InvoiceStatus = "paid"
+	InvoiceStatusOverdue       InvoiceStatus = "overdue"
+	InvoiceStatusCancelled     InvoiceStatus = "cancelled"
 )
 
 type Invoice struct {
-	ID            uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	XeroInvoiceID string        `gorm:"uniqueIndex;size:255" json:"xero_invoice_id"` // Xero source of truth
-	YachtID       uuid.UUID     `gorm:"type:uuid;not null;index" json:"yacht_id"`
-	UserID        uuid.UUID     `gorm:"type:uuid;not null;index" json:"user_id"`
-	InvoiceNumber string        `gorm:"uniqueIndex;size:100;not null" json:"invoice_number"`
-	Description   string        `gorm:"type:text" json:"description"`
-	Amount        float64       `gorm:"type:decimal(10,2);not null" json:"amount"`
-	DueDate       time.Time     `gorm:"type:date;index" json:"due_date"`
-	Status        InvoiceStatus `gorm:"type:varchar(20);not null;index;default:'draft'" json:"status"`
-	IssuedDate    time.Time     `gorm:"type:date" json:"issued_date"`
-	PaidDate      *time.Time    `gorm:"type:date" json:"paid_date,omitempty"`
-	XeroSyncedAt  *time.Time    `json:"xero_synced_at,omitempty"`
-	XeroURL       string        `gorm:"-" json:"xero_url"` // Computed field
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	XeroInvoiceID  string         `gorm:"size:255;uniqueIndex:idx_invoices_xero_invoice_id_set,where:xero_invoice_id <> ''" json:"xero_invoice_id"` // Xero source of truth; empty until pushed
+	YachtID        uuid.UUID      `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	UserID         uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	LevyID         *uuid.UUID     `gorm:"type:uuid;index" json:"levy_id,omitempty"`
+	InvoiceNumber  string         `gorm:"uniqueIndex;size:100;not null" json:"invoice_number"`
+	Description    string         `gorm:"type:text" json:"description"`
+	Currency       money.Currency `gorm:"type:varchar(3);not null;default:'AUD'" json:"currency"`       // The yacht's currency when it was raised
+	Amount         money.Money    `gorm:"type:decimal(10,2);not null" json:"amount"`                    // Including any lines added since it was raised
+	AmountPaid     money.Money    `gorm:"type:decimal(10,2);not null;default:0" json:"amount_paid"`     // Completed payments less refunds
+	AmountCredited money.Money    `gorm:"type:decimal(10,2);not null;default:0" json:"amount_credited"` // Credit notes issued against it
+	AmountDue      money.Money    `gorm:"type:decimal(10,2);not null;default:0" json:"amount_due"`      // What remains to be paid
+	DueDate        time.Time      `gorm:"type:date;index" json:"due_date"`
+	Status         InvoiceStatus  `gorm:"type:varchar(20);not null;index;default:'draft'" json:"status"`
+	IssuedDate     time.Time      `gorm:"type:date" json:"issued_date"`
+	PaidDate       *time.Time     `gorm:"type:date" json:"paid_date,omitempty"`
+	XeroSyncedAt   *time.Time     `json:"xero_synced_at,omitempty"`
+	XeroSyncError  string         `gorm:"type:text" json:"xero_sync_error,omitempty"`  // Why the last push was rejected
+	XeroUpdatedAt  *time.Time     `json:"xero_updated_at,omitempty"`                   // Xero's last change applied, so older states are not reapplied
+	XeroOutdated   bool           `gorm:"not null;default:false" json:"xero_outdated"` // Changed since it was pushed, such as by a late fee
+	XeroURL        string         `gorm:"-" json:"xero_url"`                           // Computed field
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 
 	// Relationships
-	Yacht Yacht `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"yacht,omitempty"`
-	User  User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Yacht Yacht         `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"yacht,omitempty"`
+	User  User          `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Lines []InvoiceLine `gorm:"foreignKey:InvoiceID" json:"lines,omitempty"`
 }
 
 func (Invoice) TableName() string {
 	return "invoices"
 }
 
+// AfterFind puts the invoice's amounts in its currency
+func (i *Invoice) AfterFind(tx *gorm.DB) error {
+	i.Amount = i.Amount.In(i.Currency)
+	i.AmountPaid = i.AmountPaid.In(i.Currency)
+	i.AmountCredited = i.AmountCredited.In(i.Currency)
+	i.AmountDue = i.AmountDue.In(i.Currency)
+	return nil
+}
+
+// Settle sets the invoice's balance from what has been paid, net of
+// refunds, and credited against it, and moves it between sent, partially
+// paid and paid to match. Drafts and cancelled invoices keep their status,
+// and an overdue invoice stays overdue until it is paid off.
+func (i *Invoice) Settle(paid, credited money.Money, at time.Time) {
+	i.AmountPaid = paid
+	i.AmountCredited = credited
+	i.AmountDue = i.Amount.Sub(paid).Sub(credited).Max(money.Zero(i.Amount.Currency()))
+
+	if i.Status == InvoiceStatusDraft || i.Status == InvoiceStatusCancelled {
+		return
+	}
+	today := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	if i.AmountDue.IsZero() {
+		if i.Status != InvoiceStatusPaid {
+			i.Status = InvoiceStatusPaid
+			i.PaidDate = &today
+		}
+		return
+	}
+
+	i.PaidDate = nil
+	switch {
+	case i.Status == InvoiceStatusOverdue || (i.Status == InvoiceStatusPaid && i.DueDate.Before(today)):
+		i.Status = InvoiceStatusOverdue
+	case i.AmountPaid.IsPositive():
+		i.Status = InvoiceStatusPartiallyPaid
+	default:
+		i.Status = InvoiceStatusSent
+	}
+}
+
 // GenerateXeroURL creates the Xero invoice URL from the XeroInvoiceID
 func (i *Invoice) GenerateXeroURL() {
 	if i.XeroInvoiceID != "" {
@@ -56,26 +109,43 @@ type InvoiceViewModel struct {
 	RecentActivities []InvoiceActivity `json:"recent_activities"`
 }
 
-// InvoiceStats - Summary statistics for invoices
+// InvoiceStats - Summary statistics for invoices. Totals are in Currency;
+// invoices in other currencies are converted with the managers' exchange
+// rates, and left out and listed in Unconverted if there is no rate.
 type InvoiceStats struct {
-	TotalOutstanding float64 `json:"total_outstanding"`
-	PaidCount        int     `json:"paid_count"`
-	OverdueCount     int     `json:"overdue_count"`
-	PendingCount     int     `json:"pending_count"`
-	DraftCount       int     `json:"draft_count"`
+	Currency           money.Currency   `json:"currency"`
+	TotalOutstanding   money.Money      `json:"total_outstanding"` // Amount remaining on unpaid invoices
+	TotalPaid          money.Money      `json:"total_paid"`
+	ByCurrency         []CurrencyTotals `json:"by_currency"` // Unconverted totals for each currency invoiced
+	Unconverted        []money.Currency `json:"unconverted,omitempty"`
+	PaidCount          int              `json:"paid_count"`
+	PartiallyPaidCount int              `json:"partially_paid_count"` // Also counted as pending or overdue
+	OverdueCount       int              `json:"overdue_count"`
+	PendingCount       int              `json:"pending_count"`
+	DraftCount         int              `json:"draft_count"`
+}
+
+// CurrencyTotals - Invoice totals in one currency
+type CurrencyTotals struct {
+	Currency    money.Currency `json:"currency"`
+	Outstanding money.Money    `json:"outstanding"`
+	Paid        money.Money    `json:"paid"`
 }
 
 // InvoiceInfo - Simplified invoice data for list view
 type InvoiceInfo struct {
-	ID            uuid.UUID `json:"id"`
-	InvoiceNumber string    `json:"invoice_number"`
-	Description   string    `json:"description"`
-	Amount        float64   `json:"amount"`
-	DueDate       time.Time `json:"due_date"`
-	IssuedDate    time.Time `json:"issued_date"`
-	Status        string    `json:"status"`
-	IsOverdue     bool      `json:"is_overdue"`
-	DaysUntilDue  int       `json:"days_until_due"`
+	ID            uuid.UUID      `json:"id"`
+	InvoiceNumber string         `json:"invoice_number"`
+	Description   string         `json:"description"`
+	Currency      money.Currency `json:"currency"`
+	Amount        money.Money    `json:"amount"`
+	AmountPaid    money.Money    `json:"amount_paid"`
+	AmountDue     money.Money    `json:"amount_due"` // Amount remaining
+	DueDate       time.Time      `json:"due_date"`
+	IssuedDate    time.Time      `json:"issued_date"`
+	Status        string         `json:"status"`
+	IsOverdue     bool           `json:"is_overdue"`
+	DaysUntilDue  int            `json:"days_until_due"`
 }
 
 // InvoiceActivity - Recent invoice-related activity
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvoiceLine is a charge added to an invoice after it was raised, such as
+// a late fee. The invoice's amount includes its lines.
+type InvoiceLine struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InvoiceID   uuid.UUID      `gorm:"type:uuid;not null;index" json:"invoice_id"`
+	Description string         `gorm:"type:text;not null" json:"description"`
+	Currency    money.Currency `gorm:"type:varchar(3);not null;default:'AUD'" json:"currency"` // The invoice's
+	Amount      money.Money    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+
+	// Relationships
+	Invoice Invoice `gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (InvoiceLine) TableName() string {
+	return "invoice_lines"
+}
+
+// AfterFind puts the line's amount in its currency
+func (l *InvoiceLine) AfterFind(tx *gorm.DB) error {
+	l.Amount = l.Amount.In(l.Currency)
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,7 +22,7 @@ func TestInvoiceJSONEncoding(t *testing.T) {
 		UserID:        uuid.New(),
 		InvoiceNumber: "YL-2025-001",
 		Description:   "Test invoice",
-		Amount:        1000.50,
+		Amount:        money.New(100050, money.AUD),
 		DueDate:       now.AddDate(0, 0, 30),
 		Status:        InvoiceStatusSent,
 		IssuedDate:    now,
@@ -56,7 +57,7 @@ func TestInvoiceViewModelJSONEncoding(t *testing.T) {
 	now := time.Now()
 	viewModel := InvoiceViewModel{
 		Stats: InvoiceStats{
-			TotalOutstanding: 5000.00,
+			TotalOutstanding: money.New(500000, money.AUD),
 			PaidCount:        2,
 			OverdueCount:     1,
 			PendingCount:     1,
@@ -67,7 +68,7 @@ func TestInvoiceViewModelJSONEncoding(t *testing.T) {
 				ID:            uuid.New(),
 				InvoiceNumber: "YL-2025-001",
 				Description:   "Test invoice",
-				Amount:        1000.00,
+				Amount:        money.New(100000, money.AUD),
 				DueDate:       now.AddDate(0, 0, 30),
 				IssuedDate:    now,
 				Status:        "sent",
@@ -112,7 +113,7 @@ func TestInvoiceInfoJSONEncoding(t *testing.T) {
 		ID:            uuid.New(),
 		InvoiceNumber: "YL-2025-001",
 		Description:   "Test invoice",
-		Amount:        1000.00,
+		Amount:        money.New(100000, money.AUD),
 		DueDate:       now.AddDate(0, 0, 30),
 		IssuedDate:    now,
 		Status:        "sent",
@@ -159,6 +160,7 @@ func TestInvoiceStatusValues(t *testing.T) {
 	}{
 		{InvoiceStatusDraft, "draft"},
 		{InvoiceStatusSent, "sent"},
+		{InvoiceStatusPartiallyPaid, "partially_paid"},
 		{InvoiceStatusPaid, "paid"},
 		{InvoiceStatusOverdue, "overdue"},
 		{InvoiceStatusCancelled, "cancelled"},
@@ -171,6 +173,78 @@ func TestInvoiceStatusValues(t *testing.T) {
 	}
 }
 
+// TestInvoiceSettle tests deriving an invoice's balance and status from
+// its payments and credits
+func TestInvoiceSettle(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	dueLater := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	duePast := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		status         InvoiceStatus
+		dueDate        time.Time
+		paid, credited int64 // Cents
+		wantStatus     InvoiceStatus
+		wantDue        int64
+	}{
+		{"nothing paid", InvoiceStatusSent, dueLater, 0, 0, InvoiceStatusSent, 100000},
+		{"part paid", InvoiceStatusSent, dueLater, 40010, 0, InvoiceStatusPartiallyPaid, 59990},
+		{"credited only", InvoiceStatusSent, dueLater, 0, 25000, InvoiceStatusSent, 75000},
+		{"paid with credit", InvoiceStatusPartiallyPaid, dueLater, 75000, 25000, InvoiceStatusPaid, 0},
+		{"overpaid", InvoiceStatusSent, dueLater, 120000, 0, InvoiceStatusPaid, 0},
+		{"overdue part paid", InvoiceStatusOverdue, duePast, 50000, 0, InvoiceStatusOverdue, 50000},
+		{"overdue paid off", InvoiceStatusOverdue, duePast, 100000, 0, InvoiceStatusPaid, 0},
+		{"refund reopens", InvoiceStatusPaid, dueLater, 60000, 0, InvoiceStatusPartiallyPaid, 40000},
+		{"refund reopens past due", InvoiceStatusPaid, duePast, 0, 0, InvoiceStatusOverdue, 100000},
+		{"draft keeps status", InvoiceStatusDraft, dueLater, 0, 100000, InvoiceStatusDraft, 0},
+		{"cancelled keeps status", InvoiceStatusCancelled, dueLater, 0, 0, InvoiceStatusCancelled, 100000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paidDate := duePast
+			inv := Invoice{Amount: money.New(100000, money.AUD), Status: tt.status, DueDate: tt.dueDate}
+			if tt.status == InvoiceStatusPaid {
+				inv.PaidDate = &paidDate
+			}
+
+			inv.Settle(money.New(tt.paid, money.AUD), money.New(tt.credited, money.AUD), now)
+
+			assert.Equal(t, tt.wantStatus, inv.Status)
+			assert.Equal(t, money.New(tt.wantDue, money.AUD), inv.AmountDue)
+			assert.Equal(t, money.New(tt.paid, money.AUD), inv.AmountPaid)
+			assert.Equal(t, money.New(tt.credited, money.AUD), inv.AmountCredited)
+			if inv.Status == InvoiceStatusPaid {
+				require.NotNil(t, inv.PaidDate)
+				if tt.status != InvoiceStatusPaid {
+					assert.Equal(t, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), *inv.PaidDate)
+				}
+			} else if tt.status != InvoiceStatusDraft && tt.status != InvoiceStatusCancelled {
+				assert.Nil(t, inv.PaidDate)
+			}
+		})
+	}
+}
+
+// TestInvoiceAfterFind tests that loaded amounts take the invoice's currency
+func TestInvoiceAfterFind(t *testing.T) {
+	inv := Invoice{
+		Currency:   money.NZD,
+		Amount:     money.MustParse("1200.00", money.AUD),
+		AmountPaid: money.MustParse("200.00", money.AUD),
+		AmountDue:  money.MustParse("1000.00", money.AUD),
+	}
+
+	require.NoError(t, inv.AfterFind(nil))
+
+	assert.Equal(t, money.New(120000, money.NZD), inv.Amount)
+	assert.Equal(t, money.New(20000, money.NZD), inv.AmountPaid)
+	assert.Equal(t, money.Zero(money.NZD), inv.AmountCredited)
+	assert.Equal(t, money.New(100000, money.NZD), inv.AmountDue)
+	assert.Equal(t, "NZ$1,000.00", inv.AmountDue.Format(money.LocaleAU))
+}
+
 // TestGenerateXeroURL tests the XeroURL generation
 func TestGenerateXeroURL(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LevyCategory string
+
+const (
+	LevyCategoryBerthing    LevyCategory = "berthing"
+	LevyCategoryInsurance   LevyCategory = "insurance"
+	LevyCategoryServicing   LevyCategory = "servicing"
+	LevyCategoryMaintenance LevyCategory = "maintenance"
+	LevyCategoryOther       LevyCategory = "other"
+)
+
+type InvoiceSplitRule string
+
+const (
+	SplitBySharePercentage InvoiceSplitRule = "share_percentage" // In proportion to ownership
+	SplitEqually           InvoiceSplitRule = "equal"            // The same for every share
+)
+
+// Levy is a syndicate running cost split between the shareholders of a
+// yacht, one invoice per share
+type Levy struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID     uuid.UUID        `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	Category    LevyCategory     `gorm:"type:varchar(20);not null" json:"category"`
+	Description string           `gorm:"type:text" json:"description"`
+	Currency    money.Currency   `gorm:"type:varchar(3);not null;default:'AUD'" json:"currency"` // The yacht's
+	Amount      money.Money      `gorm:"type:decimal(10,2);not null" json:"amount"`
+	SplitRule   InvoiceSplitRule `gorm:"type:varchar(20);not null;default:'share_percentage'" json:"split_rule"`
+	PeriodStart time.Time        `gorm:"type:date;not null;uniqueIndex:idx_levies_recurring_period,priority:2" json:"period_start"`
+	PeriodEnd   time.Time        `gorm:"type:date;not null" json:"period_end"` // Inclusive
+	IssuedDate  time.Time        `gorm:"type:date;not null" json:"issued_date"`
+	DueDate     time.Time        `gorm:"type:date;not null" json:"due_date"`
+	CreatedBy   uuid.UUID        `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	// Set when issued by a recurring schedule, which issues each period once
+	RecurringInvoiceID *uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_levies_recurring_period,priority:1" json:"recurring_invoice_id,omitempty"`
+
+	// Relationships
+	Yacht    Yacht     `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"-"`
+	Invoices []Invoice `gorm:"foreignKey:LevyID" json:"invoices,omitempty"`
+}
+
+func (Levy) TableName() string {
+	return "levies"
+}
+
+// AfterFind puts the levy's amount in its currency
+func (l *Levy) AfterFind(tx *gorm.DB) error {
+	l.Amount = l.Amount.In(l.Currency)
+	return nil
+}
+
+// InvoiceSequence hands out invoice numbers for a year in order
+type InvoiceSequence struct {
+	Year       int       `gorm:"primaryKey;autoIncrement:false" json:"year"`
+	LastNumber int       `gorm:"not null" json:"last_number"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 )
@@ -34,8 +35,8 @@ type MaintenanceRequest struct {
 	Status        MaintenanceStatus  `gorm:"type:varchar(20);not null;index;default:'submitted'" json:"status"`
 	Photos        datatypes.JSON     `gorm:"type:jsonb" json:"photos,omitempty"` // Array of S3 URLs
 	Location      string             `gorm:"size:255" json:"location,omitempty"` // e.g., "Port Engine", "Galley"
-	EstimatedCost *float64           `gorm:"type:decimal(10,2)" json:"estimated_cost,omitempty"`
-	ActualCost    *float64           `gorm:"type:decimal(10,2)" json:"actual_cost,omitempty"`
+	EstimatedCost *money.Money       `gorm:"type:decimal(10,2)" json:"estimated_cost,omitempty"`
+	ActualCost    *money.Money       `gorm:"type:decimal(10,2)" json:"actual_cost,omitempty"`
 	AssignedTo    string             `gorm:"size:255" json:"assigned_to,omitempty"` // Service provider name
 	ScheduledDate *time.Time         `gorm:"type:date" json:"scheduled_date,omitempty"`
 	CompletedDate *time.Time         `gorm:"type:date" json:"completed_date,omitempty"`
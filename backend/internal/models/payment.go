@@ -3,7 +3,9 @@ package models
 import (
 	"time"
 
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type PaymentMethod string
@@ -13,25 +15,32 @@ const (
 	PaymentMethodApplePay  PaymentMethod = "apple_pay"
 	PaymentMethodGooglePay PaymentMethod = "google_pay"
 	PaymentMethodCard      PaymentMethod = "card"
+	PaymentMethodXero      PaymentMethod = "xero" // Paid straight into Xero, outside the app
 
 	PaymentStatusPending   PaymentStatus = "pending"
 	PaymentStatusCompleted PaymentStatus = "completed"
 	PaymentStatusFailed    PaymentStatus = "failed"
-	PaymentStatusRefunded  PaymentStatus = "refunded"
+	PaymentStatusRefunded  PaymentStatus = "refunded"  // Fully refunded; partly refunded payments stay completed
+	PaymentStatusCancelled PaymentStatus = "cancelled" // Replaced by another payment before it was paid
 )
 
 type Payment struct {
-	ID              uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	InvoiceID       uuid.UUID     `gorm:"type:uuid;not null;index" json:"invoice_id"`
-	UserID          uuid.UUID     `gorm:"type:uuid;not null;index" json:"user_id"`
-	Amount          float64       `gorm:"type:decimal(10,2);not null" json:"amount"`
-	PaymentMethod   PaymentMethod `gorm:"type:varchar(20);not null" json:"payment_method"`
-	StripePaymentID string        `gorm:"size:255;index" json:"stripe_payment_id,omitempty"`
-	XeroPaymentID   string        `gorm:"size:255" json:"xero_payment_id,omitempty"`
-	Status          PaymentStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
-	PaidAt          *time.Time    `json:"paid_at,omitempty"`
-	XeroSyncedAt    *time.Time    `json:"xero_synced_at,omitempty"`
-	CreatedAt       time.Time     `json:"created_at"`
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InvoiceID       uuid.UUID      `gorm:"type:uuid;not null;index" json:"invoice_id"`
+	UserID          uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Currency        money.Currency `gorm:"type:varchar(3);not null;default:'AUD'" json:"currency"` // The invoice's
+	Amount          money.Money    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	AmountRefunded  money.Money    `gorm:"type:decimal(10,2);not null;default:0" json:"amount_refunded"`
+	PaymentMethod   PaymentMethod  `gorm:"type:varchar(20);not null" json:"payment_method"`
+	StripePaymentID string         `gorm:"size:255;index" json:"stripe_payment_id,omitempty"`
+	XeroPaymentID   string         `gorm:"size:255" json:"xero_payment_id,omitempty"`
+	Status          PaymentStatus  `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	PaidAt          *time.Time     `json:"paid_at,omitempty"`
+	FailureReason   string         `gorm:"type:text" json:"failure_reason,omitempty"` // Why the last attempt was declined
+	XeroSyncedAt    *time.Time     `json:"xero_synced_at,omitempty"`
+	XeroSyncError   string         `gorm:"type:text" json:"xero_sync_error,omitempty"` // Why the last push was rejected
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
 
 	// Relationships
 	Invoice Invoice `gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE" json:"invoice,omitempty"`
@@ -41,3 +50,10 @@ type Payment struct {
 func (Payment) TableName() string {
 	return "payments"
 }
+
+// AfterFind puts the payment's amounts in its currency
+func (p *Payment) AfterFind(tx *gorm.DB) error {
+	p.Amount = p.Amount.In(p.Currency)
+	p.AmountRefunded = p.AmountRefunded.In(p.Currency)
+	return nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type InvoiceCadence string
+
+const (
+	CadenceMonthly   InvoiceCadence = "monthly"
+	CadenceQuarterly InvoiceCadence = "quarterly"
+	CadenceYearly    InvoiceCadence = "yearly"
+)
+
+// Months returns the length of a cadence's period in months
+func (c InvoiceCadence) Months() int {
+	switch c {
+	case CadenceMonthly:
+		return 1
+	case CadenceQuarterly:
+		return 3
+	case CadenceYearly:
+		return 12
+	}
+	return 0
+}
+
+// RecurringInvoice is a template for a cost billed to a yacht's
+// shareholders every period, such as a monthly management fee. Each period is
+// issued as a Levy on the day it starts.
+type RecurringInvoice struct {
+	ID            uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID       uuid.UUID        `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	Category      LevyCategory     `gorm:"type:varchar(20);not null" json:"category"`
+	Description   string           `gorm:"type:text;not null" json:"description"`
+	Currency      money.Currency   `gorm:"type:varchar(3);not null;default:'AUD'" json:"currency"` // The yacht's
+	Amount        money.Money      `gorm:"type:decimal(10,2);not null" json:"amount"`              // Per period, before splitting
+	Cadence       InvoiceCadence   `gorm:"type:varchar(20);not null" json:"cadence"`
+	SplitRule     InvoiceSplitRule `gorm:"type:varchar(20);not null;default:'share_percentage'" json:"split_rule"`
+	DueDays       int              `gorm:"not null" json:"due_days"` // Days after issue the invoices fall due
+	Send          bool             `gorm:"not null" json:"send"`
+	StartDate     time.Time        `gorm:"type:date;not null" json:"start_date"`     // First period starts
+	EndDate       *time.Time       `gorm:"type:date" json:"end_date,omitempty"`      // No periods start after
+	PeriodsIssued int              `gorm:"not null;default:0" json:"periods_issued"` // Periods issued or skipped so far
+	NextIssueDate *time.Time       `gorm:"type:date;index" json:"next_issue_date"`   // Nil once the schedule ends
+	Active        bool             `gorm:"not null;index" json:"active"`             // Paused schedules issue nothing
+	LastError     string           `gorm:"type:text" json:"last_error,omitempty"`    // Why the last period was skipped
+	CreatedBy     uuid.UUID        `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+
+	// Relationships
+	Yacht  Yacht  `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"-"`
+	Levies []Levy `gorm:"foreignKey:RecurringInvoiceID" json:"levies,omitempty"`
+}
+
+func (RecurringInvoice) TableName() string {
+	return "recurring_invoices"
+}
+
+// AfterFind puts the schedule's amount in its currency
+func (r *RecurringInvoice) AfterFind(tx *gorm.DB) error {
+	r.Amount = r.Amount.In(r.Currency)
+	return nil
+}
+
+// PeriodStart returns the first day of the nth period (from zero). Periods
+// keep the start date's day of the month, falling back to the last day of
+// shorter months, so a schedule starting on the 31st does not drift.
+func (r RecurringInvoice) PeriodStart(n int) time.Time {
+	months := n * r.Cadence.Months()
+	first := time.Date(r.StartDate.Year(), r.StartDate.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	day := r.StartDate.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// PeriodsBefore returns how many of the schedule's periods start before day
+func (r RecurringInvoice) PeriodsBefore(day time.Time) int {
+	n := 0
+	for r.PeriodStart(n).Before(day) {
+		n++
+	}
+	return n
+}
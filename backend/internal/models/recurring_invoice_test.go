@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecurringInvoicePeriodStart tests period dates of recurring invoices
+func TestRecurringInvoicePeriodStart(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	monthly := RecurringInvoice{Cadence: CadenceMonthly, StartDate: date(2026, time.January, 31)}
+	assert.Equal(t, date(2026, time.January, 31), monthly.PeriodStart(0))
+	assert.Equal(t, date(2026, time.February, 28), monthly.PeriodStart(1))
+	assert.Equal(t, date(2026, time.March, 31), monthly.PeriodStart(2))
+	assert.Equal(t, date(2027, time.January, 31), monthly.PeriodStart(12))
+
+	quarterly := RecurringInvoice{Cadence: CadenceQuarterly, StartDate: date(2026, time.July, 1)}
+	assert.Equal(t, date(2026, time.October, 1), quarterly.PeriodStart(1))
+	assert.Equal(t, date(2027, time.January, 1), quarterly.PeriodStart(2))
+
+	yearly := RecurringInvoice{Cadence: CadenceYearly, StartDate: date(2024, time.February, 29)}
+	assert.Equal(t, date(2025, time.February, 28), yearly.PeriodStart(1))
+	assert.Equal(t, date(2028, time.February, 29), yearly.PeriodStart(4))
+}
+
+// TestRecurringInvoicePeriodsBefore tests counting the periods started before
+// a day, as skipped when a paused schedule is resumed
+func TestRecurringInvoicePeriodsBefore(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	monthly := RecurringInvoice{Cadence: CadenceMonthly, StartDate: date(2026, time.January, 15)}
+	assert.Equal(t, 0, monthly.PeriodsBefore(date(2026, time.January, 15)))
+	assert.Equal(t, 1, monthly.PeriodsBefore(date(2026, time.January, 16)))
+	assert.Equal(t, 4, monthly.PeriodsBefore(date(2026, time.April, 20)))
+	assert.Equal(t, 3, monthly.PeriodsBefore(date(2026, time.April, 15)))
+	assert.Equal(t, 0, monthly.PeriodsBefore(date(2025, time.December, 1)))
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StandbyEntryStatus string
+type StandbyOfferStatus string
+
+const (
+	StandbyEntryStatusWaiting   StandbyEntryStatus = "waiting"
+	StandbyEntryStatusFilled    StandbyEntryStatus = "filled"
+	StandbyEntryStatusWithdrawn StandbyEntryStatus = "withdrawn"
+
+	StandbyOfferStatusPending  StandbyOfferStatus = "pending"
+	StandbyOfferStatusAccepted StandbyOfferStatus = "accepted"
+	StandbyOfferStatusDeclined StandbyOfferStatus = "declined"
+	StandbyOfferStatusExpired  StandbyOfferStatus = "expired"
+	StandbyOfferStatusLapsed   StandbyOfferStatus = "lapsed" // The dates were booked by someone else first
+)
+
+// StandbyEntry is an owner waiting for dates on a yacht to free up
+type StandbyEntry struct {
+	ID        uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID   uuid.UUID          `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	UserID    uuid.UUID          `gorm:"type:uuid;not null;index" json:"user_id"`
+	StartDate time.Time          `gorm:"not null" json:"start_date"`
+	EndDate   time.Time          `gorm:"not null" json:"end_date"`
+	Status    StandbyEntryStatus `gorm:"type:varchar(20);not null;index;default:'waiting'" json:"status"`
+	BookingID *uuid.UUID         `gorm:"type:uuid;index" json:"booking_id,omitempty"` // Standby booking left by a lost priority resolution
+	Notes     string             `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+
+	// Relationships
+	Yacht Yacht `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"-"`
+	User  User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+func (StandbyEntry) TableName() string {
+	return "standby_entries"
+}
+
+// StandbyOffer records dates freed by a cancellation being offered to an
+// owner on standby, and what the owner did with the offer
+type StandbyOffer struct {
+	ID              uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID         uuid.UUID          `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	EntryID         uuid.UUID          `gorm:"type:uuid;not null;index" json:"entry_id"`
+	UserID          uuid.UUID          `gorm:"type:uuid;not null;index" json:"user_id"`
+	SourceBookingID uuid.UUID          `gorm:"type:uuid;not null;index" json:"source_booking_id"` // The cancelled booking
+	StartDate       time.Time          `gorm:"not null" json:"start_date"`
+	EndDate         time.Time          `gorm:"not null" json:"end_date"`
+	PriorityScore   float64            `gorm:"type:decimal(10,2)" json:"priority_score"`
+	Status          StandbyOfferStatus `gorm:"type:varchar(20);not null;index;default:'pending'" json:"status"`
+	ExpiresAt       time.Time          `gorm:"not null;index" json:"expires_at"`
+	RespondedAt     *time.Time         `json:"responded_at,omitempty"`
+	BookingID       *uuid.UUID         `gorm:"type:uuid" json:"booking_id,omitempty"` // Booking created on acceptance
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+
+	// Relationships
+	Entry StandbyEntry `gorm:"foreignKey:EntryID;constraint:OnDelete:CASCADE" json:"-"`
+	User  User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+func (StandbyOffer) TableName() string {
+	return "standby_offers"
+}
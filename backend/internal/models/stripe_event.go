@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// StripeEvent is a Stripe webhook event that has been handled. Stripe
+// retries deliveries until it gets a 2xx and may send an event more than
+// once, so recording its ID makes repeats no-ops.
+type StripeEvent struct {
+	ID          string    `gorm:"size:255;primary_key" json:"id"` // Stripe's event ID
+	Type        string    `gorm:"size:100;not null" json:"type"`
+	ObjectID    string    `gorm:"size:255;index" json:"object_id"` // The intent or charge it was about
+	CreatedAt   time.Time `json:"created_at"`                      // When Stripe created it
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+func (StripeEvent) TableName() string {
+	return "stripe_events"
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SwapOfferStatus string
+
+const (
+	SwapOfferStatusPending   SwapOfferStatus = "pending"
+	SwapOfferStatusAccepted  SwapOfferStatus = "accepted"
+	SwapOfferStatusDeclined  SwapOfferStatus = "declined"
+	SwapOfferStatusWithdrawn SwapOfferStatus = "withdrawn"
+	SwapOfferStatusVoid      SwapOfferStatus = "void" // One of the bookings changed before the offer was answered
+)
+
+// SwapOffer is one owner's proposal to exchange their booking for another
+// owner's booking on the same yacht
+type SwapOffer struct {
+	ID                 uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID            uuid.UUID       `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	ProposerID         uuid.UUID       `gorm:"type:uuid;not null;index" json:"proposer_id"`
+	RecipientID        uuid.UUID       `gorm:"type:uuid;not null;index" json:"recipient_id"`
+	OfferedBookingID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"offered_booking_id"`
+	RequestedBookingID uuid.UUID       `gorm:"type:uuid;not null;index" json:"requested_booking_id"`
+	OfferedStartDate   time.Time       `gorm:"not null" json:"offered_start_date"` // Dates of both bookings when the offer was made
+	OfferedEndDate     time.Time       `gorm:"not null" json:"offered_end_date"`
+	RequestedStartDate time.Time       `gorm:"not null" json:"requested_start_date"`
+	RequestedEndDate   time.Time       `gorm:"not null" json:"requested_end_date"`
+	OfferedValue       float64         `gorm:"type:decimal(10,2);not null" json:"offered_value"`
+	RequestedValue     float64         `gorm:"type:decimal(10,2);not null" json:"requested_value"`
+	CreditAdjustment   float64         `gorm:"type:decimal(10,2);not null" json:"credit_adjustment"` // Positive = recipient pays proposer
+	Status             SwapOfferStatus `gorm:"type:varchar(20);not null;index;default:'pending'" json:"status"`
+	Message            string          `gorm:"type:text" json:"message,omitempty"`
+	StatusNote         string          `gorm:"type:text" json:"status_note,omitempty"`
+	RespondedAt        *time.Time      `json:"responded_at,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+
+	// Relationships
+	Proposer         User    `gorm:"foreignKey:ProposerID" json:"proposer,omitempty"`
+	Recipient        User    `gorm:"foreignKey:RecipientID" json:"recipient,omitempty"`
+	OfferedBooking   Booking `gorm:"foreignKey:OfferedBookingID;constraint:OnDelete:CASCADE" json:"offered_booking,omitempty"`
+	RequestedBooking Booking `gorm:"foreignKey:RequestedBookingID;constraint:OnDelete:CASCADE" json:"requested_booking,omitempty"`
+}
+
+func (SwapOffer) TableName() string {
+	return "swap_offers"
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// SyndicateSettings holds a yacht syndicate's fair share and booking rules.
+// Yachts without a row use the defaults from the fair share specification.
+type SyndicateSettings struct {
+	ID      uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"yacht_id"`
+
+	// Slot valuation
+	StandardWeekdayWeight float64        `gorm:"type:decimal(4,2);not null" json:"standard_weekday_weight"`
+	PeakWeekdayWeight     float64        `gorm:"type:decimal(4,2);not null" json:"peak_weekday_weight"`
+	WeekendWeight         float64        `gorm:"type:decimal(4,2);not null" json:"weekend_weight"`
+	PremiumWeekendWeight  float64        `gorm:"type:decimal(4,2);not null" json:"premium_weekend_weight"`
+	HolidayWeight         float64        `gorm:"type:decimal(4,2);not null" json:"holiday_weight"`
+	Seasons               datatypes.JSON `gorm:"type:jsonb;not null" json:"seasons"` // Array of {name, months, multiplier, peak}
+	RolloverDecay         float64        `gorm:"type:decimal(4,2);not null" json:"rollover_decay"`
+	HolidayRegion         string         `gorm:"size:8;not null;default:''" json:"holiday_region"` // e.g. AU; empty for no bundled holidays
+	HolidayState          string         `gorm:"size:8;not null;default:''" json:"holiday_state"`  // e.g. QLD; empty for national holidays only
+
+	// Booking rules
+	CollectionLeadDays     int     `gorm:"not null" json:"collection_lead_days"` // Requests this far ahead are allocated by priority
+	MaxAdvanceDays         int     `gorm:"not null" json:"max_advance_days"`
+	MaxConsecutiveDays     int     `gorm:"not null" json:"max_consecutive_days"`
+	MinTripGapDays         int     `gorm:"not null" json:"min_trip_gap_days"` // Between an owner's trips
+	TurnaroundHours        int     `gorm:"not null" json:"turnaround_hours"`  // Cleaning buffer between different owners
+	CancellationFeePercent float64 `gorm:"type:decimal(5,2);not null" json:"cancellation_fee_percent"`
+	LateCancellationDays   int     `gorm:"not null" json:"late_cancellation_days"` // Cancelling closer than this incurs the fee
+
+	// Drafts, standby and swaps
+	DraftEnabled      bool `gorm:"not null" json:"draft_enabled"`
+	DraftPickHours    int  `gorm:"not null" json:"draft_pick_hours"`
+	StandbyOfferHours int  `gorm:"not null" json:"standby_offer_hours"`
+	SwapLockDays      int  `gorm:"not null" json:"swap_lock_days"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Relationships
+	Yacht Yacht `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (SyndicateSettings) TableName() string {
+	return "syndicate_settings"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+// TestSyndicateSettingsCreateZeroTripRules tests that trip rules first
+// saved as zero are stored as zero rather than taking a column default
+func TestSyndicateSettingsCreateZeroTripRules(t *testing.T) {
+	settings := SyndicateSettings{
+		YachtID:         uuid.New(),
+		Seasons:         datatypes.JSON(`[]`),
+		MaxAdvanceDays:  365,
+		MinTripGapDays:  0,
+		TurnaroundHours: 0,
+	}
+
+	inserted := insertedValues(t, &settings)
+	require.Contains(t, inserted, "min_trip_gap_days")
+	require.Contains(t, inserted, "turnaround_hours")
+	assert.Equal(t, 0, inserted["min_trip_gap_days"])
+	assert.Equal(t, 0, inserted["turnaround_hours"])
+}
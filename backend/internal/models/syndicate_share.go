@@ -7,14 +7,15 @@ import (
 )
 
 type SyndicateShare struct {
-	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	YachtID         uuid.UUID `gorm:"type:uuid;not null;index" json:"yacht_id"`
-	UserID          uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	SharePercentage float64   `gorm:"type:decimal(5,2);not null" json:"share_percentage"`
-	DaysPerYear     int       `gorm:"not null" json:"days_per_year"`
-	JoinedDate      time.Time `gorm:"type:date" json:"joined_date"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	YachtID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"yacht_id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	SharePercentage float64    `gorm:"type:decimal(5,2);not null" json:"share_percentage"`
+	DaysPerYear     int        `gorm:"not null" json:"days_per_year"`
+	JoinedDate      time.Time  `gorm:"type:date" json:"joined_date"`
+	EndedDate       *time.Time `gorm:"type:date;index" json:"ended_date,omitempty"` // First day the share is no longer held
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 
 	// Relationships
 	Yacht Yacht `gorm:"foreignKey:YachtID;constraint:OnDelete:CASCADE" json:"yacht,omitempty"`
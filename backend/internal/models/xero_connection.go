@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// XeroConnection is the OAuth2 grant for the Xero organisation invoices and
+// payments are synced with. There is at most one; connecting again replaces
+// it.
+type XeroConnection struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     string     `gorm:"size:255;not null" json:"tenant_id"`
+	TenantName   string     `gorm:"size:255" json:"tenant_name"`
+	AccessToken  string     `gorm:"type:text;not null" json:"-"`
+	RefreshToken string     `gorm:"type:text;not null" json:"-"`
+	TokenExpiry  time.Time  `json:"token_expiry"`
+	ConnectedBy  *uuid.UUID `gorm:"type:uuid" json:"connected_by,omitempty"`
+	LastPulledAt *time.Time `json:"last_pulled_at,omitempty"` // Start of the last completed status pull
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (XeroConnection) TableName() string {
+	return "xero_connections"
+}
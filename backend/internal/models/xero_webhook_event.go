@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type XeroWebhookEventStatus string
+
+const (
+	XeroWebhookEventPending   XeroWebhookEventStatus = "pending"
+	XeroWebhookEventProcessed XeroWebhookEventStatus = "processed"
+	XeroWebhookEventIgnored   XeroWebhookEventStatus = "ignored" // Not for a record the app syncs
+	XeroWebhookEventFailed    XeroWebhookEventStatus = "failed"  // Gave up after repeated errors
+)
+
+// XeroWebhookEvent is a change notification received from Xero, queued so
+// the webhook can be acknowledged straight away. Xero may deliver an event
+// more than once; EventKey identifies it so repeats are dropped.
+type XeroWebhookEvent struct {
+	ID            uuid.UUID              `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EventKey      string                 `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	TenantID      string                 `gorm:"size:255;not null" json:"tenant_id"`
+	EventCategory string                 `gorm:"size:50;not null" json:"event_category"`
+	EventType     string                 `gorm:"size:50;not null" json:"event_type"`
+	ResourceID    string                 `gorm:"size:255;not null;index" json:"resource_id"`
+	EventDate     time.Time              `json:"event_date"`
+	Status        XeroWebhookEventStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Attempts      int                    `gorm:"not null" json:"attempts"`
+	NextAttemptAt time.Time              `gorm:"index" json:"next_attempt_at"`
+	LastError     string                 `gorm:"type:text" json:"last_error,omitempty"`
+	ProcessedAt   *time.Time             `json:"processed_at,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+func (XeroWebhookEvent) TableName() string {
+	return "xero_webhook_events"
+}
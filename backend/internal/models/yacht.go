@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 )
@@ -20,8 +21,9 @@ type Yacht struct {
 	Registration        string         `gorm:"size:255" json:"registration"`
 	RegistrationCountry string         `gorm:"size:100" json:"registration_country"`
 	HomePort            string         `gorm:"size:255" json:"home_port"`
-	BerthLocation       string         `gorm:"size:255" json:"berth_location"`        // Marina/berth name
-	BerthBayNumber      string         `gorm:"size:50" json:"berth_bay_number"`       // Bay/slip number
+	BerthLocation       string         `gorm:"size:255" json:"berth_location"`                         // Marina/berth name
+	BerthBayNumber      string         `gorm:"size:50" json:"berth_bay_number"`                        // Bay/slip number
+	Currency            money.Currency `gorm:"type:varchar(3);not null;default:'AUD'" json:"currency"` // What the syndicate is invoiced in
 	MaxPassengers       int            `json:"max_passengers"`
 	CruisingSpeedKnots  float64        `gorm:"type:decimal(10,2)" json:"cruising_speed_knots"`
 	MaxSpeedKnots       float64        `gorm:"type:decimal(10,2)" json:"max_speed_knots"`
@@ -34,8 +36,8 @@ type Yacht struct {
 	EngineHours         float64        `gorm:"type:decimal(10,2)" json:"engine_hours"`
 	TransmissionType    string         `gorm:"size:100" json:"transmission_type"`
 	HeroImageURL        string         `gorm:"size:500" json:"hero_image_url"`
-	GalleryImages       datatypes.JSON `gorm:"type:jsonb" json:"gallery_images"`        // Array of image URLs
-	Specifications      datatypes.JSON `gorm:"type:jsonb" json:"specifications"`        // Additional flexible specs
+	GalleryImages       datatypes.JSON `gorm:"type:jsonb" json:"gallery_images"` // Array of image URLs
+	Specifications      datatypes.JSON `gorm:"type:jsonb" json:"specifications"` // Additional flexible specs
 	CreatedAt           time.Time      `json:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at"`
 }
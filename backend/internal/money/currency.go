@@ -0,0 +1,171 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+// Currencies the app bills in
+const (
+	AUD Currency = "AUD"
+	NZD Currency = "NZD"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+)
+
+// DefaultCurrency is the currency of amounts read without one, from a
+// database column or a JSON number, and of yachts that haven't chosen one
+const DefaultCurrency = AUD
+
+var ErrUnsupportedCurrency = errors.New("money: unsupported currency")
+
+// currencyFormat is how a currency is written. symbol is used where the
+// currency is the local one and intlSymbol elsewhere, so A$ and US$ can be
+// told apart.
+type currencyFormat struct {
+	symbol     string
+	intlSymbol string
+	locale     Locale // Where it is the local currency
+}
+
+var currencies = map[Currency]currencyFormat{
+	AUD: {symbol: "$", intlSymbol: "A$", locale: LocaleAU},
+	NZD: {symbol: "$", intlSymbol: "NZ$", locale: LocaleNZ},
+	USD: {symbol: "$", intlSymbol: "US$", locale: LocaleUS},
+	GBP: {symbol: "£", intlSymbol: "£", locale: LocaleGB},
+	EUR: {symbol: "€", intlSymbol: "€", locale: LocaleIE},
+}
+
+// ParseCurrency reads a supported currency code, in any case
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if !c.Supported() {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedCurrency, s)
+	}
+	return c, nil
+}
+
+// Supported reports whether the app can bill in the currency
+func (c Currency) Supported() bool {
+	_, ok := currencies[c]
+	return ok
+}
+
+// Digits is the number of decimal places in the currency's minor unit
+func (c Currency) Digits() int {
+	return 2
+}
+
+// Locale returns where the currency is the local one, or DefaultLocale
+func (c Currency) Locale() Locale {
+	if f, ok := currencies[c]; ok {
+		return f.locale
+	}
+	return DefaultLocale
+}
+
+// Locale is a BCP 47 language tag such as en-AU
+type Locale string
+
+// Locales amounts can be formatted for
+const (
+	LocaleAU Locale = "en-AU"
+	LocaleNZ Locale = "en-NZ"
+	LocaleGB Locale = "en-GB"
+	LocaleUS Locale = "en-US"
+	LocaleIE Locale = "en-IE"
+)
+
+// DefaultLocale is used when a client asks for none the app supports
+const DefaultLocale = LocaleAU
+
+// localeFormat is how a locale writes numbers
+type localeFormat struct {
+	currency Currency // The local currency
+	group    string   // Thousands separator
+	decimal  string
+}
+
+var locales = map[Locale]localeFormat{
+	LocaleAU: {currency: AUD, group: ",", decimal: "."},
+	LocaleNZ: {currency: NZD, group: ",", decimal: "."},
+	LocaleGB: {currency: GBP, group: ",", decimal: "."},
+	LocaleUS: {currency: USD, group: ",", decimal: "."},
+	LocaleIE: {currency: EUR, group: ",", decimal: "."},
+}
+
+// MatchLocale picks the first supported locale from an Accept-Language
+// header, such as "en-GB,en;q=0.9", or DefaultLocale if there is none
+func MatchLocale(acceptLanguage string) Locale {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, region, ok := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+		if !ok {
+			continue
+		}
+		locale := Locale(strings.ToLower(lang) + "-" + strings.ToUpper(region))
+		if _, ok := locales[locale]; ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// Format writes the amount as a reader in the locale expects, such as
+// "$1,234.50" for Australian dollars in en-AU or "A$1,234.50" in en-US.
+// Unsupported locales are treated as DefaultLocale.
+func (m Money) Format(locale Locale) string {
+	lf, ok := locales[locale]
+	if !ok {
+		lf = locales[DefaultLocale]
+	}
+
+	currency := m.currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	symbol := string(currency) + " "
+	if cf, ok := currencies[currency]; ok {
+		symbol = cf.intlSymbol
+		if lf.currency == currency {
+			symbol = cf.symbol
+		}
+	}
+
+	digits := m.Decimal()
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	b.WriteString(symbol)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(lf.group)
+		}
+		b.WriteRune(r)
+	}
+	if fraction != "" {
+		b.WriteString(lf.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// Display formats the amount for the currency's own locale, for messages
+// written without a reader's locale such as notifications
+func (m Money) Display() string {
+	currency := m.currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return m.Format(currency.Locale())
+}
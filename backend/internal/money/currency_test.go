@@ -0,0 +1,56 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseCurrency tests reading supported currency codes
+func TestParseCurrency(t *testing.T) {
+	c, err := ParseCurrency(" nzd ")
+	require.NoError(t, err)
+	assert.Equal(t, NZD, c)
+
+	_, err = ParseCurrency("XYZ")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	_, err = ParseCurrency("")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+// TestMatchLocale tests picking a locale from an Accept-Language header
+func TestMatchLocale(t *testing.T) {
+	assert.Equal(t, LocaleGB, MatchLocale("en-GB,en;q=0.9"))
+	assert.Equal(t, LocaleNZ, MatchLocale("en_nz"))
+	assert.Equal(t, LocaleUS, MatchLocale("fr-FR, en, en-US;q=0.5"))
+	assert.Equal(t, DefaultLocale, MatchLocale("de-DE"))
+	assert.Equal(t, DefaultLocale, MatchLocale(""))
+}
+
+// TestFormatLocale tests writing amounts as readers in a locale expect
+func TestFormatLocale(t *testing.T) {
+	tests := []struct {
+		amount Money
+		locale Locale
+		want   string
+	}{
+		{New(123450, AUD), LocaleAU, "$1,234.50"},
+		{New(123450, AUD), LocaleUS, "A$1,234.50"},
+		{New(123450, NZD), LocaleNZ, "$1,234.50"},
+		{New(123450, NZD), LocaleAU, "NZ$1,234.50"},
+		{New(123450, USD), LocaleGB, "US$1,234.50"},
+		{New(99, GBP), LocaleGB, "£0.99"},
+		{New(123456789, GBP), LocaleAU, "£1,234,567.89"},
+		{New(-250000, AUD), LocaleAU, "-$2,500.00"},
+		{New(50000, EUR), LocaleIE, "€500.00"},
+		{New(100, AUD), "xx-XX", "$1.00"},
+		{Money{}, LocaleAU, "$0.00"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.amount.Format(tt.locale))
+	}
+
+	assert.Equal(t, "$1,234.50", New(123450, NZD).Display())
+	assert.Equal(t, "£12.00", New(1200, GBP).Display())
+}
@@ -0,0 +1,361 @@
+// Package money represents amounts of money exactly, as a whole number of a
+// currency's minor units such as cents, so that sums and splits never gain
+// or lose a cent to floating point rounding
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("money: currencies differ")
+	ErrInvalidAmount    = errors.New("money: invalid amount")
+)
+
+// Money is an amount in a currency. The zero value is zero in no particular
+// currency, which can be added to or compared with an amount in any
+// currency.
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// New returns minor units, such as cents, of a currency
+func New(minor int64, currency Currency) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// Zero returns nothing of a currency
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// Parse reads a decimal amount such as "1234.50" exactly. Digits beyond the
+// currency's minor unit are rounded half away from zero.
+func Parse(s string, currency Currency) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return fromRat(r, currency)
+}
+
+// FromFloat converts a float amount, such as one from a third party API, to
+// the nearest minor unit. The float's shortest decimal form is used, so
+// 1.005 becomes 1.01 rather than 1.00.
+func FromFloat(amount float64, currency Currency) Money {
+	m, err := Parse(strconv.FormatFloat(amount, 'f', -1, 64), currency)
+	if err != nil {
+		// NaN and infinities have no decimal form
+		return Zero(currency)
+	}
+	return m
+}
+
+// MustParse is Parse for amounts known to be valid, such as constants. It
+// panics on an invalid amount.
+func MustParse(s string, currency Currency) Money {
+	m, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func fromRat(r *big.Rat, currency Currency) (Money, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(currency.Digits())))
+	minor := roundHalfAway(scaled)
+	if !minor.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s is too large", ErrInvalidAmount, r.FloatString(currency.Digits()))
+	}
+	return Money{minor: minor.Int64(), currency: currency}, nil
+}
+
+// Minor returns the amount in minor units, such as cents
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Currency returns the amount's currency, empty for the zero value
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// In returns the amount with its currency set. It is for amounts read
+// without one, such as from a database column, and does not convert.
+func (m Money) In(currency Currency) Money {
+	m.currency = currency
+	return m
+}
+
+// Float64 returns the amount as a float for APIs that take one. It must not
+// be used for arithmetic.
+func (m Money) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(big.NewInt(m.minor), pow10(m.digits())).Float64()
+	return f
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsPositive reports whether the amount is more than zero
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// SameCurrency reports whether two amounts can be added or compared
+func (m Money) SameCurrency(o Money) bool {
+	return m.currency == "" || o.currency == "" || m.currency == o.currency
+}
+
+// Add returns m + o. It panics if the currencies differ; use Sum to add
+// amounts that may not share a currency.
+func (m Money) Add(o Money) Money {
+	return Money{minor: m.minor + o.minor, currency: m.common(o)}
+}
+
+// Sub returns m - o. It panics if the currencies differ.
+func (m Money) Sub(o Money) Money {
+	return Money{minor: m.minor - o.minor, currency: m.common(o)}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or more than o. It
+// panics if the currencies differ.
+func (m Money) Cmp(o Money) int {
+	m.common(o)
+	switch {
+	case m.minor < o.minor:
+		return -1
+	case m.minor > o.minor:
+		return 1
+	}
+	return 0
+}
+
+// Min returns the smaller of m and o
+func (m Money) Min(o Money) Money {
+	if m.Cmp(o) <= 0 {
+		return m.In(m.common(o))
+	}
+	return o.In(m.common(o))
+}
+
+// Max returns the larger of m and o
+func (m Money) Max(o Money) Money {
+	if m.Cmp(o) >= 0 {
+		return m.In(m.common(o))
+	}
+	return o.In(m.common(o))
+}
+
+// Scale multiplies the amount by a factor, such as a share of a period,
+// rounding to the nearest minor unit
+func (m Money) Scale(factor float64) Money {
+	f := new(big.Rat)
+	if f.SetFloat64(factor) == nil {
+		return Zero(m.currency)
+	}
+	scaled := roundHalfAway(f.Mul(f, new(big.Rat).SetInt64(m.minor)))
+	return Money{minor: scaled.Int64(), currency: m.currency}
+}
+
+// Allocate divides the amount between ratios, such as share percentages, so
+// the parts add up to exactly the amount. Each part is rounded toward zero
+// and the minor units left over go to the parts with the largest
+// remainders, earlier parts winning ties. Ratios that are not positive get
+// nothing. Returns nil if no ratio is positive.
+func (m Money) Allocate(ratios ...float64) []Money {
+	total := new(big.Rat)
+	weights := make([]*big.Rat, len(ratios))
+	for i, ratio := range ratios {
+		weights[i] = new(big.Rat)
+		if ratio > 0 && weights[i].SetFloat64(ratio) != nil {
+			total.Add(total, weights[i])
+		} else {
+			weights[i].SetInt64(0)
+		}
+	}
+	if total.Sign() == 0 {
+		return nil
+	}
+
+	amount := m.minor
+	if amount < 0 {
+		amount = -amount
+	}
+	parts := make([]Money, len(ratios))
+	remainders := make([]*big.Rat, len(ratios))
+	allocated := int64(0)
+	for i, weight := range weights {
+		exact := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), weight)
+		exact.Quo(exact, total)
+		whole := new(big.Int).Quo(exact.Num(), exact.Denom())
+		parts[i] = Money{minor: whole.Int64(), currency: m.currency}
+		remainders[i] = exact.Sub(exact, new(big.Rat).SetInt(whole))
+		allocated += parts[i].minor
+	}
+
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]].Cmp(remainders[order[b]]) > 0 })
+	for i := 0; allocated < amount; i++ {
+		parts[order[i%len(order)]].minor++
+		allocated++
+	}
+
+	if m.minor < 0 {
+		for i := range parts {
+			parts[i].minor = -parts[i].minor
+		}
+	}
+	return parts
+}
+
+// Split divides the amount into n parts as equal as possible, earlier
+// parts taking any minor units left over. Returns nil if n is not positive.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	ratios := make([]float64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// Decimal formats the amount as a plain decimal, such as "-1234.50"
+func (m Money) Decimal() string {
+	return new(big.Rat).SetFrac(big.NewInt(m.minor), pow10(m.digits())).FloatString(m.digits())
+}
+
+// String formats the amount with its currency, such as "AUD 1234.50"
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Decimal()
+	}
+	return string(m.currency) + " " + m.Decimal()
+}
+
+// Sum adds amounts that must share a currency, returning
+// ErrCurrencyMismatch if they don't
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, amount := range amounts {
+		if !total.SameCurrency(amount) {
+			return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, total.currency, amount.currency)
+		}
+		total = total.Add(amount)
+	}
+	return total, nil
+}
+
+// MarshalJSON encodes the amount as a plain JSON number, such as 1234.50,
+// so clients that read amounts as numbers keep working. The currency is not
+// included; records report it alongside.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or numeric string in the default
+// currency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := Parse(s, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount in a decimal column
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+// Scan reads the amount from a decimal column, in the default currency
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*m = Zero(DefaultCurrency)
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+
+	parsed, err := Parse(s, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// common returns the currency two amounts share, panicking if they don't
+func (m Money) common(o Money) Currency {
+	if !m.SameCurrency(o) {
+		panic(fmt.Sprintf("%v: %s and %s", ErrCurrencyMismatch, m.currency, o.currency))
+	}
+	if m.currency == "" {
+		return o.currency
+	}
+	return m.currency
+}
+
+func (m Money) digits() int {
+	if m.currency == "" {
+		return DefaultCurrency.Digits()
+	}
+	return m.currency.Digits()
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundHalfAway rounds to the nearest integer, halves away from zero
+func roundHalfAway(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+	// floor((2|num| + den) / 2den)
+	q := new(big.Int).Mul(num, big.NewInt(2))
+	q.Add(q, den)
+	q.Quo(q, new(big.Int).Mul(den, big.NewInt(2)))
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
@@ -0,0 +1,163 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParse tests reading decimal amounts exactly
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1234.50", 123450},
+		{"1234.5", 123450},
+		{"1234", 123400},
+		{"0.07", 7},
+		{"-12.34", -1234},
+		{"1234.5000", 123450},
+		{"0.005", 1},
+		{"-0.005", -1},
+		{"0.0049", 0},
+	}
+	for _, tt := range tests {
+		m, err := Parse(tt.in, AUD)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, New(tt.want, AUD), m, tt.in)
+	}
+
+	_, err := Parse("12,34", AUD)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	_, err = Parse("1e30", AUD)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+// TestFromFloat tests converting floats by their shortest decimal form
+func TestFromFloat(t *testing.T) {
+	assert.Equal(t, New(101, AUD), FromFloat(1.005, AUD))
+	assert.Equal(t, New(30, AUD), FromFloat(0.1+0.2, AUD))
+	assert.Equal(t, New(245000, AUD), FromFloat(2450, AUD))
+	assert.Equal(t, New(-1999, AUD), FromFloat(-19.99, AUD))
+}
+
+// TestFormat tests formatting amounts as decimals
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "1234.50", New(123450, AUD).Decimal())
+	assert.Equal(t, "-0.05", New(-5, AUD).Decimal())
+	assert.Equal(t, "0.00", Money{}.Decimal())
+	assert.Equal(t, "AUD 1234.50", New(123450, AUD).String())
+	assert.Equal(t, 1234.5, New(123450, AUD).Float64())
+}
+
+// TestArithmetic tests adding and comparing amounts
+func TestArithmetic(t *testing.T) {
+	a, b := New(1000, AUD), New(250, AUD)
+	assert.Equal(t, New(1250, AUD), a.Add(b))
+	assert.Equal(t, New(750, AUD), a.Sub(b))
+	assert.Equal(t, New(-1000, AUD), a.Neg())
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(a))
+	assert.Equal(t, 0, a.Cmp(New(1000, AUD)))
+	assert.Equal(t, b, a.Min(b))
+	assert.Equal(t, a, a.Max(b))
+
+	// The zero value takes on the other amount's currency
+	assert.Equal(t, a, Money{}.Add(a))
+	assert.Equal(t, Zero(AUD), b.Sub(b).Max(Money{}))
+
+	assert.Panics(t, func() { a.Add(New(1, USD)) })
+	assert.Panics(t, func() { a.Cmp(New(1, USD)) })
+
+	assert.Equal(t, New(2000, AUD), New(3999, AUD).Scale(0.5))
+	assert.Equal(t, New(-2000, AUD), New(-3999, AUD).Scale(0.5))
+}
+
+// TestSum tests adding amounts that may not share a currency
+func TestSum(t *testing.T) {
+	total, err := Sum(New(100, AUD), New(250, AUD), Money{})
+	require.NoError(t, err)
+	assert.Equal(t, New(350, AUD), total)
+
+	total, err = Sum()
+	require.NoError(t, err)
+	assert.True(t, total.IsZero())
+
+	_, err = Sum(New(100, AUD), New(100, NZD))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+// TestAllocate tests splitting an amount so the parts sum exactly
+func TestAllocate(t *testing.T) {
+	thousand := New(100000, AUD)
+	assert.Equal(t, []Money{New(33334, AUD), New(33333, AUD), New(33333, AUD)}, thousand.Allocate(1, 1, 1))
+	assert.Equal(t, []Money{New(40000, AUD), New(35000, AUD), New(25000, AUD)}, thousand.Allocate(40, 35, 25))
+
+	// Ratios that do not add up to 100% are scaled up
+	assert.Equal(t, []Money{New(60000, AUD), Zero(AUD), New(40000, AUD)}, thousand.Allocate(30, 0, 20))
+
+	// The largest remainders take the leftover cents, earlier parts first
+	assert.Equal(t, []Money{New(34, AUD), New(33, AUD), New(33, AUD)}, New(100, AUD).Allocate(1, 1, 1))
+	assert.Equal(t, []Money{New(1, AUD), New(2, AUD)}, New(3, AUD).Allocate(1, 2))
+
+	parts := New(123457, AUD).Allocate(12.5, 33.3, 20, 34.2)
+	total, err := Sum(parts...)
+	require.NoError(t, err)
+	assert.Equal(t, New(123457, AUD), total)
+
+	// Negative amounts split the same way
+	assert.Equal(t, []Money{New(-34, AUD), New(-33, AUD), New(-33, AUD)}, New(-100, AUD).Allocate(1, 1, 1))
+
+	assert.Nil(t, New(10000, AUD).Allocate(0, 0))
+	assert.Nil(t, New(10000, AUD).Allocate())
+}
+
+// TestSplit tests splitting an amount into equal parts
+func TestSplit(t *testing.T) {
+	assert.Equal(t, []Money{New(4, AUD), New(3, AUD), New(3, AUD)}, New(10, AUD).Split(3))
+	assert.Equal(t, []Money{Zero(AUD), Zero(AUD)}, Zero(AUD).Split(2))
+	assert.Nil(t, New(10, AUD).Split(0))
+}
+
+// TestJSON tests amounts round-tripping as JSON numbers
+func TestJSON(t *testing.T) {
+	type invoice struct {
+		Amount Money  `json:"amount"`
+		Cost   *Money `json:"cost"`
+	}
+
+	raw, err := json.Marshal(invoice{Amount: New(245050, AUD)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": 2450.50, "cost": null}`, string(raw))
+
+	var decoded invoice
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": 2450.5, "cost": "19.99"}`), &decoded))
+	assert.Equal(t, New(245050, DefaultCurrency), decoded.Amount)
+	require.NotNil(t, decoded.Cost)
+	assert.Equal(t, New(1999, DefaultCurrency), *decoded.Cost)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": true}`), &decoded))
+}
+
+// TestSQL tests amounts round-tripping through a decimal column
+func TestSQL(t *testing.T) {
+	value, err := New(245050, AUD).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "2450.50", value)
+
+	for _, src := range []interface{}{"2450.50", []byte("2450.50"), 2450.5} {
+		var m Money
+		require.NoError(t, m.Scan(src))
+		assert.Equal(t, New(245050, DefaultCurrency), m)
+	}
+
+	var m Money
+	require.NoError(t, m.Scan(int64(12)))
+	assert.Equal(t, New(1200, DefaultCurrency), m)
+	require.NoError(t, m.Scan(nil))
+	assert.True(t, m.IsZero())
+	assert.Error(t, m.Scan(true))
+}
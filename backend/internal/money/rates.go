@@ -0,0 +1,71 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+var (
+	ErrNoRate      = errors.New("money: no exchange rate")
+	ErrInvalidRate = errors.New("money: exchange rate must be positive")
+)
+
+// Rates converts amounts between currencies at fixed rates, such as a table
+// managers maintain. A rate from one currency to another also converts the
+// other way at its inverse unless that way has a rate of its own. A nil
+// Rates converts nothing.
+type Rates struct {
+	rates map[[2]Currency]*big.Rat
+}
+
+// NewRates returns an empty table
+func NewRates() *Rates {
+	return &Rates{rates: map[[2]Currency]*big.Rat{}}
+}
+
+// Set records how many units of to one unit of from buys. The rate's
+// shortest decimal form is used, so 0.9134 is exactly 0.9134.
+func (r *Rates) Set(from, to Currency, rate float64) error {
+	exact, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok || exact.Sign() <= 0 {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidRate, from, to)
+	}
+	r.rates[[2]Currency{from, to}] = exact
+	return nil
+}
+
+// Convert returns an amount in another currency, rounded half away from
+// zero to the minor unit. Amounts already in that currency, and the zero
+// value, are returned as they are.
+func (r *Rates) Convert(m Money, to Currency) (Money, error) {
+	if m.currency == "" || m.currency == to {
+		return m.In(to), nil
+	}
+
+	rate, ok := r.rate(m.currency, to)
+	if !ok {
+		return Money{}, fmt.Errorf("%w from %s to %s", ErrNoRate, m.currency, to)
+	}
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(m.minor), rate)
+	converted.Mul(converted, new(big.Rat).SetFrac(pow10(to.Digits()), pow10(m.currency.Digits())))
+	minor := roundHalfAway(converted)
+	if !minor.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s is too large to convert", ErrInvalidAmount, m)
+	}
+	return Money{minor: minor.Int64(), currency: to}, nil
+}
+
+func (r *Rates) rate(from, to Currency) (*big.Rat, bool) {
+	if r == nil {
+		return nil, false
+	}
+	if rate, ok := r.rates[[2]Currency{from, to}]; ok {
+		return rate, true
+	}
+	if rate, ok := r.rates[[2]Currency{to, from}]; ok {
+		return new(big.Rat).Inv(rate), true
+	}
+	return nil, false
+}
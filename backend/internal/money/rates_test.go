@@ -0,0 +1,45 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRatesConvert tests converting amounts with a table of rates
+func TestRatesConvert(t *testing.T) {
+	rates := NewRates()
+	require.NoError(t, rates.Set(NZD, AUD, 0.9134))
+	require.NoError(t, rates.Set(GBP, AUD, 1.95))
+	assert.ErrorIs(t, rates.Set(USD, AUD, 0), ErrInvalidRate)
+
+	converted, err := rates.Convert(New(100000, NZD), AUD)
+	require.NoError(t, err)
+	assert.Equal(t, New(91340, AUD), converted)
+
+	// The inverse of a rate converts back
+	converted, err = rates.Convert(New(91340, AUD), NZD)
+	require.NoError(t, err)
+	assert.Equal(t, New(100000, NZD), converted)
+
+	// Rounded half away from zero
+	converted, err = rates.Convert(New(1, GBP), AUD)
+	require.NoError(t, err)
+	assert.Equal(t, New(2, AUD), converted)
+
+	converted, err = rates.Convert(New(500, AUD), AUD)
+	require.NoError(t, err)
+	assert.Equal(t, New(500, AUD), converted)
+
+	converted, err = rates.Convert(Money{}, USD)
+	require.NoError(t, err)
+	assert.Equal(t, Zero(USD), converted)
+
+	_, err = rates.Convert(New(100, USD), AUD)
+	assert.ErrorIs(t, err, ErrNoRate)
+
+	var none *Rates
+	_, err = none.Convert(New(100, NZD), AUD)
+	assert.ErrorIs(t, err, ErrNoRate)
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrChangeRequestNotFound = errors.New("change request not found")
+	ErrChangeRequestPending  = errors.New("booking already has a pending change request")
+	ErrChangeRequestDecided  = errors.New("change request has already been decided")
+	ErrDecisionReason        = errors.New("a reason is required to reject a change request")
+)
+
+// RequestChangeInput holds the fields required to ask for new booking dates
+type RequestChangeInput struct {
+	BookingID   uuid.UUID
+	RequestedBy uuid.UUID
+	StartDate   time.Time
+	EndDate     time.Time
+	Reason      string
+}
+
+// RequestChange asks a manager to move a booking to new dates. A booking can
+// have one pending change request at a time.
+func (s *BookingService) RequestChange(input RequestChangeInput) (*models.BookingChangeRequest, error) {
+	if err := validateBookingDates(input.StartDate, input.EndDate); err != nil {
+		return nil, err
+	}
+	if input.StartDate.Before(time.Now()) {
+		return nil, ErrBookingInPast
+	}
+
+	request := models.BookingChangeRequest{
+		BookingID:         input.BookingID,
+		RequestedBy:       input.RequestedBy,
+		ProposedStartDate: input.StartDate,
+		ProposedEndDate:   input.EndDate,
+		Reason:            input.Reason,
+		Status:            models.ChangeRequestStatusPending,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := lockBooking(tx, input.BookingID, &booking); err != nil {
+			return err
+		}
+		if !isActiveBooking(booking.Status) {
+			return ErrBookingNotModifiable
+		}
+
+		var pending int64
+		if err := tx.Model(&models.BookingChangeRequest{}).
+			Where("booking_id = ? AND status = ?", booking.ID, models.ChangeRequestStatusPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrChangeRequestPending
+		}
+
+		return tx.Create(&request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// ChangeRequest returns a change request with its booking
+func (s *BookingService) ChangeRequest(requestID uuid.UUID) (*models.BookingChangeRequest, error) {
+	var request models.BookingChangeRequest
+	if err := s.db.Preload("Booking").Preload("Booking.Yacht").
+		Preload("RequestedByUser").Preload("DecidedByUser").
+		First(&request, requestID).Error; err != nil {
+		return nil, translateNotFound(err, ErrChangeRequestNotFound)
+	}
+	return &request, nil
+}
+
+// ChangeRequestsFilter narrows a change request listing
+type ChangeRequestsFilter struct {
+	BookingID *uuid.UUID
+	YachtID   *uuid.UUID
+	Status    models.BookingChangeRequestStatus
+}
+
+// ChangeRequests returns change requests, oldest first so pending requests
+// are reviewed in the order they arrived
+func (s *BookingService) ChangeRequests(filter ChangeRequestsFilter) ([]models.BookingChangeRequest, error) {
+	requests := []models.BookingChangeRequest{}
+	query := s.db.Preload("Booking").Preload("Booking.Yacht").
+		Preload("RequestedByUser").Preload("DecidedByUser")
+	if filter.BookingID != nil {
+		query = query.Where("booking_id = ?", *filter.BookingID)
+	}
+	if filter.YachtID != nil {
+		query = query.Where("booking_id IN (?)",
+			s.db.Model(&models.Booking{}).Select("id").Where("yacht_id = ?", *filter.YachtID))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	err := query.Order("created_at ASC").Find(&requests).Error
+	return requests, err
+}
+
+// ApproveChange moves the booking to the proposed dates, re-running the
+// overlap checks, and records the decision in the same transaction. If the
+// dates are no longer available the request stays pending.
+func (s *BookingService) ApproveChange(requestID, deciderID uuid.UUID, reason string) (*models.BookingChangeRequest, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		request, err := lockChangeRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+
+		var booking models.Booking
+		if err := lockBooking(tx, request.BookingID, &booking); err != nil {
+			return err
+		}
+
+		previousStart, previousEnd := booking.StartDate, booking.EndDate
+		if err := s.applyUpdate(tx, &booking, UpdateBookingInput{
+			StartDate: &request.ProposedStartDate,
+			EndDate:   &request.ProposedEndDate,
+		}); err != nil {
+			return err
+		}
+
+		request.PreviousStartDate = &previousStart
+		request.PreviousEndDate = &previousEnd
+		if err := s.decide(tx, request, models.ChangeRequestStatusApproved, deciderID, reason); err != nil {
+			return err
+		}
+
+		return notifyUser(tx, request.RequestedBy, models.NotificationTypeBooking,
+			"Booking change approved",
+			fmt.Sprintf("Your booking has been moved to %s.", formatBookingDates(&booking)),
+			request.ID, "booking_change_request")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ChangeRequest(requestID)
+}
+
+// RejectChange declines a change request, leaving the booking as it was
+func (s *BookingService) RejectChange(requestID, deciderID uuid.UUID, reason string) (*models.BookingChangeRequest, error) {
+	if reason == "" {
+		return nil, ErrDecisionReason
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		request, err := lockChangeRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if err := s.decide(tx, request, models.ChangeRequestStatusRejected, deciderID, reason); err != nil {
+			return err
+		}
+
+		return notifyUser(tx, request.RequestedBy, models.NotificationTypeBooking,
+			"Booking change declined",
+			"Your booking change request was declined: "+reason,
+			request.ID, "booking_change_request")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ChangeRequest(requestID)
+}
+
+// decide records who decided a change request, when and why
+func (s *BookingService) decide(tx *gorm.DB, request *models.BookingChangeRequest,
+	status models.BookingChangeRequestStatus, deciderID uuid.UUID, reason string) error {
+	now := time.Now()
+	request.Status = status
+	request.DecidedBy = &deciderID
+	request.DecidedAt = &now
+	request.DecisionReason = reason
+	return tx.Save(request).Error
+}
+
+// lockChangeRequest loads a pending change request under a row lock
+func lockChangeRequest(tx *gorm.DB, requestID uuid.UUID) (*models.BookingChangeRequest, error) {
+	var request models.BookingChangeRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, requestID).Error; err != nil {
+		return nil, translateNotFound(err, ErrChangeRequestNotFound)
+	}
+	if request.Status != models.ChangeRequestStatusPending {
+		return nil, ErrChangeRequestDecided
+	}
+	return &request, nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrYachtNotFound = errors.New("yacht not found")
+
+// ResolutionOutcome records what a resolution run decided for one request
+type ResolutionOutcome struct {
+	BookingID     uuid.UUID            `json:"booking_id"`
+	UserID        uuid.UUID            `json:"user_id"`
+	StartDate     time.Time            `json:"start_date"`
+	EndDate       time.Time            `json:"end_date"`
+	Status        models.BookingStatus `json:"status"`
+	PriorityScore float64              `json:"priority_score"`
+	Note          string               `json:"note"`
+}
+
+// scoredRequest is a collected request with its priority score
+type scoredRequest struct {
+	booking models.Booking
+	score   float64
+}
+
+// ResolveRequests allocates collected booking requests for a yacht. Requests
+// are ranked by the owner's priority score; each request is confirmed unless
+// it overlaps a higher ranked request that has already won, in which case it
+// moves to standby (if the owner asked for that) or is rejected. Equal scores
+// are ordered by random draw. Only requests starting before until are
+// resolved; a zero until resolves every outstanding request.
+func (s *BookingService) ResolveRequests(yachtID uuid.UUID, until time.Time) ([]ResolutionOutcome, error) {
+	outcomes := []ResolutionOutcome{}
+	now := time.Now()
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Serialise resolution runs for the same yacht
+		var yacht models.Yacht
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&yacht, yachtID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrYachtNotFound
+			}
+			return err
+		}
+
+		query := tx.Where("yacht_id = ? AND awaiting_resolution = ? AND status = ?",
+			yachtID, true, models.BookingStatusPending)
+		if !until.IsZero() {
+			query = query.Where("start_date < ?", until)
+		}
+
+		var requests []models.Booking
+		if err := query.Order("created_at ASC").Find(&requests).Error; err != nil {
+			return err
+		}
+		if len(requests) == 0 {
+			return nil
+		}
+
+		from, to := requests[0].StartDate, requests[0].EndDate
+		for _, req := range requests[1:] {
+			if req.StartDate.Before(from) {
+				from = req.StartDate
+			}
+			if req.EndDate.After(to) {
+				to = req.EndDate
+			}
+		}
+		standing, err := s.ledger.Standing(tx, yachtID, from, to, now)
+		if err != nil {
+			return err
+		}
+		cfg, err := s.ledger.Config(tx, yachtID)
+		if err != nil {
+			return err
+		}
+		valuer, err := s.ledger.Valuer(tx, yachtID)
+		if err != nil {
+			return err
+		}
+
+		ranked := make([]scoredRequest, len(requests))
+		for i, req := range requests {
+			days := len(valuer.Slots(req.StartDate, req.EndDate))
+			ranked[i] = scoredRequest{booking: req, score: standing.Score(req.UserID, days, now)}
+		}
+
+		// Shuffle first so the stable sort leaves ties in random order
+		rand.Shuffle(len(ranked), func(i, j int) { ranked[i], ranked[j] = ranked[j], ranked[i] })
+		sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+		var winners []scoredRequest
+		for _, req := range ranked {
+			booking := req.booking
+			score := req.score
+			booking.AwaitingResolution = false
+			booking.PriorityScore = &score
+			booking.ResolvedAt = &now
+
+			err := requireSyndicateShare(tx, yachtID, booking.UserID, booking.StartDate, booking.EndDate)
+			if errors.Is(err, ErrNotSyndicateMember) {
+				if err := s.rejectRequest(tx, &booking, "No share in the yacht is held over these dates"); err != nil {
+					return err
+				}
+				outcomes = append(outcomes, newOutcome(booking, score))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if rival := findOverlap(winners, booking); rival != nil {
+				note := fmt.Sprintf("Outranked by a competing request (priority %.2f vs %.2f)", rival.score, score)
+				if rival.score == score {
+					note = fmt.Sprintf("Tied with a competing request on priority %.2f and lost the random draw", score)
+				}
+				if err := s.rejectRequest(tx, &booking, note); err != nil {
+					return err
+				}
+				outcomes = append(outcomes, newOutcome(booking, score))
+				continue
+			}
+
+			// Winners are saved as they are allocated, so the trip rules see
+			// every higher ranked request
+			if err := checkTripRules(tx, cfg, &booking); err != nil {
+				if !isTripRuleError(err) {
+					return err
+				}
+				if err := s.rejectRequest(tx, &booking, "Breaks the syndicate's trip rules ("+err.Error()+")"); err != nil {
+					return err
+				}
+				outcomes = append(outcomes, newOutcome(booking, score))
+				continue
+			}
+
+			booking.Status = models.BookingStatusConfirmed
+			booking.ResolutionNote = fmt.Sprintf("Allocated by priority resolution (priority %.2f)", score)
+
+			// Savepoint so a clash with a booking made outside the collection
+			// window only fails this request
+			err = tx.Transaction(func(sp *gorm.DB) error {
+				if err := sp.Save(&booking).Error; err != nil {
+					return translateBookingError(err)
+				}
+				return s.ledger.SyncBooking(sp, &booking)
+			})
+			if errors.Is(err, ErrBookingOverlap) {
+				if err := s.rejectRequest(tx, &booking, "The dates were booked before this request was resolved"); err != nil {
+					return err
+				}
+				outcomes = append(outcomes, newOutcome(booking, score))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			winners = append(winners, scoredRequest{booking: booking, score: score})
+			outcomes = append(outcomes, newOutcome(booking, score))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return outcomes, nil
+}
+
+// rejectRequest moves a losing request to standby or rejects it, recording why
+func (s *BookingService) rejectRequest(tx *gorm.DB, booking *models.Booking, reason string) error {
+	booking.AwaitingResolution = false
+	if !booking.StandbyIfOutranked {
+		booking.Status = models.BookingStatusRejected
+		booking.ResolutionNote = reason
+		return tx.Save(booking).Error
+	}
+
+	booking.Status = models.BookingStatusStandby
+	booking.ResolutionNote = reason + "; moved to standby"
+	if err := tx.Save(booking).Error; err != nil {
+		return err
+	}
+
+	// Queue the request so it can be offered the dates if they free up
+	return tx.Create(&models.StandbyEntry{
+		YachtID:   booking.YachtID,
+		UserID:    booking.UserID,
+		StartDate: booking.StartDate,
+		EndDate:   booking.EndDate,
+		Status:    models.StandbyEntryStatusWaiting,
+		BookingID: &booking.ID,
+	}).Error
+}
+
+// findOverlap returns the first winning request whose dates overlap booking
+func findOverlap(winners []scoredRequest, booking models.Booking) *scoredRequest {
+	for i := range winners {
+		w := winners[i].booking
+		if w.StartDate.Before(booking.EndDate) && w.EndDate.After(booking.StartDate) {
+			return &winners[i]
+		}
+	}
+	return nil
+}
+
+func newOutcome(booking models.Booking, score float64) ResolutionOutcome {
+	return ResolutionOutcome{
+		BookingID:     booking.ID,
+		UserID:        booking.UserID,
+		StartDate:     booking.StartDate,
+		EndDate:       booking.EndDate,
+		Status:        booking.Status,
+		PriorityScore: score,
+		Note:          booking.ResolutionNote,
+	}
+}
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrBookingNotFound      = errors.New("booking not found")
+	ErrBookingOverlap       = errors.New("booking overlaps an existing booking for this yacht")
+	ErrBookingInvalidDates  = errors.New("booking end date must be after start date")
+	ErrBookingInPast        = errors.New("booking cannot start in the past")
+	ErrBookingNotModifiable = errors.New("booking can no longer be modified")
+	ErrNotSyndicateMember   = errors.New("user does not hold a share in this yacht")
+	ErrDuplicateRequest     = errors.New("you already have a booking request for these dates")
+	ErrBookingTooFarAhead   = errors.New("booking starts too far ahead")
+	ErrBookingTooLong       = errors.New("booking is longer than the syndicate allows")
+)
+
+// Postgres SQLSTATE codes raised by constraint failures
+const (
+	pgUniqueViolation    = "23505"
+	pgExclusionViolation = "23P01"
+)
+
+// BookingService handles booking creation, amendment and cancellation.
+// Every change is posted to the fair share credit ledger in the same
+// transaction.
+type BookingService struct {
+	db     *gorm.DB
+	ledger *fairshare.Ledger
+}
+
+// NewBookingService creates a new booking service
+func NewBookingService(db *gorm.DB, ledger *fairshare.Ledger) *BookingService {
+	return &BookingService{
+		db:     db,
+		ledger: ledger,
+	}
+}
+
+// CreateBookingInput holds the fields required to create a booking
+type CreateBookingInput struct {
+	YachtID   uuid.UUID
+	UserID    uuid.UUID
+	StartDate time.Time
+	EndDate   time.Time
+	Notes     string
+
+	// Move the request to standby rather than rejecting it if it loses a
+	// priority resolution
+	StandbyIfOutranked bool
+}
+
+// UpdateBookingInput holds the optional fields that can be amended on a booking
+type UpdateBookingInput struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+	Notes     *string
+}
+
+// Create books the yacht for a syndicate member. Overlapping bookings are
+// rejected by the bookings_no_live_overlap exclusion constraint.
+//
+// Requests far enough ahead to fall in the collection window are stored as
+// pending and left to compete with other owners' requests until the next
+// priority resolution run.
+func (s *BookingService) Create(input CreateBookingInput) (*models.Booking, error) {
+	if err := validateBookingDates(input.StartDate, input.EndDate); err != nil {
+		return nil, err
+	}
+	if input.StartDate.Before(time.Now()) {
+		return nil, ErrBookingInPast
+	}
+
+	booking := models.Booking{
+		YachtID:   input.YachtID,
+		UserID:    input.UserID,
+		StartDate: input.StartDate,
+		EndDate:   input.EndDate,
+		Status:    models.BookingStatusConfirmed,
+		Notes:     input.Notes,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireSyndicateShare(tx, input.YachtID, input.UserID, input.StartDate, input.EndDate); err != nil {
+			return err
+		}
+
+		cfg, err := s.ledger.Config(tx, input.YachtID)
+		if err != nil {
+			return err
+		}
+		if err := checkBookingLimits(cfg, &booking, time.Now()); err != nil {
+			return err
+		}
+		if err := checkTripRules(tx, cfg, &booking); err != nil {
+			return err
+		}
+		if err := s.checkBookingWindows(tx, &booking, time.Now()); err != nil {
+			return err
+		}
+
+		if time.Until(input.StartDate) >= time.Duration(cfg.CollectionLeadDays)*24*time.Hour {
+			booking.Status = models.BookingStatusPending
+			booking.AwaitingResolution = true
+			booking.StandbyIfOutranked = input.StandbyIfOutranked
+
+			if err := checkRequestAvailable(tx, &booking); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&booking).Error; err != nil {
+			return translateBookingError(err)
+		}
+		return s.ledger.SyncBooking(tx, &booking)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// Update amends the dates and/or notes of a pending or confirmed booking
+func (s *BookingService) Update(bookingID uuid.UUID, input UpdateBookingInput) (*models.Booking, error) {
+	var booking models.Booking
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBooking(tx, bookingID, &booking); err != nil {
+			return err
+		}
+		return s.applyUpdate(tx, &booking, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// applyUpdate amends a locked booking, re-checking its dates
+func (s *BookingService) applyUpdate(tx *gorm.DB, booking *models.Booking, input UpdateBookingInput) error {
+	if !isActiveBooking(booking.Status) {
+		return ErrBookingNotModifiable
+	}
+
+	startDate, endDate := booking.StartDate, booking.EndDate
+	if input.StartDate != nil {
+		startDate = *input.StartDate
+	}
+	if input.EndDate != nil {
+		endDate = *input.EndDate
+	}
+	if err := validateBookingDates(startDate, endDate); err != nil {
+		return err
+	}
+	if !startDate.Equal(booking.StartDate) && startDate.Before(time.Now()) {
+		return ErrBookingInPast
+	}
+
+	if err := requireSyndicateShare(tx, booking.YachtID, booking.UserID, startDate, endDate); err != nil {
+		return err
+	}
+
+	datesChanged := !startDate.Equal(booking.StartDate) || !endDate.Equal(booking.EndDate)
+	booking.StartDate = startDate
+	booking.EndDate = endDate
+	if input.Notes != nil {
+		booking.Notes = *input.Notes
+	}
+
+	if datesChanged {
+		cfg, err := s.ledger.Config(tx, booking.YachtID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkBookingRules(tx, cfg, booking, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if booking.AwaitingResolution {
+		if err := checkRequestAvailable(tx, booking); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Save(booking).Error; err != nil {
+		return translateBookingError(err)
+	}
+	return s.ledger.SyncBooking(tx, booking)
+}
+
+// checkBookingRules applies the syndicate's limits, trip rules and booking
+// windows to a booking's owner and dates
+func (s *BookingService) checkBookingRules(tx *gorm.DB, cfg fairshare.Config, booking *models.Booking, now time.Time) error {
+	if err := checkBookingLimits(cfg, booking, now); err != nil {
+		return err
+	}
+	if err := checkTripRules(tx, cfg, booking); err != nil {
+		return err
+	}
+	return s.checkBookingWindows(tx, booking, now)
+}
+
+// Cancel marks a pending, confirmed or standby booking as cancelled. Dates
+// freed by a held booking are offered to owners on standby.
+func (s *BookingService) Cancel(bookingID uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBooking(tx, bookingID, &booking); err != nil {
+			return err
+		}
+		if !isActiveBooking(booking.Status) && booking.Status != models.BookingStatusStandby {
+			return ErrBookingNotModifiable
+		}
+
+		now := time.Now()
+		debited := booking.Status == models.BookingStatusConfirmed
+		if err := s.release(tx, &booking, now); err != nil {
+			return err
+		}
+		if debited {
+			return s.ledger.ChargeCancellationFee(tx, &booking, now)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// release cancels a locked booking, refunds its credits and offers the freed
+// dates to owners on standby
+func (s *BookingService) release(tx *gorm.DB, booking *models.Booking, now time.Time) error {
+	held := isActiveBooking(booking.Status) && !booking.AwaitingResolution
+
+	if booking.Status == models.BookingStatusStandby {
+		var entry models.StandbyEntry
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ? AND status = ?", booking.ID, models.StandbyEntryStatusWaiting).
+			First(&entry).Error
+		if err == nil {
+			if err := s.withdrawEntry(tx, &entry, now); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	booking.Status = models.BookingStatusCancelled
+	booking.CancelledAt = &now
+
+	if err := tx.Save(booking).Error; err != nil {
+		return err
+	}
+	if err := s.ledger.SyncBooking(tx, booking); err != nil {
+		return err
+	}
+
+	// Offer the freed dates to owners on standby
+	if held {
+		return s.offerFreedDates(tx, booking, now)
+	}
+	return nil
+}
+
+// insertConfirmedBooking creates a confirmed booking inside an existing
+// transaction and debits its credits. Used when the slot has already been
+// allocated by another process, such as a draft pick.
+func (s *BookingService) insertConfirmedBooking(tx *gorm.DB, booking *models.Booking) error {
+	if err := requireSyndicateShare(tx, booking.YachtID, booking.UserID, booking.StartDate, booking.EndDate); err != nil {
+		return err
+	}
+	if err := validateBookingDates(booking.StartDate, booking.EndDate); err != nil {
+		return err
+	}
+
+	booking.Status = models.BookingStatusConfirmed
+	if err := tx.Create(booking).Error; err != nil {
+		return translateBookingError(err)
+	}
+	return s.ledger.SyncBooking(tx, booking)
+}
+
+// lockBooking loads a booking and holds a row lock for the rest of the transaction
+func lockBooking(tx *gorm.DB, bookingID uuid.UUID, booking *models.Booking) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(booking, bookingID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrBookingNotFound
+	}
+	return err
+}
+
+// requireSyndicateShare checks the user holds a share in the yacht over
+// [start, end): one joined before the dates end and not ended before they
+// start
+func requireSyndicateShare(tx *gorm.DB, yachtID, userID uuid.UUID, start, end time.Time) error {
+	var count int64
+	if err := tx.Model(&models.SyndicateShare{}).
+		Scopes(fairshare.HeldBetween(start, end)).
+		Where("yacht_id = ? AND user_id = ?", yachtID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotSyndicateMember
+	}
+	return nil
+}
+
+// checkRequestAvailable rejects a collected request that can never be granted
+// because the dates are already booked, or that duplicates one of the owner's
+// own pending requests
+func checkRequestAvailable(tx *gorm.DB, booking *models.Booking) error {
+	overlapping := tx.Model(&models.Booking{}).
+		Where("yacht_id = ? AND start_date < ? AND end_date > ?", booking.YachtID, booking.EndDate, booking.StartDate).
+		Where("status IN ?", []models.BookingStatus{models.BookingStatusPending, models.BookingStatusConfirmed})
+	if booking.ID != uuid.Nil {
+		overlapping = overlapping.Where("id <> ?", booking.ID)
+	}
+
+	var conflicts []models.Booking
+	if err := overlapping.Select("id", "user_id", "awaiting_resolution").Find(&conflicts).Error; err != nil {
+		return err
+	}
+
+	for _, conflict := range conflicts {
+		if !conflict.AwaitingResolution {
+			return ErrBookingOverlap
+		}
+		if conflict.UserID == booking.UserID {
+			return ErrDuplicateRequest
+		}
+	}
+	return nil
+}
+
+// checkBookingLimits applies the syndicate's advance booking and maximum
+// booking length limits
+func checkBookingLimits(cfg fairshare.Config, booking *models.Booking, now time.Time) error {
+	if booking.StartDate.After(now.AddDate(0, 0, cfg.MaxAdvanceDays)) {
+		return fmt.Errorf("%w: bookings open %d days ahead", ErrBookingTooFarAhead, cfg.MaxAdvanceDays)
+	}
+	if days := len(fairshare.NewValuer(cfg, nil).Slots(booking.StartDate, booking.EndDate)); days > cfg.MaxConsecutiveDays {
+		return fmt.Errorf("%w: %d days requested, the limit is %d", ErrBookingTooLong, days, cfg.MaxConsecutiveDays)
+	}
+	return nil
+}
+
+func validateBookingDates(startDate, endDate time.Time) error {
+	if !endDate.After(startDate) {
+		return ErrBookingInvalidDates
+	}
+	return nil
+}
+
+// isActiveBooking reports whether a booking still holds the yacht
+func isActiveBooking(status models.BookingStatus) bool {
+	return status == models.BookingStatusPending || status == models.BookingStatusConfirmed
+}
+
+// translateBookingError maps database constraint failures to service errors
+func translateBookingError(err error) error {
+	if isPgError(err, pgExclusionViolation) {
+		return ErrBookingOverlap
+	}
+	return err
+}
+
+// isPgError reports whether err is a Postgres error with the given SQLSTATE
+func isPgError(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// formatBookingDates renders a booking's dates for notifications
+func formatBookingDates(b *models.Booking) string {
+	return fmt.Sprintf("%s – %s", b.StartDate.Format("Mon 2 Jan"), b.EndDate.Format("Mon 2 Jan"))
+}
+
+// translateNotFound maps gorm.ErrRecordNotFound to a service error
+func translateNotFound(err, notFound error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
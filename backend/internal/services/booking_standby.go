@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrStandbyEntryNotFound = errors.New("standby entry not found")
+	ErrStandbyOfferNotFound = errors.New("standby offer not found")
+	ErrStandbyNotWaiting    = errors.New("standby entry is no longer waiting")
+	ErrStandbyOfferClosed   = errors.New("standby offer has already been answered")
+	ErrStandbyOfferExpired  = errors.New("standby offer has expired")
+)
+
+// JoinStandbyInput holds the fields required to join a yacht's standby list
+type JoinStandbyInput struct {
+	YachtID   uuid.UUID
+	UserID    uuid.UUID
+	StartDate time.Time
+	EndDate   time.Time
+	Notes     string
+}
+
+// JoinStandby puts an owner on standby for any dates freed within a range
+func (s *BookingService) JoinStandby(input JoinStandbyInput) (*models.StandbyEntry, error) {
+	if err := validateBookingDates(input.StartDate, input.EndDate); err != nil {
+		return nil, err
+	}
+	if !input.EndDate.After(time.Now()) {
+		return nil, ErrBookingInPast
+	}
+
+	entry := models.StandbyEntry{
+		YachtID:   input.YachtID,
+		UserID:    input.UserID,
+		StartDate: input.StartDate,
+		EndDate:   input.EndDate,
+		Status:    models.StandbyEntryStatusWaiting,
+		Notes:     input.Notes,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireSyndicateShare(tx, input.YachtID, input.UserID, input.StartDate, input.EndDate); err != nil {
+			return err
+		}
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// WithdrawStandby takes an entry off the standby list. Any offer the owner has
+// not yet answered is declined and passed on.
+func (s *BookingService) WithdrawStandby(entryID uuid.UUID) (*models.StandbyEntry, error) {
+	var entry models.StandbyEntry
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, entryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrStandbyEntryNotFound
+			}
+			return err
+		}
+		if entry.Status != models.StandbyEntryStatusWaiting {
+			return ErrStandbyNotWaiting
+		}
+
+		return s.withdrawEntry(tx, &entry, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// StandbyEntry returns a standby entry
+func (s *BookingService) StandbyEntry(entryID uuid.UUID) (*models.StandbyEntry, error) {
+	var entry models.StandbyEntry
+	if err := s.db.First(&entry, entryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStandbyEntryNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// StandbyEntries returns a yacht's standby list, optionally for one owner
+func (s *BookingService) StandbyEntries(yachtID uuid.UUID, userID *uuid.UUID) ([]models.StandbyEntry, error) {
+	entries := []models.StandbyEntry{}
+	query := s.db.Preload("User").Where("yacht_id = ?", yachtID)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Order("start_date ASC, created_at ASC").Find(&entries).Error
+	return entries, err
+}
+
+// StandbyOffersFilter narrows a standby offer listing
+type StandbyOffersFilter struct {
+	YachtID *uuid.UUID
+	UserID  *uuid.UUID
+	Status  models.StandbyOfferStatus
+}
+
+// StandbyOffers returns standby offers and their outcomes, newest first
+func (s *BookingService) StandbyOffers(filter StandbyOffersFilter) ([]models.StandbyOffer, error) {
+	offers := []models.StandbyOffer{}
+	query := s.db.Preload("User")
+	if filter.YachtID != nil {
+		query = query.Where("yacht_id = ?", *filter.YachtID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	err := query.Order("created_at DESC").Find(&offers).Error
+	return offers, err
+}
+
+// AcceptStandbyOffer books the offered dates for the owner. If the offer has
+// expired or the dates have since been booked, it passes to the next owner and
+// an error is returned.
+func (s *BookingService) AcceptStandbyOffer(offerID, userID uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	var failure error
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		offer, err := s.lockOffer(tx, offerID, userID)
+		if err != nil {
+			return err
+		}
+
+		if !now.Before(offer.ExpiresAt) {
+			failure = ErrStandbyOfferExpired
+			return s.closeOffer(tx, offer, models.StandbyOfferStatusExpired, now)
+		}
+
+		var entry models.StandbyEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, offer.EntryID).Error; err != nil {
+			return err
+		}
+
+		// Savepoint so a clash with another booking only lapses this offer
+		err = tx.Transaction(func(sp *gorm.DB) error {
+			if entry.BookingID != nil {
+				// Promote the standby booking left by a lost priority resolution
+				if err := lockBooking(sp, *entry.BookingID, &booking); err != nil {
+					return err
+				}
+				booking.StartDate = offer.StartDate
+				booking.EndDate = offer.EndDate
+				booking.Status = models.BookingStatusConfirmed
+				booking.ResolutionNote = "Allocated from standby after a cancellation"
+				if err := sp.Save(&booking).Error; err != nil {
+					return translateBookingError(err)
+				}
+				return s.ledger.SyncBooking(sp, &booking)
+			}
+
+			booking = models.Booking{
+				YachtID:   offer.YachtID,
+				UserID:    offer.UserID,
+				StartDate: offer.StartDate,
+				EndDate:   offer.EndDate,
+				Notes:     "Allocated from standby after a cancellation",
+			}
+			return s.insertConfirmedBooking(sp, &booking)
+		})
+		if errors.Is(err, ErrBookingOverlap) {
+			failure = ErrBookingOverlap
+			return s.closeOffer(tx, offer, models.StandbyOfferStatusLapsed, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		offer.Status = models.StandbyOfferStatusAccepted
+		offer.RespondedAt = &now
+		offer.BookingID = &booking.ID
+		if err := tx.Save(offer).Error; err != nil {
+			return err
+		}
+
+		entry.Status = models.StandbyEntryStatusFilled
+		return tx.Save(&entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if failure != nil {
+		return nil, failure
+	}
+
+	return &booking, nil
+}
+
+// DeclineStandbyOffer turns down an offer and passes the dates to the next
+// owner on standby. The owner stays on standby for other dates.
+func (s *BookingService) DeclineStandbyOffer(offerID, userID uuid.UUID) (*models.StandbyOffer, error) {
+	var offer *models.StandbyOffer
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		offer, err = s.lockOffer(tx, offerID, userID)
+		if err != nil {
+			return err
+		}
+		return s.closeOffer(tx, offer, models.StandbyOfferStatusDeclined, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+// ExpireStandbyOffers expires unanswered offers past their deadline and passes
+// the dates on. Offers locked by another instance are skipped until the next
+// run.
+func (s *BookingService) ExpireStandbyOffers(ctx context.Context) error {
+	now := time.Now()
+
+	var offerIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.StandbyOffer{}).
+		Where("status = ? AND expires_at <= ?", models.StandbyOfferStatusPending, now).
+		Pluck("id", &offerIDs).Error; err != nil {
+		return err
+	}
+
+	for _, offerID := range offerIDs {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var offer models.StandbyOffer
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("id = ? AND status = ?", offerID, models.StandbyOfferStatusPending).
+				First(&offer).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return s.closeOffer(tx, &offer, models.StandbyOfferStatusExpired, now)
+		})
+		if err != nil {
+			return fmt.Errorf("standby offer %s: %w", offerID, err)
+		}
+	}
+
+	return nil
+}
+
+// lockOffer loads an unanswered offer belonging to a user under a row lock
+func (s *BookingService) lockOffer(tx *gorm.DB, offerID, userID uuid.UUID) (*models.StandbyOffer, error) {
+	var offer models.StandbyOffer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&offer, offerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStandbyOfferNotFound
+		}
+		return nil, err
+	}
+	if offer.UserID != userID {
+		return nil, ErrStandbyOfferNotFound
+	}
+	if offer.Status != models.StandbyOfferStatusPending {
+		return nil, ErrStandbyOfferClosed
+	}
+	return &offer, nil
+}
+
+// closeOffer records the outcome of an unanswered offer and cascades the dates
+// to the next owner on standby
+func (s *BookingService) closeOffer(tx *gorm.DB, offer *models.StandbyOffer, status models.StandbyOfferStatus, now time.Time) error {
+	offer.Status = status
+	offer.RespondedAt = &now
+	if err := tx.Save(offer).Error; err != nil {
+		return err
+	}
+
+	var source models.Booking
+	if err := lockBooking(tx, offer.SourceBookingID, &source); err != nil {
+		return err
+	}
+	return s.offerFreedDates(tx, &source, now)
+}
+
+// withdrawEntry takes an entry off standby, declining any open offer
+func (s *BookingService) withdrawEntry(tx *gorm.DB, entry *models.StandbyEntry, now time.Time) error {
+	entry.Status = models.StandbyEntryStatusWithdrawn
+	if err := tx.Save(entry).Error; err != nil {
+		return err
+	}
+
+	var offers []models.StandbyOffer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("entry_id = ? AND status = ?", entry.ID, models.StandbyOfferStatusPending).
+		Find(&offers).Error; err != nil {
+		return err
+	}
+	for i := range offers {
+		if err := s.closeOffer(tx, &offers[i], models.StandbyOfferStatusDeclined, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// standbyCandidate is a waiting entry scored for a set of freed dates
+type standbyCandidate struct {
+	entry     models.StandbyEntry
+	startDate time.Time
+	endDate   time.Time
+	score     float64
+}
+
+// offerFreedDates offers the dates of a cancelled booking to the highest
+// priority owner on standby who has not yet been offered them. Each owner is
+// offered the part of the dates their standby entry covers. Does nothing once
+// the dates have passed or no one is left to offer them to.
+func (s *BookingService) offerFreedDates(tx *gorm.DB, source *models.Booking, now time.Time) error {
+	if !source.EndDate.After(now) {
+		return nil
+	}
+
+	var entries []models.StandbyEntry
+	if err := tx.Where("yacht_id = ? AND status = ? AND user_id <> ? AND start_date < ? AND end_date > ?",
+		source.YachtID, models.StandbyEntryStatusWaiting, source.UserID, source.EndDate, source.StartDate).
+		Where("id NOT IN (?)", tx.Model(&models.StandbyOffer{}).Select("entry_id").Where("source_booking_id = ?", source.ID)).
+		Order("created_at ASC").
+		Find(&entries).Error; err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	standing, err := s.ledger.Standing(tx, source.YachtID, source.StartDate, source.EndDate, now)
+	if err != nil {
+		return err
+	}
+	cfg, err := s.ledger.Config(tx, source.YachtID)
+	if err != nil {
+		return err
+	}
+	valuer, err := s.ledger.Valuer(tx, source.YachtID)
+	if err != nil {
+		return err
+	}
+
+	candidates := make([]standbyCandidate, 0, len(entries))
+	for _, entry := range entries {
+		c := standbyCandidate{entry: entry, startDate: source.StartDate, endDate: source.EndDate}
+		if entry.StartDate.After(c.startDate) {
+			c.startDate = entry.StartDate
+		}
+		if entry.EndDate.Before(c.endDate) {
+			c.endDate = entry.EndDate
+		}
+		if !c.endDate.After(now) {
+			continue
+		}
+		if _, ok := standing.Owners[entry.UserID]; !ok {
+			// No longer holds a share over the freed dates
+			continue
+		}
+		c.score = standing.Score(entry.UserID, len(valuer.Slots(c.startDate, c.endDate)), now)
+		candidates = append(candidates, c)
+	}
+
+	// Earlier entries win ties
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	for _, c := range candidates {
+		var booked int64
+		if err := tx.Model(&models.Booking{}).
+			Where("yacht_id = ? AND start_date < ? AND end_date > ? AND status IN ? AND NOT awaiting_resolution",
+				source.YachtID, c.endDate, c.startDate,
+				[]models.BookingStatus{models.BookingStatusPending, models.BookingStatusConfirmed}).
+			Count(&booked).Error; err != nil {
+			return err
+		}
+		if booked > 0 {
+			continue
+		}
+
+		// Offers never run past the start of the freed dates
+		expiresAt := now.Add(time.Duration(cfg.StandbyOfferHours) * time.Hour)
+		cutoff := c.endDate
+		if c.startDate.After(now) {
+			cutoff = c.startDate
+		}
+		if cutoff.Before(expiresAt) {
+			expiresAt = cutoff
+		}
+
+		offer := models.StandbyOffer{
+			YachtID:         source.YachtID,
+			EntryID:         c.entry.ID,
+			UserID:          c.entry.UserID,
+			SourceBookingID: source.ID,
+			StartDate:       c.startDate,
+			EndDate:         c.endDate,
+			PriorityScore:   c.score,
+			Status:          models.StandbyOfferStatusPending,
+			ExpiresAt:       expiresAt,
+		}
+		if err := tx.Create(&offer).Error; err != nil {
+			return err
+		}
+
+		return notifyUser(tx, offer.UserID, models.NotificationTypeBooking,
+			"Standby dates available",
+			fmt.Sprintf("%s to %s has been freed by a cancellation. Accept before %s to book it.",
+				offer.StartDate.Format("Mon 2 Jan 15:04"), offer.EndDate.Format("Mon 2 Jan 15:04"),
+				offer.ExpiresAt.Format("Mon 2 Jan 15:04")),
+			offer.ID, "standby_offer")
+	}
+
+	return nil
+}
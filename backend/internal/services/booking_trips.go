@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTripGapTooShort = errors.New("booking is too close to another of your trips")
+	ErrTurnaroundClash = errors.New("booking leaves too little turnaround time before or after another owner's booking")
+)
+
+// An owner's bookings less than this apart, such as 10:00 to 19:00 on
+// consecutive days, are back-to-back: one trip broken only by a night
+const tripJoinGap = 24 * time.Hour
+
+// checkTripRules applies the syndicate's rules on how bookings sit next to
+// the bookings already holding the yacht:
+//
+//   - an owner's back-to-back bookings count as one trip, which may not be
+//     longer than MaxConsecutiveDays
+//   - an owner's separate trips must be at least MinTripGapDays apart
+//   - bookings of different owners must leave TurnaroundHours free for
+//     cleaning between them
+//
+// Overlapping bookings are left to the overlap checks.
+func checkTripRules(tx *gorm.DB, cfg fairshare.Config, booking *models.Booking) error {
+	// Nothing further away than this can affect the booking
+	reach := time.Duration(cfg.MaxConsecutiveDays+cfg.MinTripGapDays)*24*time.Hour +
+		time.Duration(cfg.TurnaroundHours)*time.Hour + tripJoinGap
+
+	query := tx.Model(&models.Booking{}).
+		Where("yacht_id = ? AND status IN ? AND awaiting_resolution = ?", booking.YachtID,
+			[]models.BookingStatus{models.BookingStatusPending, models.BookingStatusConfirmed}, false).
+		Where("start_date < ? AND end_date > ?", booking.EndDate.Add(reach), booking.StartDate.Add(-reach))
+	if booking.ID != uuid.Nil {
+		query = query.Where("id <> ?", booking.ID)
+	}
+
+	var nearby []models.Booking
+	if err := query.Order("start_date ASC").Find(&nearby).Error; err != nil {
+		return err
+	}
+	return tripRuleViolation(cfg, booking, nearby)
+}
+
+// tripRuleViolation checks the trip rules against the bookings near a
+// booking
+func tripRuleViolation(cfg fairshare.Config, booking *models.Booking, nearby []models.Booking) error {
+	var own []models.Booking
+	for _, other := range nearby {
+		if other.StartDate.Before(booking.EndDate) && other.EndDate.After(booking.StartDate) {
+			continue // Overlap
+		}
+
+		if other.UserID == booking.UserID {
+			own = append(own, other)
+			continue
+		}
+
+		turnaround := time.Duration(cfg.TurnaroundHours) * time.Hour
+		if gap := bookingGap(booking, &other); gap < turnaround {
+			return fmt.Errorf("%w: %d hours are needed for cleaning between bookings, the booking for %s leaves %s",
+				ErrTurnaroundClash, cfg.TurnaroundHours, formatBookingDates(&other), formatGap(gap))
+		}
+	}
+
+	minGap := time.Duration(cfg.MinTripGapDays) * 24 * time.Hour
+	for i := range own {
+		if gap := bookingGap(booking, &own[i]); gap >= tripJoinGap && gap < minGap {
+			return fmt.Errorf("%w: trips must be at least %d day(s) apart, your booking for %s is %s away",
+				ErrTripGapTooShort, cfg.MinTripGapDays, formatBookingDates(&own[i]), formatGap(gap))
+		}
+	}
+
+	// The booking's own length is checked by checkBookingLimits; extend the
+	// trip through any of the owner's bookings back-to-back with it
+	start, end := tripSpan(booking, own)
+	if days := len(fairshare.NewValuer(cfg, nil).Slots(start, end)); days > cfg.MaxConsecutiveDays {
+		return fmt.Errorf("%w: together with your adjoining bookings the trip is %d days, the limit is %d",
+			ErrBookingTooLong, days, cfg.MaxConsecutiveDays)
+	}
+
+	return nil
+}
+
+// isTripRuleError reports whether err is a violation of the trip rules
+func isTripRuleError(err error) bool {
+	return errors.Is(err, ErrBookingTooLong) || errors.Is(err, ErrTripGapTooShort) || errors.Is(err, ErrTurnaroundClash)
+}
+
+// bookingGap returns the time between two bookings that do not overlap
+func bookingGap(a, b *models.Booking) time.Duration {
+	if b.StartDate.Before(a.StartDate) {
+		return a.StartDate.Sub(b.EndDate)
+	}
+	return b.StartDate.Sub(a.EndDate)
+}
+
+// tripSpan returns the start and end of the trip formed by booking and the
+// owner's bookings back-to-back with it, directly or through each other
+func tripSpan(booking *models.Booking, own []models.Booking) (time.Time, time.Time) {
+	sort.Slice(own, func(i, j int) bool { return own[i].StartDate.Before(own[j].StartDate) })
+
+	start, end := booking.StartDate, booking.EndDate
+	for i := len(own) - 1; i >= 0; i-- {
+		if own[i].StartDate.Before(start) && start.Sub(own[i].EndDate) < tripJoinGap {
+			start = own[i].StartDate
+		}
+	}
+	for i := range own {
+		if own[i].EndDate.After(end) && own[i].StartDate.Sub(end) < tripJoinGap {
+			end = own[i].EndDate
+		}
+	}
+	return start, end
+}
+
+// formatGap renders the time between two bookings for error messages
+func formatGap(gap time.Duration) string {
+	if gap < 24*time.Hour {
+		return fmt.Sprintf("%d hour(s)", int(gap.Hours()))
+	}
+	return fmt.Sprintf("%d day(s)", int(gap.Hours()/24))
+}
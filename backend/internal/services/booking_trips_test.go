@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// dayTrip returns a 10:00 to 19:00 booking on a day of March 2026
+func dayTrip(userID uuid.UUID, day int) models.Booking {
+	return models.Booking{
+		ID:        uuid.New(),
+		UserID:    userID,
+		StartDate: time.Date(2026, 3, day, 10, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 3, day, 19, 0, 0, 0, time.UTC),
+	}
+}
+
+// TestTripRuleViolation tests the trip rules on day bookings, where an
+// owner's bookings on consecutive days are one trip
+func TestTripRuleViolation(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		cfg     func(*fairshare.Config)
+		nearby  []models.Booking
+		booking models.Booking
+		wantErr error
+	}{
+		{
+			name:    "consecutive days are one trip",
+			nearby:  []models.Booking{dayTrip(owner, 10)},
+			booking: dayTrip(owner, 11),
+		},
+		{
+			name:    "consecutive days before an existing booking",
+			nearby:  []models.Booking{dayTrip(owner, 11)},
+			booking: dayTrip(owner, 10),
+		},
+		{
+			name:    "consecutive days longer than the limit",
+			cfg:     func(c *fairshare.Config) { c.MaxConsecutiveDays = 2 },
+			nearby:  []models.Booking{dayTrip(owner, 10), dayTrip(owner, 11)},
+			booking: dayTrip(owner, 12),
+			wantErr: ErrBookingTooLong,
+		},
+		{
+			name:    "separate trips a day apart",
+			nearby:  []models.Booking{dayTrip(owner, 10)},
+			booking: dayTrip(owner, 12),
+		},
+		{
+			name:    "separate trips closer than the gap",
+			cfg:     func(c *fairshare.Config) { c.MinTripGapDays = 3 },
+			nearby:  []models.Booking{dayTrip(owner, 10)},
+			booking: dayTrip(owner, 12),
+			wantErr: ErrTripGapTooShort,
+		},
+		{
+			name:    "another owner the next day",
+			nearby:  []models.Booking{dayTrip(other, 10)},
+			booking: dayTrip(owner, 11),
+		},
+		{
+			name:    "another owner without turnaround",
+			cfg:     func(c *fairshare.Config) { c.TurnaroundHours = 16 },
+			nearby:  []models.Booking{dayTrip(other, 10)},
+			booking: dayTrip(owner, 11),
+			wantErr: ErrTurnaroundClash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := fairshare.DefaultConfig()
+			cfg.MaxConsecutiveDays = 7
+			cfg.MinTripGapDays = 1
+			cfg.TurnaroundHours = 4
+			if tt.cfg != nil {
+				tt.cfg(&cfg)
+			}
+
+			err := tripRuleViolation(cfg, &tt.booking, tt.nearby)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOutsideBookingWindow  = errors.New("dates are not open for booking")
+	ErrBookingWindowNotFound = errors.New("booking window not found")
+	ErrInvalidBookingWindow  = errors.New("invalid booking window")
+)
+
+// Longest range the window availability endpoint will report on
+const maxAvailabilityDays = 366
+
+// BookingWindowInput holds the fields of a booking window rule
+type BookingWindowInput struct {
+	Name           string
+	Dates          models.BookingWindowDates
+	Eligibility    models.BookingWindowEligibility
+	OpensDaysAhead int
+	Active         bool
+}
+
+func (in BookingWindowInput) validate() error {
+	if in.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidBookingWindow)
+	}
+	if in.OpensDaysAhead < 0 {
+		return fmt.Errorf("%w: opens_days_ahead cannot be negative", ErrInvalidBookingWindow)
+	}
+	switch in.Dates {
+	case models.BookingWindowAllDates, models.BookingWindowPremiumDates:
+	default:
+		return fmt.Errorf("%w: dates must be all or premium", ErrInvalidBookingWindow)
+	}
+	switch in.Eligibility {
+	case models.BookingWindowAllOwners, models.BookingWindowBelowFairShare:
+	default:
+		return fmt.Errorf("%w: eligibility must be all_owners or below_fair_share", ErrInvalidBookingWindow)
+	}
+	return nil
+}
+
+// BookingWindows returns a yacht's booking window rules
+func (s *BookingService) BookingWindows(yachtID uuid.UUID) ([]models.BookingWindow, error) {
+	windows := []models.BookingWindow{}
+	err := s.db.Where("yacht_id = ?", yachtID).Order("opens_days_ahead DESC").Find(&windows).Error
+	return windows, err
+}
+
+// CreateBookingWindow adds a booking window rule to a yacht
+func (s *BookingService) CreateBookingWindow(yachtID uuid.UUID, input BookingWindowInput) (*models.BookingWindow, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	var yacht models.Yacht
+	if err := s.db.Select("id").First(&yacht, yachtID).Error; err != nil {
+		return nil, translateNotFound(err, ErrYachtNotFound)
+	}
+
+	window := models.BookingWindow{
+		YachtID:        yachtID,
+		Name:           input.Name,
+		Dates:          input.Dates,
+		Eligibility:    input.Eligibility,
+		OpensDaysAhead: input.OpensDaysAhead,
+		Active:         input.Active,
+	}
+	if err := s.db.Create(&window).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// UpdateBookingWindow replaces a booking window rule
+func (s *BookingService) UpdateBookingWindow(windowID uuid.UUID, input BookingWindowInput) (*models.BookingWindow, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	var window models.BookingWindow
+	if err := s.db.First(&window, windowID).Error; err != nil {
+		return nil, translateNotFound(err, ErrBookingWindowNotFound)
+	}
+
+	window.Name = input.Name
+	window.Dates = input.Dates
+	window.Eligibility = input.Eligibility
+	window.OpensDaysAhead = input.OpensDaysAhead
+	window.Active = input.Active
+	if err := s.db.Save(&window).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// DeleteBookingWindow removes a booking window rule
+func (s *BookingService) DeleteBookingWindow(windowID uuid.UUID) error {
+	result := s.db.Delete(&models.BookingWindow{}, windowID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBookingWindowNotFound
+	}
+	return nil
+}
+
+// DayAvailability reports whether one date is open to an owner
+type DayAvailability struct {
+	Date     time.Time          `json:"date"`
+	SlotType fairshare.SlotType `json:"slot_type"`
+	Open     bool               `json:"open"`
+	OpensAt  *time.Time         `json:"opens_at,omitempty"` // Unset when open, or when no window will open it to the owner
+	Window   string             `json:"window,omitempty"`   // The window that opens (or opened) the date
+}
+
+// WindowOpening is the next time a window opens dates to an owner
+type WindowOpening struct {
+	OpensAt time.Time `json:"opens_at"`
+	Window  string    `json:"window"`
+	Dates   []string  `json:"dates"` // YYYY-MM-DD
+}
+
+// WindowAvailability is the booking window view of a date range for an owner
+type WindowAvailability struct {
+	YachtID        uuid.UUID         `json:"yacht_id"`
+	UserID         uuid.UUID         `json:"user_id"`
+	BelowFairShare bool              `json:"below_fair_share"`
+	Days           []DayAvailability `json:"days"`
+	NextOpening    *WindowOpening    `json:"next_opening,omitempty"`
+}
+
+// WindowAvailability reports which dates in [from, to) are open to an owner
+// under the yacht's booking windows and when the next closed dates open
+func (s *BookingService) WindowAvailability(yachtID, userID uuid.UUID, from, to time.Time) (*WindowAvailability, error) {
+	if !to.After(from) {
+		return nil, ErrBookingInvalidDates
+	}
+	if to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+		to = from.AddDate(0, 0, maxAvailabilityDays)
+	}
+
+	now := time.Now()
+	availability := WindowAvailability{YachtID: yachtID, UserID: userID, Days: []DayAvailability{}}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireSyndicateShare(tx, yachtID, userID, from, to); err != nil {
+			return err
+		}
+
+		rules, err := s.windowRules(tx, yachtID)
+		if err != nil {
+			return err
+		}
+		valuer, err := s.ledger.Valuer(tx, yachtID)
+		if err != nil {
+			return err
+		}
+		fairShare := newFairShareCheck(s.ledger, tx, yachtID, userID)
+		if availability.BelowFairShare, err = fairShare.belowFairShare(now.Year()); err != nil {
+			return err
+		}
+
+		for _, slot := range valuer.Slots(from, to) {
+			below, err := fairShare.belowFairShare(slot.Date.Year())
+			if err != nil {
+				return err
+			}
+
+			day := DayAvailability{Date: slot.Date, SlotType: slot.Type, Open: true}
+			opensAt, rule, restricted := rules.OpensAt(slot.Date, slot.Type, below)
+			if restricted {
+				day.Open = false
+				if rule != nil {
+					day.Window = rule.Name
+					day.Open = !now.Before(opensAt)
+				}
+				if !day.Open && rule != nil {
+					day.OpensAt = &opensAt
+
+					date := slot.Date.Format("2006-01-02")
+					next := availability.NextOpening
+					switch {
+					case next == nil || opensAt.Before(next.OpensAt):
+						availability.NextOpening = &WindowOpening{OpensAt: opensAt, Window: rule.Name, Dates: []string{date}}
+					case opensAt.Equal(next.OpensAt):
+						next.Dates = append(next.Dates, date)
+					}
+				}
+			}
+
+			availability.Days = append(availability.Days, day)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &availability, nil
+}
+
+// checkBookingWindows rejects a booking touching any date that is not yet open
+// to the owner under the yacht's booking windows
+func (s *BookingService) checkBookingWindows(tx *gorm.DB, booking *models.Booking, now time.Time) error {
+	rules, err := s.windowRules(tx, booking.YachtID)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	valuer, err := s.ledger.Valuer(tx, booking.YachtID)
+	if err != nil {
+		return err
+	}
+	fairShare := newFairShareCheck(s.ledger, tx, booking.YachtID, booking.UserID)
+
+	for _, slot := range valuer.Slots(booking.StartDate, booking.EndDate) {
+		below, err := fairShare.belowFairShare(slot.Date.Year())
+		if err != nil {
+			return err
+		}
+		if rules.IsOpen(slot.Date, slot.Type, below, now) {
+			continue
+		}
+
+		opensAt, rule, _ := rules.OpensAt(slot.Date, slot.Type, below)
+		if rule == nil {
+			return fmt.Errorf("%w: %s is only open to owners below their fair share",
+				ErrOutsideBookingWindow, slot.Date.Format("2 Jan 2006"))
+		}
+		return fmt.Errorf("%w: %s opens on %s (%s)",
+			ErrOutsideBookingWindow, slot.Date.Format("2 Jan 2006"), opensAt.Format("2 Jan 2006"), rule.Name)
+	}
+
+	return nil
+}
+
+// windowRules loads a yacht's active booking windows
+func (s *BookingService) windowRules(tx *gorm.DB, yachtID uuid.UUID) (fairshare.WindowRules, error) {
+	var windows []models.BookingWindow
+	if err := tx.Where("yacht_id = ? AND active", yachtID).Find(&windows).Error; err != nil {
+		return nil, err
+	}
+
+	rules := make(fairshare.WindowRules, len(windows))
+	for i, w := range windows {
+		rules[i] = fairshare.WindowRule{
+			Name:               w.Name,
+			OpensDaysAhead:     w.OpensDaysAhead,
+			PremiumOnly:        w.Dates == models.BookingWindowPremiumDates,
+			BelowFairShareOnly: w.Eligibility == models.BookingWindowBelowFairShare,
+		}
+	}
+	return rules, nil
+}
+
+// fairShareCheck lazily works out whether an owner is below their fair share,
+// once per year
+type fairShareCheck struct {
+	ledger  *fairshare.Ledger
+	tx      *gorm.DB
+	yachtID uuid.UUID
+	userID  uuid.UUID
+	years   map[int]bool
+}
+
+func newFairShareCheck(ledger *fairshare.Ledger, tx *gorm.DB, yachtID, userID uuid.UUID) *fairShareCheck {
+	return &fairShareCheck{ledger: ledger, tx: tx, yachtID: yachtID, userID: userID, years: make(map[int]bool)}
+}
+
+func (c *fairShareCheck) belowFairShare(year int) (bool, error) {
+	if below, ok := c.years[year]; ok {
+		return below, nil
+	}
+	usage, err := c.ledger.Usage(c.tx, c.yachtID, year)
+	if err != nil {
+		return false, err
+	}
+	below := usage[c.userID].AtOrBelowFairShare()
+	c.years[year] = below
+	return below, nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/ical"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrCalendarFeedNotFound is returned for an unknown or revoked feed
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+// How far back a feed includes past bookings and maintenance
+const calendarFeedHistoryDays = 90
+
+// CalendarFeedService issues secret-token iCalendar feeds of the bookings and
+// maintenance downtime of the yachts an owner holds shares in
+type CalendarFeedService struct {
+	db *gorm.DB
+}
+
+// NewCalendarFeedService creates a new calendar feed service
+func NewCalendarFeedService(db *gorm.DB) *CalendarFeedService {
+	return &CalendarFeedService{
+		db: db,
+	}
+}
+
+// IssuedCalendarFeed is a newly created feed with its token. The token is
+// not stored and cannot be retrieved again.
+type IssuedCalendarFeed struct {
+	models.CalendarFeed
+	Token string `json:"token"`
+}
+
+// Feeds returns a user's calendar feeds, newest first
+func (s *CalendarFeedService) Feeds(userID uuid.UUID) ([]models.CalendarFeed, error) {
+	var feeds []models.CalendarFeed
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&feeds).Error; err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+// Create issues a new feed token for a user
+func (s *CalendarFeedService) Create(userID uuid.UUID, name string) (*IssuedCalendarFeed, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	feed := models.CalendarFeed{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		TokenHash: hashFeedToken(token),
+	}
+	if err := s.db.Create(&feed).Error; err != nil {
+		return nil, err
+	}
+
+	return &IssuedCalendarFeed{CalendarFeed: feed, Token: token}, nil
+}
+
+// Revoke disables one of a user's feeds. Revoking a feed twice is not an
+// error.
+func (s *CalendarFeedService) Revoke(feedID, userID uuid.UUID) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := s.db.Where("id = ? AND user_id = ?", feedID, userID).First(&feed).Error; err != nil {
+		return nil, translateNotFound(err, ErrCalendarFeedNotFound)
+	}
+	if feed.RevokedAt != nil {
+		return &feed, nil
+	}
+
+	now := time.Now()
+	feed.RevokedAt = &now
+	if err := s.db.Save(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// Render returns the iCalendar feed for a token
+func (s *CalendarFeedService) Render(token string, now time.Time) ([]byte, error) {
+	var feed models.CalendarFeed
+	if err := s.db.Where("token_hash = ? AND revoked_at IS NULL", hashFeedToken(token)).First(&feed).Error; err != nil {
+		return nil, translateNotFound(err, ErrCalendarFeedNotFound)
+	}
+	if err := s.db.Model(&feed).UpdateColumn("last_used_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	var shares []models.SyndicateShare
+	if err := s.db.Preload("Yacht").Scopes(fairshare.HeldAt(now)).
+		Where("user_id = ?", feed.UserID).Find(&shares).Error; err != nil {
+		return nil, err
+	}
+
+	cal := ical.Calendar{ProdID: "-//YachtLife//Bookings//EN", Name: "YachtLife"}
+	yachts := make(map[uuid.UUID]models.Yacht)
+	var yachtIDs []uuid.UUID
+	for _, share := range shares {
+		if _, ok := yachts[share.YachtID]; !ok {
+			yachts[share.YachtID] = share.Yacht
+			yachtIDs = append(yachtIDs, share.YachtID)
+		}
+	}
+	if len(yachtIDs) == 1 {
+		cal.Name = yachts[yachtIDs[0]].Name
+	}
+	if len(yachtIDs) == 0 {
+		return cal.Bytes(), nil
+	}
+
+	since := now.AddDate(0, 0, -calendarFeedHistoryDays)
+
+	// Other owners' requests and standby places are not shown
+	var bookings []models.Booking
+	if err := s.db.Preload("User").
+		Where("yacht_id IN ? AND end_date > ?", yachtIDs, since).
+		Where("status IN ? OR (user_id = ? AND status IN ?)",
+			[]models.BookingStatus{models.BookingStatusConfirmed, models.BookingStatusCompleted, models.BookingStatusCancelled},
+			feed.UserID,
+			[]models.BookingStatus{models.BookingStatusPending, models.BookingStatusStandby, models.BookingStatusRejected}).
+		Order("start_date ASC").
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range bookings {
+		cal.Events = append(cal.Events, bookingEvent(b, yachts[b.YachtID], feed.UserID))
+	}
+
+	var maintenance []models.MaintenanceRequest
+	if err := s.db.Where("yacht_id IN ? AND scheduled_date IS NOT NULL AND COALESCE(completed_date, scheduled_date) >= ?",
+		yachtIDs, since).
+		Order("scheduled_date ASC").
+		Find(&maintenance).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range maintenance {
+		cal.Events = append(cal.Events, maintenanceEvent(m, yachts[m.YachtID]))
+	}
+
+	return cal.Bytes(), nil
+}
+
+// bookingEvent renders a booking. The owner's own notes are included; other
+// owners' bookings show only who has the yacht.
+func bookingEvent(b models.Booking, yacht models.Yacht, viewerID uuid.UUID) ical.Event {
+	event := ical.Event{
+		UID:          fmt.Sprintf("booking-%s@yachtlife", b.ID),
+		Location:     yachtLocation(yacht),
+		Start:        b.StartDate,
+		End:          b.EndDate,
+		Status:       ical.StatusConfirmed,
+		Sequence:     feedSequence(b.CreatedAt, b.UpdatedAt),
+		Stamp:        b.UpdatedAt,
+		LastModified: b.UpdatedAt,
+	}
+
+	switch b.Status {
+	case models.BookingStatusPending, models.BookingStatusStandby:
+		event.Status = ical.StatusTentative
+	case models.BookingStatusCancelled, models.BookingStatusRejected:
+		event.Status = ical.StatusCancelled
+	}
+
+	if b.UserID != viewerID {
+		event.Summary = fmt.Sprintf("%s: %s", yacht.Name, strings.TrimSpace(b.User.FirstName+" "+b.User.LastName))
+		return event
+	}
+
+	switch b.Status {
+	case models.BookingStatusPending:
+		event.Summary = yacht.Name + ": Your booking request"
+	case models.BookingStatusStandby:
+		event.Summary = yacht.Name + ": Standby"
+	default:
+		event.Summary = yacht.Name + ": Your booking"
+	}
+	event.Description = b.Notes
+	return event
+}
+
+// maintenanceEvent renders scheduled maintenance as all-day downtime from
+// the scheduled date to the completion date
+func maintenanceEvent(m models.MaintenanceRequest, yacht models.Yacht) ical.Event {
+	start := *m.ScheduledDate
+	last := start
+	if m.CompletedDate != nil && m.CompletedDate.After(start) {
+		last = *m.CompletedDate
+	}
+
+	status := ical.StatusConfirmed
+	if m.Status == models.MaintenanceStatusCancelled {
+		status = ical.StatusCancelled
+	}
+
+	return ical.Event{
+		UID:          fmt.Sprintf("maintenance-%s@yachtlife", m.ID),
+		Summary:      fmt.Sprintf("%s: Maintenance – %s", yacht.Name, m.Title),
+		Description:  m.Description,
+		Location:     yachtLocation(yacht),
+		Start:        start,
+		End:          last.AddDate(0, 0, 1),
+		AllDay:       true,
+		Status:       status,
+		Sequence:     feedSequence(m.CreatedAt, m.UpdatedAt),
+		Stamp:        m.UpdatedAt,
+		LastModified: m.UpdatedAt,
+	}
+}
+
+// feedSequence derives an event's SEQUENCE from when its record last changed,
+// so it increases with every update
+func feedSequence(created, updated time.Time) int {
+	if !updated.After(created) {
+		return 0
+	}
+	return int(updated.Sub(created) / time.Second)
+}
+
+func yachtLocation(yacht models.Yacht) string {
+	if yacht.BerthLocation != "" {
+		return yacht.BerthLocation
+	}
+	return yacht.HomePort
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/holidays"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCustomHolidayNotFound = errors.New("custom holiday not found")
+	ErrInvalidCustomHoliday  = errors.New("invalid custom holiday")
+)
+
+// Longest custom holiday a manager may add
+const maxCustomHolidayDays = 31
+
+// CalendarService looks up holiday calendars and manages custom dates
+type CalendarService struct {
+	db *gorm.DB
+}
+
+// NewCalendarService creates a new calendar service
+func NewCalendarService(db *gorm.DB) *CalendarService {
+	return &CalendarService{db: db}
+}
+
+// CalendarInput selects a region's holidays for a year. When YachtID is set
+// the yacht's custom dates are included.
+type CalendarInput struct {
+	Region  string
+	State   string
+	Year    int
+	YachtID *uuid.UUID
+}
+
+// Calendar returns the holidays for a region, state and year
+func (s *CalendarService) Calendar(input CalendarInput) (*holidays.Region, []holidays.Holiday, error) {
+	region, err := holidays.Lookup(input.Region)
+	if err != nil {
+		return nil, nil, err
+	}
+	calendar, err := holidays.NewCalendar(region.Code, input.State)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if input.YachtID != nil {
+		custom, err := s.CustomHolidays(*input.YachtID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, h := range custom {
+			calendar.Add(holidays.Holiday{Name: h.Name, Kind: holidays.Kind(h.Kind), Start: h.StartDate, End: h.EndDate})
+		}
+	}
+
+	list, err := calendar.Holidays(input.Year)
+	if err != nil {
+		return nil, nil, err
+	}
+	return region, list, nil
+}
+
+// CustomHolidays returns the dates managers have added to a yacht's calendar
+func (s *CalendarService) CustomHolidays(yachtID uuid.UUID) ([]models.CustomHoliday, error) {
+	custom := []models.CustomHoliday{}
+	err := s.db.Where("yacht_id = ?", yachtID).Order("start_date ASC").Find(&custom).Error
+	return custom, err
+}
+
+// CustomHolidayInput holds the fields of a custom holiday
+type CustomHolidayInput struct {
+	Name      string
+	Kind      models.HolidayKind
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// AddCustomHoliday adds a date range, such as a regatta weekend, to a yacht's
+// holiday calendar. Bookings made afterwards are valued with it.
+func (s *CalendarService) AddCustomHoliday(yachtID, createdBy uuid.UUID, input CustomHolidayInput) (*models.CustomHoliday, error) {
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidCustomHoliday)
+	}
+	switch input.Kind {
+	case models.HolidayKindPublic, models.HolidayKindSchool:
+	default:
+		return nil, fmt.Errorf("%w: kind must be public or school", ErrInvalidCustomHoliday)
+	}
+	start := truncateDate(input.StartDate)
+	end := truncateDate(input.EndDate)
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: end_date is before start_date", ErrInvalidCustomHoliday)
+	}
+	if end.Sub(start) >= maxCustomHolidayDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: custom holidays cannot be longer than %d days", ErrInvalidCustomHoliday, maxCustomHolidayDays)
+	}
+
+	if err := requireYacht(s.db, yachtID); err != nil {
+		return nil, err
+	}
+
+	holiday := models.CustomHoliday{
+		YachtID:   yachtID,
+		Name:      strings.TrimSpace(input.Name),
+		Kind:      input.Kind,
+		StartDate: start,
+		EndDate:   end,
+		CreatedBy: createdBy,
+	}
+	if err := s.db.Create(&holiday).Error; err != nil {
+		return nil, err
+	}
+
+	return &holiday, nil
+}
+
+// DeleteCustomHoliday removes a custom holiday
+func (s *CalendarService) DeleteCustomHoliday(holidayID uuid.UUID) error {
+	result := s.db.Delete(&models.CustomHoliday{}, holidayID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCustomHolidayNotFound
+	}
+	return nil
+}
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
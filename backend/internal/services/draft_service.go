@@ -0,0 +1,615 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDraftNotFound       = errors.New("draft not found")
+	ErrDraftExists         = errors.New("a draft already exists for this yacht and season")
+	ErrDraftClosed         = errors.New("draft is not open")
+	ErrDraftInvalidSeason  = errors.New("season end must be after season start")
+	ErrNoDraftSlots        = errors.New("no premium slots are available in this season")
+	ErrNoDraftParticipants = errors.New("yacht has no shareholders to draft")
+	ErrNotDraftParticipant = errors.New("user is not taking part in this draft")
+	ErrNotYourPick         = errors.New("it is not your turn to pick")
+	ErrDraftSlotNotFound   = errors.New("slot is not part of this draft")
+	ErrDraftSlotTaken      = errors.New("slot is no longer available")
+	ErrDraftDisabled       = errors.New("drafts are disabled for this syndicate")
+)
+
+// Generated slots follow the usual 10am departure and 7pm return
+const (
+	draftSlotStartHour = 10
+	draftSlotEndHour   = 19
+)
+
+// DraftService runs snake drafts for premium season allocation
+type DraftService struct {
+	db       *gorm.DB
+	bookings *BookingService
+	ledger   *fairshare.Ledger
+}
+
+// NewDraftService creates a new draft service
+func NewDraftService(db *gorm.DB, bookings *BookingService, ledger *fairshare.Ledger) *DraftService {
+	return &DraftService{
+		db:       db,
+		bookings: bookings,
+		ledger:   ledger,
+	}
+}
+
+// DraftSlotInput describes a manager-defined draft slot
+type DraftSlotInput struct {
+	Label     string
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// OpenDraftInput holds the fields required to open a draft
+type OpenDraftInput struct {
+	YachtID       uuid.UUID
+	CreatedBy     uuid.UUID
+	Season        int
+	Name          string
+	SeasonStart   time.Time
+	SeasonEnd     time.Time
+	Rounds        int              // Zero means enough rounds to allocate every slot
+	PickTimeLimit time.Duration    // Zero means the syndicate's draft pick time
+	Slots         []DraftSlotInput // Empty means every weekend and public holiday in the season
+}
+
+// Open creates a draft for a yacht's season and starts the clock on the first
+// pick. The first-round order rotates from the yacht's previous draft.
+func (s *DraftService) Open(input OpenDraftInput) (*models.Draft, error) {
+	if !input.SeasonEnd.After(input.SeasonStart) {
+		return nil, ErrDraftInvalidSeason
+	}
+
+	now := time.Now()
+	draft := models.Draft{
+		YachtID:     input.YachtID,
+		Season:      input.Season,
+		Name:        input.Name,
+		SeasonStart: input.SeasonStart,
+		SeasonEnd:   input.SeasonEnd,
+		Status:      models.DraftStatusOpen,
+		CreatedBy:   input.CreatedBy,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		cfg, err := s.ledger.Config(tx, input.YachtID)
+		if err != nil {
+			return err
+		}
+		if !cfg.DraftEnabled {
+			return ErrDraftDisabled
+		}
+		if input.PickTimeLimit <= 0 {
+			input.PickTimeLimit = time.Duration(cfg.DraftPickHours) * time.Hour
+		}
+		draft.PickTimeLimitMinutes = int(input.PickTimeLimit.Minutes())
+
+		// Owners who joined partway through the season's year sit out until
+		// the next
+		seasonYear := time.Date(input.SeasonStart.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		var owners []uuid.UUID
+		if err := tx.Model(&models.SyndicateShare{}).
+			Scopes(fairshare.HeldBetween(input.SeasonStart, input.SeasonEnd)).
+			Select("user_id").
+			Where("yacht_id = ? AND (joined_date IS NULL OR joined_date <= ?)", input.YachtID, seasonYear).
+			Group("user_id").
+			Order("MIN(joined_date) ASC, user_id ASC").
+			Pluck("user_id", &owners).Error; err != nil {
+			return err
+		}
+		if len(owners) == 0 {
+			return ErrNoDraftParticipants
+		}
+
+		previous, err := s.previousOrder(tx, input.YachtID, input.Season)
+		if err != nil {
+			return err
+		}
+		order := fairshare.DraftOrder(previous, owners)
+
+		valuer, err := s.ledger.Valuer(tx, input.YachtID)
+		if err != nil {
+			return err
+		}
+
+		var candidates []models.DraftSlot
+		if len(input.Slots) > 0 {
+			for _, in := range input.Slots {
+				if !in.EndDate.After(in.StartDate) {
+					return ErrBookingInvalidDates
+				}
+				candidates = append(candidates, models.DraftSlot{
+					Label:     in.Label,
+					StartDate: in.StartDate,
+					EndDate:   in.EndDate,
+				})
+			}
+		} else {
+			candidates = premiumSlots(valuer, input.SeasonStart, input.SeasonEnd)
+		}
+
+		// Leave out anything that is already booked
+		var slots []models.DraftSlot
+		for _, slot := range candidates {
+			var booked int64
+			if err := tx.Model(&models.Booking{}).
+				Where("yacht_id = ? AND start_date < ? AND end_date > ? AND status IN ? AND NOT awaiting_resolution",
+					input.YachtID, slot.EndDate, slot.StartDate,
+					[]models.BookingStatus{models.BookingStatusPending, models.BookingStatusConfirmed}).
+				Count(&booked).Error; err != nil {
+				return err
+			}
+			if booked == 0 {
+				slot.Value = valuer.RangeValue(slot.StartDate, slot.EndDate)
+				slots = append(slots, slot)
+			}
+		}
+		if len(slots) == 0 {
+			return ErrNoDraftSlots
+		}
+
+		draft.Rounds = input.Rounds
+		if draft.Rounds <= 0 {
+			draft.Rounds = (len(slots) + len(order) - 1) / len(order)
+		}
+		deadline := now.Add(input.PickTimeLimit)
+		draft.PickDeadline = &deadline
+
+		if err := tx.Create(&draft).Error; err != nil {
+			if isPgError(err, pgUniqueViolation) {
+				return ErrDraftExists
+			}
+			return err
+		}
+
+		participants := make([]models.DraftParticipant, len(order))
+		for i, userID := range order {
+			participants[i] = models.DraftParticipant{DraftID: draft.ID, UserID: userID, Position: i + 1}
+		}
+		if err := tx.Create(&participants).Error; err != nil {
+			return err
+		}
+
+		for i := range slots {
+			slots[i].DraftID = draft.ID
+		}
+		return tx.Create(&slots).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(draft.ID)
+}
+
+// Get returns a draft with its participants, slots and picks, first applying
+// any auto-picks that have fallen due
+func (s *DraftService) Get(draftID uuid.UUID) (*models.Draft, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var draft models.Draft
+		if err := lockDraft(tx, draftID, &draft); err != nil {
+			return err
+		}
+		return s.advance(tx, &draft, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var draft models.Draft
+	if err := s.db.
+		Preload("Participants", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Participants.User").
+		Preload("Slots", func(db *gorm.DB) *gorm.DB { return db.Order("start_date ASC") }).
+		Preload("Picks", func(db *gorm.DB) *gorm.DB { return db.Order("pick_number ASC") }).
+		First(&draft, draftID).Error; err != nil {
+		return nil, err
+	}
+
+	return &draft, nil
+}
+
+// ListForYacht returns a yacht's drafts, most recent season first
+func (s *DraftService) ListForYacht(yachtID uuid.UUID) ([]models.Draft, error) {
+	var drafts []models.Draft
+	err := s.db.Where("yacht_id = ?", yachtID).Order("season DESC").Find(&drafts).Error
+	return drafts, err
+}
+
+// IsParticipant reports whether a user has a position in a draft
+func (s *DraftService) IsParticipant(draftID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.DraftParticipant{}).
+		Where("draft_id = ? AND user_id = ?", draftID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Pick submits the on-the-clock owner's pick. The slot becomes a confirmed
+// booking and its value is debited from the owner's credits.
+func (s *DraftService) Pick(draftID, userID, slotID uuid.UUID) (*models.DraftPick, error) {
+	var pick *models.DraftPick
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var draft models.Draft
+		if err := lockDraft(tx, draftID, &draft); err != nil {
+			return err
+		}
+		if err := s.advance(tx, &draft, now); err != nil {
+			return err
+		}
+		if draft.Status != models.DraftStatusOpen {
+			return ErrDraftClosed
+		}
+
+		participants, err := draftParticipants(tx, draftID)
+		if err != nil {
+			return err
+		}
+		_, onClock := onTheClock(&draft, participants)
+		if onClock != userID {
+			return ErrNotYourPick
+		}
+
+		var slot models.DraftSlot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND draft_id = ?", slotID, draftID).
+			First(&slot).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDraftSlotNotFound
+			}
+			return err
+		}
+		if slot.PickedBy != nil {
+			return ErrDraftSlotTaken
+		}
+
+		pick, err = s.makePick(tx, &draft, participants, &slot, userID, false, now)
+		if err != nil {
+			return err
+		}
+		return tx.Save(&draft).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pick, nil
+}
+
+// Wishlist returns an owner's ranked wishlist for a draft
+func (s *DraftService) Wishlist(draftID, userID uuid.UUID) ([]models.DraftWishlistItem, error) {
+	items := []models.DraftWishlistItem{}
+	err := s.db.Preload("Slot").
+		Where("draft_id = ? AND user_id = ?", draftID, userID).
+		Order("rank ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// SetWishlist replaces an owner's wishlist with slots in preference order
+func (s *DraftService) SetWishlist(draftID, userID uuid.UUID, slotIDs []uuid.UUID) ([]models.DraftWishlistItem, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var draft models.Draft
+		if err := tx.First(&draft, draftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDraftNotFound
+			}
+			return err
+		}
+		if draft.Status != models.DraftStatusOpen {
+			return ErrDraftClosed
+		}
+
+		var participant int64
+		if err := tx.Model(&models.DraftParticipant{}).
+			Where("draft_id = ? AND user_id = ?", draftID, userID).
+			Count(&participant).Error; err != nil {
+			return err
+		}
+		if participant == 0 {
+			return ErrNotDraftParticipant
+		}
+
+		if err := tx.Where("draft_id = ? AND user_id = ?", draftID, userID).
+			Delete(&models.DraftWishlistItem{}).Error; err != nil {
+			return err
+		}
+
+		var items []models.DraftWishlistItem
+		seen := make(map[uuid.UUID]bool)
+		for _, slotID := range slotIDs {
+			if seen[slotID] {
+				continue
+			}
+			seen[slotID] = true
+
+			var count int64
+			if err := tx.Model(&models.DraftSlot{}).
+				Where("id = ? AND draft_id = ?", slotID, draftID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrDraftSlotNotFound
+			}
+
+			items = append(items, models.DraftWishlistItem{
+				DraftID: draftID,
+				UserID:  userID,
+				SlotID:  slotID,
+				Rank:    len(items) + 1,
+			})
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Wishlist(draftID, userID)
+}
+
+// AdvanceDue auto-picks for every open draft whose pick clock has run out.
+// Drafts locked by another instance are skipped until the next run.
+func (s *DraftService) AdvanceDue(ctx context.Context) error {
+	now := time.Now()
+
+	var draftIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.Draft{}).
+		Where("status = ? AND pick_deadline <= ?", models.DraftStatusOpen, now).
+		Pluck("id", &draftIDs).Error; err != nil {
+		return err
+	}
+
+	for _, draftID := range draftIDs {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var draft models.Draft
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).First(&draft, draftID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return s.advance(tx, &draft, now)
+		})
+		if err != nil {
+			return fmt.Errorf("draft %s: %w", draftID, err)
+		}
+	}
+
+	return nil
+}
+
+// advance auto-picks for each owner whose time has run out, using the first
+// still-available slot on their wishlist. An owner with nothing available
+// forfeits the pick. Each turn's clock starts when the previous one ended.
+func (s *DraftService) advance(tx *gorm.DB, draft *models.Draft, now time.Time) error {
+	if draft.Status != models.DraftStatusOpen || draft.PickDeadline == nil || now.Before(*draft.PickDeadline) {
+		return nil
+	}
+
+	participants, err := draftParticipants(tx, draft.ID)
+	if err != nil {
+		return err
+	}
+
+	for draft.Status == models.DraftStatusOpen && draft.PickDeadline != nil && !now.Before(*draft.PickDeadline) {
+		turnEnded := *draft.PickDeadline
+		_, userID := onTheClock(draft, participants)
+
+		var wishlist []models.DraftWishlistItem
+		if err := tx.Preload("Slot").
+			Joins("JOIN draft_slots ON draft_slots.id = draft_wishlist_items.slot_id").
+			Where("draft_wishlist_items.draft_id = ? AND draft_wishlist_items.user_id = ? AND draft_slots.picked_by IS NULL",
+				draft.ID, userID).
+			Order("draft_wishlist_items.rank ASC").
+			Find(&wishlist).Error; err != nil {
+			return err
+		}
+
+		picked := false
+		for _, item := range wishlist {
+			slot := item.Slot
+			_, err := s.makePick(tx, draft, participants, &slot, userID, true, turnEnded)
+			if errors.Is(err, ErrDraftSlotTaken) || errors.Is(err, ErrNotSyndicateMember) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			picked = true
+			break
+		}
+
+		if !picked {
+			if _, err := s.recordPick(tx, draft, participants, nil, nil, userID, true, turnEnded); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Save(draft).Error
+}
+
+// makePick books a slot for an owner and records the pick. The booking is
+// created under a savepoint so a clash with another booking only fails this
+// pick.
+func (s *DraftService) makePick(tx *gorm.DB, draft *models.Draft, participants []models.DraftParticipant,
+	slot *models.DraftSlot, userID uuid.UUID, auto bool, pickedAt time.Time) (*models.DraftPick, error) {
+	booking := models.Booking{
+		YachtID:   draft.YachtID,
+		UserID:    userID,
+		StartDate: slot.StartDate,
+		EndDate:   slot.EndDate,
+		Notes:     fmt.Sprintf("%s draft pick: %s", draft.Name, slot.Label),
+	}
+
+	err := tx.Transaction(func(sp *gorm.DB) error {
+		if err := s.bookings.insertConfirmedBooking(sp, &booking); err != nil {
+			return err
+		}
+
+		slot.PickedBy = &userID
+		slot.BookingID = &booking.ID
+		return sp.Save(slot).Error
+	})
+	if errors.Is(err, ErrBookingOverlap) {
+		return nil, ErrDraftSlotTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.recordPick(tx, draft, participants, &slot.ID, &booking.ID, userID, auto, pickedAt)
+}
+
+// recordPick stores a pick and moves the draft on to the next turn
+func (s *DraftService) recordPick(tx *gorm.DB, draft *models.Draft, participants []models.DraftParticipant,
+	slotID, bookingID *uuid.UUID, userID uuid.UUID, auto bool, pickedAt time.Time) (*models.DraftPick, error) {
+	round, _ := onTheClock(draft, participants)
+
+	pick := models.DraftPick{
+		DraftID:    draft.ID,
+		PickNumber: draft.CurrentPick + 1,
+		Round:      round,
+		UserID:     userID,
+		SlotID:     slotID,
+		BookingID:  bookingID,
+		AutoPicked: auto,
+		PickedAt:   pickedAt,
+	}
+	if err := tx.Create(&pick).Error; err != nil {
+		return nil, err
+	}
+
+	draft.CurrentPick++
+
+	var remaining int64
+	if err := tx.Model(&models.DraftSlot{}).
+		Where("draft_id = ? AND picked_by IS NULL", draft.ID).
+		Count(&remaining).Error; err != nil {
+		return nil, err
+	}
+
+	if remaining == 0 || draft.CurrentPick >= draft.Rounds*len(participants) {
+		completedAt := pickedAt
+		draft.Status = models.DraftStatusCompleted
+		draft.CompletedAt = &completedAt
+		draft.PickDeadline = nil
+		return &pick, nil
+	}
+
+	deadline := pickedAt.Add(time.Duration(draft.PickTimeLimitMinutes) * time.Minute)
+	draft.PickDeadline = &deadline
+	return &pick, nil
+}
+
+// previousOrder returns the first-round order of the yacht's most recent
+// earlier draft
+func (s *DraftService) previousOrder(tx *gorm.DB, yachtID uuid.UUID, season int) ([]uuid.UUID, error) {
+	var previous models.Draft
+	err := tx.Where("yacht_id = ? AND season < ? AND status <> ?", yachtID, season, models.DraftStatusCancelled).
+		Order("season DESC").
+		First(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var order []uuid.UUID
+	err = tx.Model(&models.DraftParticipant{}).
+		Where("draft_id = ?", previous.ID).
+		Order("position ASC").
+		Pluck("user_id", &order).Error
+	return order, err
+}
+
+// premiumSlots generates a weekend slot for every Saturday in the season and
+// a day slot for every weekday public holiday
+func premiumSlots(valuer *fairshare.Valuer, seasonStart, seasonEnd time.Time) []models.DraftSlot {
+	var slots []models.DraftSlot
+
+	first := time.Date(seasonStart.Year(), seasonStart.Month(), seasonStart.Day(), 0, 0, 0, 0, seasonStart.Location())
+	for day := first; day.Before(seasonEnd); day = day.AddDate(0, 0, 1) {
+		start := day.Add(draftSlotStartHour * time.Hour)
+
+		switch {
+		case day.Weekday() == time.Saturday:
+			end := day.AddDate(0, 0, 1).Add(draftSlotEndHour * time.Hour)
+			if start.Before(seasonStart) || end.After(seasonEnd) {
+				continue
+			}
+			slots = append(slots, models.DraftSlot{
+				Label:     "Weekend of " + day.Format("2 Jan"),
+				StartDate: start,
+				EndDate:   end,
+			})
+		case day.Weekday() != time.Sunday && valuer.Classify(day) == fairshare.SlotPublicHoliday:
+			end := day.Add(draftSlotEndHour * time.Hour)
+			if start.Before(seasonStart) || end.After(seasonEnd) {
+				continue
+			}
+			slots = append(slots, models.DraftSlot{
+				Label:     "Public holiday " + day.Format("2 Jan"),
+				StartDate: start,
+				EndDate:   end,
+			})
+		}
+	}
+
+	return slots
+}
+
+// onTheClock returns the current round and the owner whose turn it is
+func onTheClock(draft *models.Draft, participants []models.DraftParticipant) (int, uuid.UUID) {
+	round, index := fairshare.SnakeTurn(draft.CurrentPick, len(participants))
+	return round, participants[index].UserID
+}
+
+func draftParticipants(tx *gorm.DB, draftID uuid.UUID) ([]models.DraftParticipant, error) {
+	var participants []models.DraftParticipant
+	err := tx.Where("draft_id = ?", draftID).Order("position ASC").Find(&participants).Error
+	return participants, err
+}
+
+// lockDraft loads a draft and holds a row lock for the rest of the transaction
+func lockDraft(tx *gorm.DB, draftID uuid.UUID, draft *models.Draft) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(draft, draftID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDraftNotFound
+	}
+	return err
+}
+
+// DraftOnTheClock returns the owner whose turn it is in an open draft loaded
+// with its participants in position order
+func DraftOnTheClock(draft *models.Draft) uuid.UUID {
+	_, userID := onTheClock(draft, draft.Participants)
+	return userID
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidDunningPolicy = errors.New("invalid dunning policy")
+
+// Limits of a dunning sequence
+const (
+	maxDunningSteps    = 10
+	maxDunningLeadDays = 60  // Earliest reminder before an invoice is due
+	maxDunningDays     = 365 // Latest reminder after it is due
+)
+
+// DunningService moves unpaid invoices to overdue and chases them with
+// each syndicate's sequence of reminders and late fees
+type DunningService struct {
+	db *gorm.DB
+}
+
+// NewDunningService creates a new dunning service
+func NewDunningService(db *gorm.DB) *DunningService {
+	return &DunningService{
+		db: db,
+	}
+}
+
+// DunningPolicyInput holds changes to a yacht's dunning policy. Nil fields
+// keep their current value.
+type DunningPolicyInput struct {
+	Enabled        *bool
+	Steps          *[]models.DunningStep
+	LateFeeAmount  *money.Money
+	LateFeePercent *float64
+}
+
+// Policy returns a yacht's dunning policy, or the default if none has been
+// saved
+func (s *DunningService) Policy(yachtID uuid.UUID) (*models.DunningPolicy, error) {
+	currency, err := yachtCurrency(s.db, yachtID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := dunningPolicy(s.db, yachtID)
+	if err != nil {
+		return nil, err
+	}
+	policy.LateFeeAmount = policy.LateFeeAmount.In(currency)
+	return &policy, nil
+}
+
+// SavePolicy applies changes to a yacht's dunning policy. Steps are stored
+// in order, and apply to reminders not yet sent.
+func (s *DunningService) SavePolicy(yachtID, updatedBy uuid.UUID, input DunningPolicyInput) (*models.DunningPolicy, error) {
+	var policy models.DunningPolicy
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var yacht models.Yacht
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "currency").First(&yacht, yachtID).Error; err != nil {
+			return translateNotFound(err, ErrYachtNotFound)
+		}
+
+		var err error
+		if policy, err = dunningPolicy(tx, yachtID); err != nil {
+			return err
+		}
+		steps, err := policy.Sequence()
+		if err != nil {
+			return err
+		}
+
+		if input.Enabled != nil {
+			policy.Enabled = *input.Enabled
+		}
+		if input.Steps != nil {
+			steps = append([]models.DunningStep{}, *input.Steps...)
+		}
+		if input.LateFeeAmount != nil {
+			policy.LateFeeAmount = *input.LateFeeAmount
+		}
+		if input.LateFeePercent != nil {
+			policy.LateFeePercent = *input.LateFeePercent
+		}
+		policy.LateFeeAmount = policy.LateFeeAmount.In(yacht.Currency)
+
+		if err := validateDunningPolicy(&policy, steps); err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(steps)
+		if err != nil {
+			return err
+		}
+		policy.Steps = datatypes.JSON(encoded)
+		policy.UpdatedBy = &updatedBy
+
+		if policy.ID == uuid.Nil {
+			return tx.Create(&policy).Error
+		}
+		return tx.Save(&policy).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// MarkOverdue moves issued invoices with a balance left after their due date
+// to overdue. They stay overdue until paid off.
+func (s *DunningService) MarkOverdue(ctx context.Context) error {
+	today := truncateDate(time.Now())
+	return s.db.WithContext(ctx).Model(&models.Invoice{}).
+		Where("status IN ? AND due_date < ? AND amount_due > 0",
+			[]models.InvoiceStatus{models.InvoiceStatusSent, models.InvoiceStatusPartiallyPaid}, today).
+		Update("status", models.InvoiceStatusOverdue).Error
+}
+
+// SendReminders sends each unpaid invoice the latest step of its yacht's
+// dunning sequence it has reached. Steps already sent, and those passed while
+// a later one was sent, are not repeated, so an invoice catching up after the
+// scheduler was stopped gets a single reminder, but is still charged the late
+// fee of every step it passed. Invoices locked by another instance are
+// skipped.
+func (s *DunningService) SendReminders(ctx context.Context) error {
+	now := time.Now()
+	today := truncateDate(now)
+
+	var invoiceIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.Invoice{}).
+		Where("status IN ? AND amount_due > 0 AND due_date <= ?",
+			[]models.InvoiceStatus{models.InvoiceStatusSent, models.InvoiceStatusPartiallyPaid, models.InvoiceStatusOverdue},
+			today.AddDate(0, 0, maxDunningLeadDays)).
+		Order("due_date ASC").
+		Pluck("id", &invoiceIDs).Error; err != nil {
+		return err
+	}
+
+	policies := make(map[uuid.UUID]models.DunningPolicy)
+	for _, invoiceID := range invoiceIDs {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var inv models.Invoice
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).First(&inv, invoiceID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			policy, ok := policies[inv.YachtID]
+			if !ok {
+				if policy, err = dunningPolicy(tx, inv.YachtID); err != nil {
+					return err
+				}
+				policies[inv.YachtID] = policy
+			}
+			return s.remind(tx, &inv, policy, today, now)
+		})
+		if err != nil {
+			return fmt.Errorf("invoice %s: %w", invoiceID, err)
+		}
+	}
+
+	return nil
+}
+
+// remind sends a locked invoice the latest step of the sequence it has
+// reached, if it has not been sent that step or a later one. The late fee of
+// every step reached since the last reminder is charged, each separately.
+func (s *DunningService) remind(tx *gorm.DB, inv *models.Invoice, policy models.DunningPolicy, today, now time.Time) error {
+	if !policy.Enabled || !inv.AmountDue.IsPositive() {
+		return nil
+	}
+	switch inv.Status {
+	case models.InvoiceStatusSent, models.InvoiceStatusPartiallyPaid, models.InvoiceStatusOverdue:
+	default:
+		return nil
+	}
+
+	steps, err := policy.Sequence()
+	if err != nil {
+		return err
+	}
+	reached := models.DunningStepsReached(steps, truncateDate(inv.IssuedDate), truncateDate(inv.DueDate), today)
+
+	var sent []int
+	if err := tx.Model(&models.InvoiceReminder{}).
+		Where("invoice_id = ?", inv.ID).
+		Pluck("days", &sent).Error; err != nil {
+		return err
+	}
+	for _, days := range sent {
+		for len(reached) > 0 && reached[0].Days <= days {
+			reached = reached[1:]
+		}
+	}
+	if len(reached) == 0 {
+		return nil
+	}
+
+	// Steps passed since the last reminder are recorded only if they charged
+	// a late fee; the owner is notified once, of the latest
+	fees := money.Zero(inv.Currency)
+	var feeInvoices []*models.Invoice
+	for i, step := range reached {
+		if i < len(reached)-1 && !step.LateFee {
+			continue
+		}
+
+		reminder := models.InvoiceReminder{
+			InvoiceID: inv.ID,
+			Days:      step.Days,
+			SentAt:    now,
+		}
+		if step.LateFee && step.Days > 0 {
+			line, feeInvoice, err := s.chargeLateFee(tx, inv, policy, step, today, now)
+			if err != nil {
+				return err
+			}
+			if line != nil {
+				reminder.LateFeeLineID = &line.ID
+				fees = fees.Add(line.Amount)
+			}
+			if feeInvoice != nil {
+				reminder.LateFeeInvoiceID = &feeInvoice.ID
+				feeInvoices = append(feeInvoices, feeInvoice)
+			}
+		}
+		if err := tx.Create(&reminder).Error; err != nil {
+			return err
+		}
+	}
+
+	var yacht models.Yacht
+	if err := tx.Select("id", "name").First(&yacht, inv.YachtID).Error; err != nil {
+		return err
+	}
+
+	due := truncateDate(inv.DueDate)
+	var title, message string
+	switch days := int(today.Sub(due).Hours() / 24); {
+	case days < 0:
+		title = "Invoice due soon"
+		message = fmt.Sprintf("%s for %s: %s is due in %s, on %s", inv.InvoiceNumber, yacht.Name,
+			inv.AmountDue.Display(), formatDays(-days), due.Format("2 Jan 2006"))
+	case days == 0:
+		title = "Invoice due today"
+		message = fmt.Sprintf("%s for %s: %s is due today", inv.InvoiceNumber, yacht.Name, inv.AmountDue.Display())
+	default:
+		title = "Invoice overdue"
+		message = fmt.Sprintf("%s for %s was due on %s and is %s overdue. %s remains to pay",
+			inv.InvoiceNumber, yacht.Name, due.Format("2 Jan 2006"), formatDays(days), inv.AmountDue.Display())
+		if fees.IsPositive() {
+			message += fmt.Sprintf(", including late fees of %s", fees.Display())
+		}
+		message += "."
+		for _, feeInvoice := range feeInvoices {
+			message += fmt.Sprintf(" A late fee of %s has been raised as %s.", feeInvoice.Amount.Display(), feeInvoice.InvoiceNumber)
+		}
+	}
+	return notifyUser(tx, inv.UserID, models.NotificationTypeInvoice, title, message, inv.ID, "invoice")
+}
+
+// chargeLateFee charges a step's late fee on a locked invoice, as a line on
+// it or as an invoice of its own (see lateFeeCharge). It returns neither if
+// the policy's fee comes to nothing. An invoice already in Xero that gains a
+// line is pushed again with the fee.
+func (s *DunningService) chargeLateFee(tx *gorm.DB, inv *models.Invoice, policy models.DunningPolicy, step models.DunningStep, today, now time.Time) (*models.InvoiceLine, *models.Invoice, error) {
+	fee := policy.LateFee(inv.AmountDue)
+	if !fee.IsPositive() {
+		return nil, nil, nil
+	}
+
+	line, feeInvoice := lateFeeCharge(inv, fee, step, today)
+	if feeInvoice != nil {
+		var err error
+		if feeInvoice.InvoiceNumber, err = nextInvoiceNumber(tx, today.Year()); err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Omit("Yacht", "User").Create(feeInvoice).Error; err != nil {
+			return nil, nil, err
+		}
+		return nil, feeInvoice, nil
+	}
+
+	if err := tx.Create(line).Error; err != nil {
+		return nil, nil, err
+	}
+	inv.Amount = inv.Amount.Add(fee)
+	changes := map[string]interface{}{"amount": inv.Amount}
+	if inv.XeroInvoiceID != "" {
+		changes["xero_outdated"] = true
+	}
+	if err := tx.Model(inv).Updates(changes).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := settleInvoice(tx, inv, now); err != nil {
+		return nil, nil, err
+	}
+	return line, nil, nil
+}
+
+// lateFeeCharge builds the charge for a step's late fee: a line on the
+// invoice, or an invoice of its own, due straight away, once the invoice is
+// in Xero with a payment or credit against it, since Xero refuses to change
+// the lines of an invoice with payments allocated
+func lateFeeCharge(inv *models.Invoice, fee money.Money, step models.DunningStep, today time.Time) (*models.InvoiceLine, *models.Invoice) {
+	description := fmt.Sprintf("Late fee (%s overdue)", formatDays(step.Days))
+	if inv.XeroInvoiceID == "" || (!inv.AmountPaid.IsPositive() && !inv.AmountCredited.IsPositive()) {
+		return &models.InvoiceLine{
+			InvoiceID:   inv.ID,
+			Description: description,
+			Currency:    inv.Currency,
+			Amount:      fee,
+		}, nil
+	}
+
+	return nil, &models.Invoice{
+		YachtID:     inv.YachtID,
+		UserID:      inv.UserID,
+		Description: fmt.Sprintf("%s on %s", description, inv.InvoiceNumber),
+		Currency:    inv.Currency,
+		Amount:      fee,
+		AmountDue:   fee,
+		Status:      models.InvoiceStatusSent,
+		IssuedDate:  today,
+		DueDate:     today,
+	}
+}
+
+// dunningPolicy returns a yacht's saved dunning policy or the default
+func dunningPolicy(tx *gorm.DB, yachtID uuid.UUID) (models.DunningPolicy, error) {
+	var policy models.DunningPolicy
+	err := tx.Where("yacht_id = ?", yachtID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultDunningPolicy(yachtID), nil
+	}
+	return policy, err
+}
+
+// validateDunningPolicy checks a policy and sorts its steps into order
+func validateDunningPolicy(policy *models.DunningPolicy, steps []models.DunningStep) error {
+	if len(steps) > maxDunningSteps {
+		return fmt.Errorf("%w: at most %d steps are allowed", ErrInvalidDunningPolicy, maxDunningSteps)
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].Days < steps[j].Days })
+
+	lateFees := false
+	for i, step := range steps {
+		if step.Days < -maxDunningLeadDays || step.Days > maxDunningDays {
+			return fmt.Errorf("%w: step days must be between %d and %d", ErrInvalidDunningPolicy, -maxDunningLeadDays, maxDunningDays)
+		}
+		if i > 0 && steps[i-1].Days == step.Days {
+			return fmt.Errorf("%w: more than one step at %d days", ErrInvalidDunningPolicy, step.Days)
+		}
+		if step.LateFee && step.Days <= 0 {
+			return fmt.Errorf("%w: late fees can only be charged after the due date", ErrInvalidDunningPolicy)
+		}
+		lateFees = lateFees || step.LateFee
+	}
+
+	if policy.LateFeeAmount.IsNegative() {
+		return fmt.Errorf("%w: late_fee_amount must not be negative", ErrInvalidDunningPolicy)
+	}
+	if policy.LateFeePercent < 0 || policy.LateFeePercent > 100 {
+		return fmt.Errorf("%w: late_fee_percent must be between 0 and 100", ErrInvalidDunningPolicy)
+	}
+	if lateFees && policy.LateFeeAmount.IsZero() && policy.LateFeePercent == 0 {
+		return fmt.Errorf("%w: steps charging a late fee need a late_fee_amount or late_fee_percent", ErrInvalidDunningPolicy)
+	}
+	return nil
+}
+
+func formatDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
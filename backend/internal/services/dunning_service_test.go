@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLateFeeCharge tests charging a late fee as a line on the invoice, or
+// as an invoice of its own once Xero will not accept changes to the invoice
+func TestLateFeeCharge(t *testing.T) {
+	today := time.Date(2026, time.May, 14, 0, 0, 0, 0, time.UTC)
+	step := models.DunningStep{Days: 14, LateFee: true}
+	fee := money.New(2500, money.AUD)
+
+	invoice := func(xeroID string, paid, credited int64) *models.Invoice {
+		return &models.Invoice{
+			ID:             uuid.New(),
+			XeroInvoiceID:  xeroID,
+			YachtID:        uuid.New(),
+			UserID:         uuid.New(),
+			InvoiceNumber:  "INV-2026-0042",
+			Currency:       money.AUD,
+			Amount:         money.New(100000, money.AUD),
+			AmountPaid:     money.New(paid, money.AUD),
+			AmountCredited: money.New(credited, money.AUD),
+			Status:         models.InvoiceStatusOverdue,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		inv         *models.Invoice
+		wantInvoice bool
+	}{
+		{"not in xero", invoice("", 40000, 0), false},
+		{"in xero without payments", invoice("xero-1", 0, 0), false},
+		{"in xero partly paid", invoice("xero-1", 40000, 0), true},
+		{"in xero partly credited", invoice("xero-1", 0, 10000), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, feeInvoice := lateFeeCharge(tt.inv, fee, step, today)
+			if !tt.wantInvoice {
+				require.NotNil(t, line)
+				assert.Nil(t, feeInvoice)
+				assert.Equal(t, tt.inv.ID, line.InvoiceID)
+				assert.Equal(t, fee, line.Amount)
+				assert.Equal(t, "Late fee (14 days overdue)", line.Description)
+				return
+			}
+
+			require.NotNil(t, feeInvoice)
+			assert.Nil(t, line)
+			assert.Equal(t, tt.inv.YachtID, feeInvoice.YachtID)
+			assert.Equal(t, tt.inv.UserID, feeInvoice.UserID)
+			assert.Equal(t, fee, feeInvoice.Amount)
+			assert.Equal(t, fee, feeInvoice.AmountDue)
+			assert.Equal(t, models.InvoiceStatusSent, feeInvoice.Status)
+			assert.Equal(t, today, feeInvoice.IssuedDate)
+			assert.Equal(t, today, feeInvoice.DueDate)
+			assert.Empty(t, feeInvoice.XeroInvoiceID)
+			assert.Equal(t, "Late fee (14 days overdue) on INV-2026-0042", feeInvoice.Description)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrInvalidExchangeRate  = errors.New("invalid exchange rate")
+)
+
+// ExchangeRateService manages the exchange rates reports convert between
+// currencies with. Rates are entered by managers, never fetched, so totals
+// only change when they do.
+type ExchangeRateService struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateService creates a new exchange rate service
+func NewExchangeRateService(db *gorm.DB) *ExchangeRateService {
+	return &ExchangeRateService{
+		db: db,
+	}
+}
+
+// List returns every exchange rate, ordered by currency pair
+func (s *ExchangeRateService) List() ([]models.ExchangeRate, error) {
+	rates := []models.ExchangeRate{}
+	if err := s.db.Order("from_currency, to_currency").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Set records how many units of to one unit of from buys, replacing any
+// earlier rate for the pair
+func (s *ExchangeRateService) Set(from, to string, rate float64, updatedBy uuid.UUID) (*models.ExchangeRate, error) {
+	fromCurrency, toCurrency, err := parseCurrencyPair(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if !(rate > 0) || math.IsInf(rate, 1) {
+		return nil, fmt.Errorf("%w: rate must be positive", ErrInvalidExchangeRate)
+	}
+
+	record := models.ExchangeRate{
+		FromCurrency: fromCurrency,
+		ToCurrency:   toCurrency,
+		Rate:         rate,
+		UpdatedBy:    updatedBy,
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_currency"}, {Name: "to_currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_by", "updated_at"}),
+	}).Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Where("from_currency = ? AND to_currency = ?", fromCurrency, toCurrency).
+		First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Delete removes the rate for a pair
+func (s *ExchangeRateService) Delete(from, to string) error {
+	fromCurrency, toCurrency, err := parseCurrencyPair(from, to)
+	if err != nil {
+		return err
+	}
+	result := s.db.Where("from_currency = ? AND to_currency = ?", fromCurrency, toCurrency).
+		Delete(&models.ExchangeRate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrExchangeRateNotFound
+	}
+	return nil
+}
+
+// Rates loads every exchange rate for converting
+func (s *ExchangeRateService) Rates() (*money.Rates, error) {
+	records, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	rates := money.NewRates()
+	for _, r := range records {
+		if err := rates.Set(r.FromCurrency, r.ToCurrency, r.Rate); err != nil {
+			return nil, err
+		}
+	}
+	return rates, nil
+}
+
+func parseCurrencyPair(from, to string) (money.Currency, money.Currency, error) {
+	fromCurrency, err := money.ParseCurrency(from)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: unsupported currency %q", ErrInvalidExchangeRate, from)
+	}
+	toCurrency, err := money.ParseCurrency(to)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: unsupported currency %q", ErrInvalidExchangeRate, to)
+	}
+	if fromCurrency == toCurrency {
+		return "", "", fmt.Errorf("%w: currencies must differ", ErrInvalidExchangeRate)
+	}
+	return fromCurrency, toCurrency, nil
+}
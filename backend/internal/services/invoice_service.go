@@ -0,0 +1,358 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvoiceNotFound   = errors.New("invoice not found")
+	ErrInvalidCreditNote = errors.New("invalid credit note")
+
+	// ErrInvalidInvoiceQuery is returned for unusable invoice list parameters
+	ErrInvalidInvoiceQuery = errors.New("invalid invoice query")
+)
+
+// Page sizes for invoice lists
+const (
+	defaultInvoicePageSize = 25
+	maxInvoicePageSize     = 100
+)
+
+// invoiceSortColumns are the columns an invoice list can be sorted by
+var invoiceSortColumns = map[string]string{
+	"due_date":       "due_date",
+	"issued_date":    "issued_date",
+	"amount":         "amount",
+	"invoice_number": "invoice_number",
+	"created_at":     "created_at",
+}
+
+// InvoiceService handles invoice queries
+type InvoiceService struct {
+	db *gorm.DB
+}
+
+// NewInvoiceService creates a new invoice service
+func NewInvoiceService(db *gorm.DB) *InvoiceService {
+	return &InvoiceService{
+		db: db,
+	}
+}
+
+// InvoiceQuery filters, sorts and pages an invoice list. Nil and empty
+// fields do not filter.
+type InvoiceQuery struct {
+	YachtID   *uuid.UUID
+	UserID    *uuid.UUID
+	Statuses  []models.InvoiceStatus
+	DueFrom   *time.Time // Inclusive
+	DueTo     *time.Time // Inclusive
+	Currency  money.Currency
+	MinAmount *money.Money // In Currency, or each invoice's own
+	MaxAmount *money.Money
+
+	Sort       string // One of the invoiceSortColumns keys; due_date by default
+	Descending bool
+	Cursor     string // NextCursor of the previous page
+	Limit      int
+}
+
+// InvoicePage is one page of an invoice list
+type InvoicePage struct {
+	Invoices   []models.Invoice `json:"invoices"`
+	NextCursor string           `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// invoiceCursor marks the last invoice of a page. It records the sort it
+// was issued for so it cannot be replayed against a different ordering.
+type invoiceCursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d"`
+	Value      string    `json:"v"`
+	ID         uuid.UUID `json:"id"`
+}
+
+// List returns a page of invoices. Pages are keyed on the sort column and
+// the invoice ID, so rows added or removed between requests do not shift
+// later pages.
+func (s *InvoiceService) List(q InvoiceQuery) (*InvoicePage, error) {
+	if q.Sort == "" {
+		q.Sort = "due_date"
+	}
+	column, ok := invoiceSortColumns[q.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot sort by %s", ErrInvalidInvoiceQuery, q.Sort)
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultInvoicePageSize
+	}
+	if q.Limit < 1 || q.Limit > maxInvoicePageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInvoiceQuery, maxInvoicePageSize)
+	}
+	if q.MinAmount != nil && q.MaxAmount != nil && q.MinAmount.Cmp(*q.MaxAmount) > 0 {
+		return nil, fmt.Errorf("%w: min_amount is greater than max_amount", ErrInvalidInvoiceQuery)
+	}
+	if q.DueFrom != nil && q.DueTo != nil && q.DueFrom.After(*q.DueTo) {
+		return nil, fmt.Errorf("%w: due_from is after due_to", ErrInvalidInvoiceQuery)
+	}
+
+	query := s.db.Preload("Yacht").Preload("User")
+	if q.YachtID != nil {
+		query = query.Where("yacht_id = ?", *q.YachtID)
+	}
+	if q.UserID != nil {
+		query = query.Where("user_id = ?", *q.UserID)
+	}
+	if len(q.Statuses) > 0 {
+		query = query.Where("status IN ?", q.Statuses)
+	}
+	if q.Currency != "" {
+		query = query.Where("currency = ?", q.Currency)
+	}
+	if q.DueFrom != nil {
+		query = query.Where("due_date >= ?", *q.DueFrom)
+	}
+	if q.DueTo != nil {
+		query = query.Where("due_date <= ?", *q.DueTo)
+	}
+	if q.MinAmount != nil {
+		query = query.Where("amount >= ?", *q.MinAmount)
+	}
+	if q.MaxAmount != nil {
+		query = query.Where("amount <= ?", *q.MaxAmount)
+	}
+
+	direction, comparison := "ASC", ">"
+	if q.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if q.Cursor != "" {
+		value, id, err := invoiceCursorKey(q)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparison), value, id)
+	}
+
+	var invoices []models.Invoice
+	if err := query.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(q.Limit + 1).
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	page := InvoicePage{Invoices: invoices}
+	if len(invoices) > q.Limit {
+		page.Invoices = invoices[:q.Limit]
+		last := page.Invoices[q.Limit-1]
+		page.NextCursor = encodeInvoiceCursor(invoiceCursor{
+			Sort:       q.Sort,
+			Descending: q.Descending,
+			Value:      invoiceSortValue(q.Sort, last),
+			ID:         last.ID,
+		})
+	}
+	for i := range page.Invoices {
+		page.Invoices[i].GenerateXeroURL()
+	}
+
+	return &page, nil
+}
+
+// CreditNoteInput describes a credit against an invoice
+type CreditNoteInput struct {
+	Amount money.Money
+	Reason string
+}
+
+// IssueCreditNote credits an amount against the remaining balance of an
+// issued invoice and tells its owner. A credit that clears the balance
+// marks the invoice paid. Credit notes are not pushed to Xero.
+func (s *InvoiceService) IssueCreditNote(invoiceID, createdBy uuid.UUID, input CreditNoteInput, now time.Time) (*models.CreditNote, error) {
+	input.Reason = strings.TrimSpace(input.Reason)
+	if !input.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidCreditNote)
+	}
+	if input.Reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidCreditNote)
+	}
+
+	var note models.CreditNote
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var inv models.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, invoiceID).Error; err != nil {
+			return translateNotFound(err, ErrInvoiceNotFound)
+		}
+		switch inv.Status {
+		case models.InvoiceStatusDraft, models.InvoiceStatusPaid, models.InvoiceStatusCancelled:
+			return fmt.Errorf("%w: invoice is %s", ErrInvalidCreditNote, inv.Status)
+		}
+		if err := settleInvoice(tx, &inv, now); err != nil {
+			return err
+		}
+		// Credits are given in the invoice's currency
+		input.Amount = input.Amount.In(inv.Currency)
+		if input.Amount.Cmp(inv.AmountDue) > 0 {
+			return fmt.Errorf("%w: amount is more than the %s remaining", ErrInvalidCreditNote, inv.AmountDue.Display())
+		}
+
+		note = models.CreditNote{
+			InvoiceID: inv.ID,
+			UserID:    inv.UserID,
+			Currency:  inv.Currency,
+			Amount:    input.Amount,
+			Reason:    input.Reason,
+			CreatedBy: createdBy,
+		}
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		if err := settleInvoice(tx, &inv, now); err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("A credit of %s has been applied to %s: %s. %s remains to pay.",
+			note.Amount.Display(), inv.InvoiceNumber, note.Reason, inv.AmountDue.Display())
+		return notifyUser(tx, inv.UserID, models.NotificationTypeInvoice, "Invoice credited",
+			message, inv.ID, "invoice")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// CreditNotes lists the credit notes on an invoice, oldest first. A non-nil
+// ownerID restricts it to that user's invoices.
+func (s *InvoiceService) CreditNotes(invoiceID uuid.UUID, ownerID *uuid.UUID) ([]models.CreditNote, error) {
+	var inv models.Invoice
+	if err := s.db.Select("id", "user_id").First(&inv, invoiceID).Error; err != nil {
+		return nil, translateNotFound(err, ErrInvoiceNotFound)
+	}
+	if ownerID != nil && inv.UserID != *ownerID {
+		return nil, ErrInvoiceNotFound
+	}
+
+	notes := []models.CreditNote{}
+	if err := s.db.Where("invoice_id = ?", invoiceID).Order("created_at").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// settleInvoice recalculates a locked invoice's balance and status from its
+// payments, net of refunds, and credit notes, saving any change
+func settleInvoice(tx *gorm.DB, inv *models.Invoice, at time.Time) error {
+	var paid, credited money.Money
+	if err := tx.Model(&models.Payment{}).
+		Where("invoice_id = ? AND status IN ?", inv.ID,
+			[]models.PaymentStatus{models.PaymentStatusCompleted, models.PaymentStatusRefunded}).
+		Select("COALESCE(SUM(amount - amount_refunded), 0)").Row().Scan(&paid); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.CreditNote{}).Where("invoice_id = ?", inv.ID).
+		Select("COALESCE(SUM(amount), 0)").Row().Scan(&credited); err != nil {
+		return err
+	}
+
+	before := *inv
+	inv.Settle(paid.In(inv.Currency), credited.In(inv.Currency), at)
+	if inv.AmountPaid == before.AmountPaid && inv.AmountCredited == before.AmountCredited &&
+		inv.AmountDue == before.AmountDue && inv.Status == before.Status {
+		return nil
+	}
+
+	return tx.Model(inv).Updates(map[string]interface{}{
+		"amount_paid":     inv.AmountPaid,
+		"amount_credited": inv.AmountCredited,
+		"amount_due":      inv.AmountDue,
+		"status":          inv.Status,
+		"paid_date":       inv.PaidDate,
+	}).Error
+}
+
+// invoiceSortValue renders an invoice's value of a sort column for a cursor
+func invoiceSortValue(sort string, inv models.Invoice) string {
+	switch sort {
+	case "due_date":
+		return inv.DueDate.Format("2006-01-02")
+	case "issued_date":
+		return inv.IssuedDate.Format("2006-01-02")
+	case "amount":
+		return inv.Amount.Decimal()
+	case "invoice_number":
+		return inv.InvoiceNumber
+	default:
+		return inv.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// parseInvoiceSortValue converts a cursor value back to the column's type
+func parseInvoiceSortValue(sort, value string) (any, error) {
+	var (
+		parsed any
+		err    error
+	)
+	switch sort {
+	case "due_date", "issued_date":
+		parsed, err = time.Parse("2006-01-02", value)
+	case "amount":
+		parsed, err = money.Parse(value, money.DefaultCurrency)
+	case "invoice_number":
+		parsed = value
+	default:
+		parsed, err = time.Parse(time.RFC3339Nano, value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInvoiceQuery)
+	}
+	return parsed, nil
+}
+
+// invoiceCursorKey returns the sort value and invoice ID a query's cursor
+// continues from, rejecting cursors issued for a different sort
+func invoiceCursorKey(q InvoiceQuery) (any, uuid.UUID, error) {
+	cursor, err := decodeInvoiceCursor(q.Cursor)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if cursor.Sort != q.Sort || cursor.Descending != q.Descending {
+		return nil, uuid.Nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidInvoiceQuery)
+	}
+	value, err := parseInvoiceSortValue(q.Sort, cursor.Value)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return value, cursor.ID, nil
+}
+
+func encodeInvoiceCursor(c invoiceCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeInvoiceCursor(s string) (invoiceCursor, error) {
+	var c invoiceCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(raw, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidInvoiceQuery)
+	}
+	return c, nil
+}
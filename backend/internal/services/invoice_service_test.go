@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInvoiceCursor tests that a cursor issued for the last invoice of a page
+// gives back that invoice's sort value and ID for every sort column
+func TestInvoiceCursor(t *testing.T) {
+	inv := models.Invoice{
+		ID:            uuid.New(),
+		InvoiceNumber: "INV-2026-0042",
+		Amount:        money.New(123450, money.DefaultCurrency),
+		IssuedDate:    time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:       time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+	inv.CreatedAt = time.Date(2026, 3, 1, 9, 30, 15, 123456789, time.UTC)
+
+	tests := []struct {
+		sort string
+		want any
+	}{
+		{sort: "due_date", want: inv.DueDate},
+		{sort: "issued_date", want: inv.IssuedDate},
+		{sort: "amount", want: inv.Amount},
+		{sort: "invoice_number", want: inv.InvoiceNumber},
+		{sort: "created_at", want: inv.CreatedAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			for _, descending := range []bool{false, true} {
+				q := InvoiceQuery{Sort: tt.sort, Descending: descending}
+				q.Cursor = encodeInvoiceCursor(invoiceCursor{
+					Sort:       tt.sort,
+					Descending: descending,
+					Value:      invoiceSortValue(tt.sort, inv),
+					ID:         inv.ID,
+				})
+
+				decoded, err := decodeInvoiceCursor(q.Cursor)
+				require.NoError(t, err)
+				assert.Equal(t, tt.sort, decoded.Sort)
+				assert.Equal(t, descending, decoded.Descending)
+
+				value, id, err := invoiceCursorKey(q)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, value)
+				assert.Equal(t, inv.ID, id)
+			}
+		})
+	}
+}
+
+// TestInvoiceCursorRejected tests that malformed cursors and cursors issued
+// for a different sort are refused
+func TestInvoiceCursorRejected(t *testing.T) {
+	dueDate := encodeInvoiceCursor(invoiceCursor{Sort: "due_date", Value: "2026-03-31", ID: uuid.New()})
+
+	tests := []struct {
+		name  string
+		query InvoiceQuery
+	}{
+		{name: "different column", query: InvoiceQuery{Sort: "amount", Cursor: dueDate}},
+		{name: "different direction", query: InvoiceQuery{Sort: "due_date", Descending: true, Cursor: dueDate}},
+		{name: "not base64", query: InvoiceQuery{Sort: "due_date", Cursor: "not a cursor!"}},
+		{name: "not json", query: InvoiceQuery{Sort: "due_date", Cursor: "bm90IGpzb24"}},
+		{
+			name: "malformed value",
+			query: InvoiceQuery{Sort: "due_date", Cursor: encodeInvoiceCursor(invoiceCursor{
+				Sort: "due_date", Value: "31/03/2026", ID: uuid.New(),
+			})},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := invoiceCursorKey(tt.query)
+			assert.ErrorIs(t, err, ErrInvalidInvoiceQuery)
+		})
+	}
+}
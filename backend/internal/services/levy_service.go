@@ -0,0 +1,314 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidLevy    = errors.New("invalid levy")
+	ErrNoShareholders = errors.New("no shares were held in the levy period")
+)
+
+// Prefix of generated invoice numbers
+const invoiceNumberPrefix = "YL-"
+
+// LevyService splits syndicate running costs between a yacht's
+// shareholders and raises an invoice for each share
+type LevyService struct {
+	db *gorm.DB
+}
+
+// NewLevyService creates a new levy service
+func NewLevyService(db *gorm.DB) *LevyService {
+	return &LevyService{
+		db: db,
+	}
+}
+
+// LevyInput holds the cost to split
+type LevyInput struct {
+	Category    models.LevyCategory
+	Description string
+	Amount      money.Money
+	SplitRule   models.InvoiceSplitRule // By share percentage if empty
+	PeriodStart time.Time
+	PeriodEnd   time.Time // Inclusive
+	IssuedDate  time.Time // Today if zero
+	DueDate     time.Time
+	Send        bool // Raise the invoices as sent rather than draft
+}
+
+// LevyPlan is a levy and the invoices it raises. In a preview nothing is
+// saved and the invoices have no IDs or numbers yet.
+type LevyPlan struct {
+	Levy     models.Levy      `json:"levy"`
+	Invoices []models.Invoice `json:"invoices"`
+	Preview  bool             `json:"preview"`
+}
+
+// Levies returns a yacht's levies with their invoices, newest first
+func (s *LevyService) Levies(yachtID uuid.UUID) ([]models.Levy, error) {
+	var levies []models.Levy
+	if err := s.db.Preload("Invoices", func(db *gorm.DB) *gorm.DB {
+		return db.Order("invoice_number ASC")
+	}).Where("yacht_id = ?", yachtID).
+		Order("issued_date DESC, created_at DESC").
+		Find(&levies).Error; err != nil {
+		return nil, err
+	}
+	return levies, nil
+}
+
+// Preview works out how a levy would be split without saving anything
+func (s *LevyService) Preview(yachtID, createdBy uuid.UUID, input LevyInput) (*LevyPlan, error) {
+	currency, err := yachtCurrency(s.db, yachtID)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planLevy(s.db, yachtID, currency, createdBy, input)
+	if err != nil {
+		return nil, err
+	}
+	plan.Preview = true
+	return plan, nil
+}
+
+// Create saves a levy and raises its invoices, numbering them in sequence
+// and notifying owners if the invoices are sent
+func (s *LevyService) Create(yachtID, createdBy uuid.UUID, input LevyInput) (*LevyPlan, error) {
+	var plan *LevyPlan
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		plan, err = s.raise(tx, yachtID, createdBy, input, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// raise saves a levy and its invoices inside a transaction. recurringID is
+// set for a period of a recurring schedule.
+func (s *LevyService) raise(tx *gorm.DB, yachtID, createdBy uuid.UUID, input LevyInput, recurringID *uuid.UUID) (*LevyPlan, error) {
+	// Serialise levies for the yacht so shares cannot change mid-split
+	var yacht models.Yacht
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "name", "currency").First(&yacht, yachtID).Error; err != nil {
+		return nil, translateNotFound(err, ErrYachtNotFound)
+	}
+
+	plan, err := planLevy(tx, yachtID, yacht.Currency, createdBy, input)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Levy.RecurringInvoiceID = recurringID
+	if err := tx.Omit("Invoices").Create(&plan.Levy).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range plan.Invoices {
+		inv := &plan.Invoices[i]
+		inv.LevyID = &plan.Levy.ID
+		if inv.InvoiceNumber, err = nextInvoiceNumber(tx, inv.IssuedDate.Year()); err != nil {
+			return nil, err
+		}
+		if err := tx.Omit("Yacht", "User").Create(inv).Error; err != nil {
+			return nil, err
+		}
+
+		if inv.Status == models.InvoiceStatusSent {
+			message := fmt.Sprintf("%s for %s: %s due %s", inv.InvoiceNumber, yacht.Name,
+				inv.Amount.Display(), inv.DueDate.Format("2 Jan 2006"))
+			if err := notifyUser(tx, inv.UserID, models.NotificationTypeInvoice,
+				"New invoice", message, inv.ID, "invoice"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// planLevy validates a levy and splits it between the shares held during
+// its period, by share percentage or equally per share. Each share's part is
+// pro-rated for the days it was held, then the parts are scaled so they add
+// up to exactly the levy amount: costs of unallocated ownership are shared by
+// the remaining owners. Amounts are in the yacht's currency.
+func planLevy(tx *gorm.DB, yachtID uuid.UUID, currency money.Currency, createdBy uuid.UUID, input LevyInput) (*LevyPlan, error) {
+	if err := validateLevy(&input); err != nil {
+		return nil, err
+	}
+	input.Amount = input.Amount.In(currency)
+
+	start := truncateDate(input.PeriodStart)
+	end := truncateDate(input.PeriodEnd).AddDate(0, 0, 1)
+
+	var shares []models.SyndicateShare
+	if err := tx.Preload("User").Scopes(fairshare.HeldBetween(start, end)).
+		Where("yacht_id = ?", yachtID).
+		Order("joined_date ASC, created_at ASC").
+		Find(&shares).Error; err != nil {
+		return nil, err
+	}
+
+	weights := make([]float64, len(shares))
+	for i, share := range shares {
+		weight := share.SharePercentage
+		if input.SplitRule == models.SplitEqually {
+			weight = 1
+		}
+		weights[i] = weight * fairshare.ShareFraction(share, start, end)
+	}
+	parts := input.Amount.Allocate(weights...)
+	if parts == nil {
+		return nil, ErrNoShareholders
+	}
+
+	plan := &LevyPlan{
+		Levy: models.Levy{
+			YachtID:     yachtID,
+			Category:    input.Category,
+			Description: input.Description,
+			Currency:    currency,
+			Amount:      input.Amount,
+			SplitRule:   input.SplitRule,
+			PeriodStart: start,
+			PeriodEnd:   truncateDate(input.PeriodEnd),
+			IssuedDate:  input.IssuedDate,
+			DueDate:     input.DueDate,
+			CreatedBy:   createdBy,
+		},
+		Invoices: []models.Invoice{},
+	}
+
+	status := models.InvoiceStatusDraft
+	if input.Send {
+		status = models.InvoiceStatusSent
+	}
+
+	for i, share := range shares {
+		if parts[i].IsZero() {
+			continue
+		}
+
+		description := fmt.Sprintf("%s levy %s – %s (%.2f%% share", levyCategoryName(input.Category),
+			start.Format("2 Jan 2006"), plan.Levy.PeriodEnd.Format("2 Jan 2006"), share.SharePercentage)
+		if fairshare.ShareFraction(share, start, end) < 1 {
+			description += ", pro-rated"
+		}
+		description += ")"
+		if input.Description != "" {
+			description = input.Description + "\n" + description
+		}
+
+		plan.Invoices = append(plan.Invoices, models.Invoice{
+			YachtID:     yachtID,
+			UserID:      share.UserID,
+			Description: description,
+			Currency:    currency,
+			Amount:      parts[i],
+			AmountDue:   parts[i],
+			IssuedDate:  input.IssuedDate,
+			DueDate:     input.DueDate,
+			Status:      status,
+			User:        share.User,
+		})
+	}
+
+	return plan, nil
+}
+
+func validateLevy(input *LevyInput) error {
+	switch input.Category {
+	case models.LevyCategoryBerthing, models.LevyCategoryInsurance, models.LevyCategoryServicing,
+		models.LevyCategoryMaintenance, models.LevyCategoryOther:
+	default:
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidLevy, input.Category)
+	}
+
+	switch input.SplitRule {
+	case "":
+		input.SplitRule = models.SplitBySharePercentage
+	case models.SplitBySharePercentage, models.SplitEqually:
+	default:
+		return fmt.Errorf("%w: unknown split_rule %q", ErrInvalidLevy, input.SplitRule)
+	}
+
+	if !input.Amount.IsPositive() {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidLevy)
+	}
+	if input.PeriodStart.IsZero() || input.PeriodEnd.IsZero() || truncateDate(input.PeriodEnd).Before(truncateDate(input.PeriodStart)) {
+		return fmt.Errorf("%w: period_end must not be before period_start", ErrInvalidLevy)
+	}
+
+	if input.IssuedDate.IsZero() {
+		input.IssuedDate = time.Now()
+	}
+	input.IssuedDate = truncateDate(input.IssuedDate)
+	if input.DueDate.IsZero() {
+		return fmt.Errorf("%w: due_date is required", ErrInvalidLevy)
+	}
+	input.DueDate = truncateDate(input.DueDate)
+	if input.DueDate.Before(input.IssuedDate) {
+		return fmt.Errorf("%w: due_date must not be before issued_date", ErrInvalidLevy)
+	}
+	return nil
+}
+
+func levyCategoryName(category models.LevyCategory) string {
+	name := string(category)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// nextInvoiceNumber takes the next invoice number for a year, e.g.
+// YL-2026-007. The year's counter starts after any numbers already used by
+// imported invoices.
+func nextInvoiceNumber(tx *gorm.DB, year int) (string, error) {
+	prefix := fmt.Sprintf("%s%d-", invoiceNumberPrefix, year)
+
+	var seq models.InvoiceSequence
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seq, "year = ?", year).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var numbers []string
+		if err := tx.Model(&models.Invoice{}).Where("invoice_number LIKE ?", prefix+"%").
+			Pluck("invoice_number", &numbers).Error; err != nil {
+			return "", err
+		}
+		last := 0
+		for _, number := range numbers {
+			if n, err := strconv.Atoi(strings.TrimPrefix(number, prefix)); err == nil && n > last {
+				last = n
+			}
+		}
+
+		// Another transaction may have started the year first
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.InvoiceSequence{Year: year, LastNumber: last}).Error; err != nil {
+			return "", err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seq, "year = ?", year).Error
+	}
+	if err != nil {
+		return "", err
+	}
+
+	seq.LastNumber++
+	if err := tx.Save(&seq).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%03d", prefix, seq.LastNumber), nil
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// notifyUser queues a push notification for a user about a related record
+func notifyUser(tx *gorm.DB, userID uuid.UUID, notificationType models.NotificationType,
+	title, message string, relatedID uuid.UUID, relatedType string) error {
+	notification := models.Notification{
+		UserID:      &userID,
+		Title:       title,
+		Message:     message,
+		Type:        notificationType,
+		Channels:    datatypes.JSON(`["push"]`),
+		Status:      models.NotificationStatusPending,
+		RelatedID:   &relatedID,
+		RelatedType: relatedType,
+	}
+	return tx.Create(&notification).Error
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/bitcoinbrisbane/yachtlife/internal/stripe"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvoiceNotPayable    = errors.New("invoice cannot be paid")
+	ErrInvalidPayment       = errors.New("invalid payment")
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPaymentNotRefundable = errors.New("payment cannot be refunded")
+)
+
+// minimumCharges are the smallest payments Stripe will take, in minor units
+var minimumCharges = map[money.Currency]int64{
+	money.AUD: 50,
+	money.NZD: 50,
+	money.USD: 50,
+	money.EUR: 50,
+	money.GBP: 30,
+}
+
+// PaymentService takes payments for invoices through Stripe
+type PaymentService struct {
+	db     *gorm.DB
+	stripe stripe.API
+}
+
+// NewPaymentService creates a new payment service
+func NewPaymentService(db *gorm.DB, stripeClient stripe.API) *PaymentService {
+	return &PaymentService{
+		db:     db,
+		stripe: stripeClient,
+	}
+}
+
+// PaymentCheckout is what the app needs to collect a payment with Stripe's
+// payment sheet
+type PaymentCheckout struct {
+	Payment         models.Payment `json:"payment"`
+	PaymentIntentID string         `json:"payment_intent_id"`
+	ClientSecret    string         `json:"client_secret"`
+	Amount          money.Money    `json:"amount"`
+	Currency        string         `json:"currency"`
+}
+
+// StartPayment creates a Stripe PaymentIntent for some or, if amount is nil,
+// all of the remaining balance of one of the user's invoices and records it
+// as a pending payment, which is completed when Stripe reports the outcome.
+//
+// Asking again before a payment goes through returns the same intent, so an
+// invoice never has two payable intents at once and cannot be paid twice.
+// If the outstanding amount has changed since, the old intent is cancelled
+// first.
+//
+// Stripe is called outside the invoice lock, so a slow response does not hold
+// up webhooks for the invoice. The pending payment is saved before its intent
+// is created and keys it, so a retry after a failure gets the same intent
+// back rather than leaving one behind.
+func (s *PaymentService) StartPayment(ctx context.Context, invoiceID, userID uuid.UUID, method models.PaymentMethod,
+	amount *money.Money, now time.Time) (*PaymentCheckout, error) {
+	switch method {
+	case "":
+		method = models.PaymentMethodCard
+	case models.PaymentMethodCard, models.PaymentMethodApplePay, models.PaymentMethodGooglePay:
+	default:
+		return nil, fmt.Errorf("%w: unknown payment_method %q", ErrInvalidPayment, method)
+	}
+
+	res, err := s.reservePayment(ctx, invoiceID, userID, method, amount, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []uuid.UUID
+	for _, open := range res.open {
+		pi, err := s.reuseOpen(ctx, open, res.payment.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if pi != nil {
+			// The reserved payment is not needed
+			return s.commitPayment(ctx, invoiceID, open.ID, pi, method, append(dropped, res.payment.ID))
+		}
+		dropped = append(dropped, open.ID)
+	}
+
+	pi, err := s.stripe.CreatePaymentIntent(ctx, res.params, "payment-"+res.payment.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	checkout, err := s.commitPayment(ctx, invoiceID, res.payment.ID, pi, method, dropped)
+	if errors.Is(err, ErrInvoiceNotPayable) {
+		// Another request replaced the payment, so nobody can be handed
+		// this intent
+		if _, cancelErr := s.stripe.CancelPaymentIntent(ctx, pi.ID); cancelErr != nil {
+			return nil, cancelErr
+		}
+	}
+	return checkout, err
+}
+
+// paymentReservation is a pending payment set aside for a new intent, along
+// with the invoice's other unpaid payments whose intents may be reused
+type paymentReservation struct {
+	payment models.Payment
+	open    []models.Payment
+	params  stripe.PaymentIntentParams
+}
+
+// reservePayment works out the charge under the invoice lock and saves a
+// pending payment for it without an intent. A payment reserved earlier for
+// the same amount is reused, so retries and concurrent requests share its
+// idempotency key; reservations for other amounts are cancelled.
+func (s *PaymentService) reservePayment(ctx context.Context, invoiceID, userID uuid.UUID, method models.PaymentMethod,
+	amount *money.Money, now time.Time) (*paymentReservation, error) {
+	var res paymentReservation
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var inv models.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, invoiceID).Error; err != nil {
+			return translateNotFound(err, ErrInvoiceNotFound)
+		}
+		if inv.UserID != userID {
+			return ErrInvoiceNotFound
+		}
+		if !invoicePayable(inv.Status) {
+			return fmt.Errorf("%w: invoice is %s", ErrInvoiceNotPayable, inv.Status)
+		}
+		if err := settleInvoice(tx, &inv, now); err != nil {
+			return err
+		}
+		if !invoicePayable(inv.Status) || !inv.AmountDue.IsPositive() {
+			return fmt.Errorf("%w: nothing remains to pay", ErrInvoiceNotPayable)
+		}
+
+		charge := inv.AmountDue
+		if amount != nil {
+			// Amounts are asked for in the invoice's currency
+			charge = amount.In(inv.Currency)
+			if charge.Cmp(inv.AmountDue) > 0 {
+				return fmt.Errorf("%w: amount is more than the %s remaining", ErrInvalidPayment, inv.AmountDue.Display())
+			}
+			minimum := money.New(minimumCharges[inv.Currency], inv.Currency)
+			if charge.Cmp(minimum) < 0 && charge.Cmp(inv.AmountDue) != 0 {
+				return fmt.Errorf("%w: amount must be at least %s", ErrInvalidPayment, minimum.Display())
+			}
+		}
+
+		// A declined intent can still be retried, so failed payments are
+		// reused or cancelled along with pending ones
+		var open []models.Payment
+		if err := tx.Where("invoice_id = ? AND status IN ?", inv.ID,
+			[]models.PaymentStatus{models.PaymentStatusPending, models.PaymentStatusFailed}).
+			Order("created_at DESC").Find(&open).Error; err != nil {
+			return err
+		}
+		reserved := false
+		for _, payment := range open {
+			switch {
+			case payment.StripePaymentID != "":
+				res.open = append(res.open, payment)
+			case !reserved && payment.Status == models.PaymentStatusPending && payment.Amount.Cmp(charge) == 0:
+				res.payment = payment
+				reserved = true
+			default:
+				if err := tx.Model(&payment).Update("status", models.PaymentStatusCancelled).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if !reserved {
+			res.payment = models.Payment{
+				ID:            uuid.New(),
+				InvoiceID:     inv.ID,
+				UserID:        userID,
+				Currency:      inv.Currency,
+				Amount:        charge,
+				PaymentMethod: method,
+				Status:        models.PaymentStatusPending,
+			}
+			if err := tx.Create(&res.payment).Error; err != nil {
+				return err
+			}
+		}
+
+		var user models.User
+		if err := tx.Select("id", "email").First(&user, userID).Error; err != nil {
+			return err
+		}
+		var yacht models.Yacht
+		if err := tx.Select("id", "name").First(&yacht, inv.YachtID).Error; err != nil {
+			return err
+		}
+
+		res.params = stripe.PaymentIntentParams{
+			Amount:       charge.Minor(),
+			Currency:     strings.ToLower(string(inv.Currency)),
+			Description:  fmt.Sprintf("%s – %s", inv.InvoiceNumber, yacht.Name),
+			ReceiptEmail: user.Email,
+			Metadata: map[string]string{
+				"invoice_id":     inv.ID.String(),
+				"invoice_number": inv.InvoiceNumber,
+				"payment_id":     res.payment.ID.String(),
+				"user_id":        userID.String(),
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// reuseOpen returns an unpaid payment's intent if it is for the amount being
+// paid and can still be paid. An intent for a different amount is cancelled
+// and nil returned, so the payment can be dropped; one Stripe is already
+// charging blocks a new payment.
+func (s *PaymentService) reuseOpen(ctx context.Context, payment models.Payment, amount money.Money) (*stripe.PaymentIntent, error) {
+	pi, err := s.stripe.PaymentIntent(ctx, payment.StripePaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case pi.Status == stripe.PaymentIntentProcessing || pi.Status == stripe.PaymentIntentSucceeded:
+		return nil, fmt.Errorf("%w: a payment is already being processed", ErrInvoiceNotPayable)
+	case pi.Reusable() && pi.Amount == amount.Minor():
+		return pi, nil
+	case pi.Reusable():
+		if _, err := s.stripe.CancelPaymentIntent(ctx, pi.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// commitPayment records under the invoice lock which payment's intent is
+// handed out and cancels the dropped ones. It fails if the payment was
+// completed or replaced while Stripe was being called.
+func (s *PaymentService) commitPayment(ctx context.Context, invoiceID, paymentID uuid.UUID, pi *stripe.PaymentIntent,
+	method models.PaymentMethod, dropped []uuid.UUID) (*PaymentCheckout, error) {
+	var checkout *PaymentCheckout
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var inv models.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&inv, invoiceID).Error; err != nil {
+			return translateNotFound(err, ErrInvoiceNotFound)
+		}
+
+		var payment models.Payment
+		if err := tx.First(&payment, paymentID).Error; err != nil {
+			return err
+		}
+		open := payment.Status == models.PaymentStatusPending || payment.Status == models.PaymentStatusFailed
+		if !open || (payment.StripePaymentID != "" && payment.StripePaymentID != pi.ID) {
+			return fmt.Errorf("%w: the payment changed while it was being started, try again", ErrInvoiceNotPayable)
+		}
+
+		payment.Status = models.PaymentStatusPending
+		payment.PaymentMethod = method
+		payment.StripePaymentID = pi.ID
+		if err := tx.Model(&payment).Updates(map[string]interface{}{
+			"status":            payment.Status,
+			"payment_method":    payment.PaymentMethod,
+			"stripe_payment_id": payment.StripePaymentID,
+		}).Error; err != nil {
+			return err
+		}
+
+		if len(dropped) > 0 {
+			if err := tx.Model(&models.Payment{}).
+				Where("id IN ? AND status IN ?", dropped,
+					[]models.PaymentStatus{models.PaymentStatusPending, models.PaymentStatusFailed}).
+				Update("status", models.PaymentStatusCancelled).Error; err != nil {
+				return err
+			}
+		}
+
+		checkout = newPaymentCheckout(payment, pi)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return checkout, nil
+}
+
+// RefundInput describes money to return from a payment. A nil Amount
+// refunds everything not yet refunded.
+type RefundInput struct {
+	Amount *money.Money
+	Reason string
+}
+
+// Refund returns some or all of a completed payment through Stripe and
+// reopens its invoice for whatever is then owed
+func (s *PaymentService) Refund(ctx context.Context, paymentID, refundedBy uuid.UUID, input RefundInput, now time.Time) (*models.Payment, error) {
+	var payment *models.Payment
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var inv *models.Invoice
+		var err error
+		payment, inv, err = lockPayment(tx, "id = ?", paymentID)
+		if err != nil {
+			return err
+		}
+		if payment == nil {
+			return ErrPaymentNotFound
+		}
+		if payment.Status != models.PaymentStatusCompleted || payment.StripePaymentID == "" {
+			return fmt.Errorf("%w: payment is %s", ErrPaymentNotRefundable, payment.Status)
+		}
+
+		refundable := payment.Amount.Sub(payment.AmountRefunded)
+		amount := refundable
+		if input.Amount != nil {
+			amount = input.Amount.In(payment.Currency)
+		}
+		if !amount.IsPositive() || amount.Cmp(refundable) > 0 {
+			return fmt.Errorf("%w: amount must be between %s and %s", ErrInvalidPayment,
+				money.New(1, payment.Currency).Display(), refundable.Display())
+		}
+
+		// Keyed on what had been refunded, so a retry repeats this refund
+		// rather than making another
+		refund, err := s.stripe.CreateRefund(ctx, stripe.RefundParams{
+			PaymentIntent: payment.StripePaymentID,
+			Amount:        amount.Minor(),
+			Reason:        stripe.RefundReasonRequestedByCustomer,
+			Metadata: map[string]string{
+				"invoice_id":  inv.ID.String(),
+				"payment_id":  payment.ID.String(),
+				"refunded_by": refundedBy.String(),
+				"reason":      strings.TrimSpace(input.Reason),
+			},
+		}, fmt.Sprintf("refund-%s-%d", payment.ID, payment.AmountRefunded.Minor()))
+		if err != nil {
+			return err
+		}
+		if refund.Status == stripe.RefundFailed || refund.Status == stripe.RefundCanceled {
+			return fmt.Errorf("%w: refund %s", ErrPaymentNotRefundable, refund.Status)
+		}
+
+		return applyRefund(tx, payment, inv, payment.AmountRefunded.Add(amount), now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// applyRefund records that refunded has been returned from a locked payment
+// in total, settles its invoice and tells the owner. Totals only grow, so
+// Stripe's reports of earlier refunds arriving late change nothing.
+func applyRefund(tx *gorm.DB, payment *models.Payment, inv *models.Invoice, refunded money.Money, now time.Time) error {
+	refunded = refunded.Min(payment.Amount)
+	if refunded.Cmp(payment.AmountRefunded) <= 0 {
+		return nil
+	}
+	amount := refunded.Sub(payment.AmountRefunded)
+
+	payment.AmountRefunded = refunded
+	if refunded.Cmp(payment.Amount) >= 0 {
+		payment.Status = models.PaymentStatusRefunded
+	}
+	if err := tx.Model(payment).Updates(map[string]interface{}{
+		"amount_refunded": payment.AmountRefunded,
+		"status":          payment.Status,
+	}).Error; err != nil {
+		return err
+	}
+	if err := settleInvoice(tx, inv, now); err != nil {
+		return err
+	}
+
+	return notifyUser(tx, payment.UserID, models.NotificationTypeInvoice, "Payment refunded",
+		fmt.Sprintf("%s of your payment for %s has been refunded", amount.Display(), inv.InvoiceNumber),
+		inv.ID, "invoice")
+}
+
+// lockPayment locks the payment matching a condition and its invoice,
+// returning nil if there is none. The invoice is locked first, as when
+// starting a payment, so the two can't deadlock.
+func lockPayment(tx *gorm.DB, query string, args ...interface{}) (*models.Payment, *models.Invoice, error) {
+	var payment models.Payment
+	err := tx.Select("id", "invoice_id").Where(query, args...).First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var inv models.Invoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, payment.InvoiceID).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, payment.ID).Error; err != nil {
+		return nil, nil, err
+	}
+	return &payment, &inv, nil
+}
+
+// invoicePayable reports whether an invoice in a status can take payments
+func invoicePayable(status models.InvoiceStatus) bool {
+	switch status {
+	case models.InvoiceStatusSent, models.InvoiceStatusPartiallyPaid, models.InvoiceStatusOverdue:
+		return true
+	}
+	return false
+}
+
+func newPaymentCheckout(payment models.Payment, pi *stripe.PaymentIntent) *PaymentCheckout {
+	return &PaymentCheckout{
+		Payment:         payment,
+		PaymentIntentID: pi.ID,
+		ClientSecret:    pi.ClientSecret,
+		Amount:          money.New(pi.Amount, money.Currency(strings.ToUpper(pi.Currency))),
+		Currency:        pi.Currency,
+	}
+}
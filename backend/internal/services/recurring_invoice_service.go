@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRecurringInvoiceNotFound = errors.New("recurring invoice not found")
+	ErrInvalidRecurringInvoice  = errors.New("invalid recurring invoice")
+)
+
+// RecurringInvoiceService manages recurring invoice schedules and issues
+// their periods as levies when they fall due
+type RecurringInvoiceService struct {
+	db     *gorm.DB
+	levies *LevyService
+}
+
+// NewRecurringInvoiceService creates a new recurring invoice service
+func NewRecurringInvoiceService(db *gorm.DB, levies *LevyService) *RecurringInvoiceService {
+	return &RecurringInvoiceService{
+		db:     db,
+		levies: levies,
+	}
+}
+
+// RecurringInvoiceInput holds the fields of a new schedule
+type RecurringInvoiceInput struct {
+	Category    models.LevyCategory
+	Description string
+	Amount      money.Money
+	Cadence     models.InvoiceCadence
+	SplitRule   models.InvoiceSplitRule // By share percentage if empty
+	DueDays     int
+	Send        bool
+	StartDate   time.Time
+	EndDate     *time.Time
+}
+
+// RecurringInvoiceUpdate holds changes to a schedule. Nil fields keep their
+// current value. Changes apply to periods not yet issued.
+type RecurringInvoiceUpdate struct {
+	Description *string
+	Amount      *money.Money
+	DueDays     *int
+	Send        *bool
+	EndDate     *time.Time
+	Active      *bool
+}
+
+// Schedules returns a yacht's recurring invoices
+func (s *RecurringInvoiceService) Schedules(yachtID uuid.UUID) ([]models.RecurringInvoice, error) {
+	var schedules []models.RecurringInvoice
+	if err := s.db.Where("yacht_id = ?", yachtID).Order("created_at ASC").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// Create adds a schedule. Its first period is issued on the start date.
+func (s *RecurringInvoiceService) Create(yachtID, createdBy uuid.UUID, input RecurringInvoiceInput, now time.Time) (*models.RecurringInvoice, error) {
+	schedule := models.RecurringInvoice{
+		YachtID:     yachtID,
+		Category:    input.Category,
+		Description: input.Description,
+		Amount:      input.Amount,
+		Cadence:     input.Cadence,
+		SplitRule:   input.SplitRule,
+		DueDays:     input.DueDays,
+		Send:        input.Send,
+		StartDate:   truncateDate(input.StartDate),
+		Active:      true,
+		CreatedBy:   createdBy,
+	}
+	if schedule.SplitRule == "" {
+		schedule.SplitRule = models.SplitBySharePercentage
+	}
+	if input.EndDate != nil {
+		end := truncateDate(*input.EndDate)
+		schedule.EndDate = &end
+	}
+	next := schedule.StartDate
+	schedule.NextIssueDate = &next
+
+	if err := validateRecurringInvoice(&schedule); err != nil {
+		return nil, err
+	}
+	if schedule.StartDate.Before(truncateDate(now)) {
+		return nil, fmt.Errorf("%w: start_date cannot be in the past", ErrInvalidRecurringInvoice)
+	}
+
+	currency, err := yachtCurrency(s.db, yachtID)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Currency = currency
+	schedule.Amount = schedule.Amount.In(currency)
+	if err := s.db.Create(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// Update changes a schedule. Resuming a paused schedule skips the periods
+// that started while it was paused, so only periods from today are issued.
+func (s *RecurringInvoiceService) Update(scheduleID uuid.UUID, update RecurringInvoiceUpdate, now time.Time) (*models.RecurringInvoice, error) {
+	var schedule models.RecurringInvoice
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schedule, scheduleID).Error; err != nil {
+			return translateNotFound(err, ErrRecurringInvoiceNotFound)
+		}
+
+		if update.Description != nil {
+			schedule.Description = *update.Description
+		}
+		if update.Amount != nil {
+			schedule.Amount = update.Amount.In(schedule.Currency)
+		}
+		if update.DueDays != nil {
+			schedule.DueDays = *update.DueDays
+		}
+		if update.Send != nil {
+			schedule.Send = *update.Send
+		}
+		if update.EndDate != nil {
+			end := truncateDate(*update.EndDate)
+			schedule.EndDate = &end
+		}
+		if update.Active != nil {
+			if *update.Active && !schedule.Active {
+				skipPausedPeriods(&schedule, truncateDate(now))
+			}
+			schedule.Active = *update.Active
+		}
+		if err := validateRecurringInvoice(&schedule); err != nil {
+			return err
+		}
+
+		schedule.NextIssueDate = nextIssueDate(&schedule)
+		if schedule.NextIssueDate == nil {
+			schedule.Active = false
+		}
+		return tx.Save(&schedule).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// IssueDue issues every period of an active schedule that has started.
+// Run from the background scheduler.
+//
+// Each schedule is issued in its own transaction that both raises the
+// period's levy and advances the schedule, so a crash never issues a period
+// without recording it. Schedules locked by another instance are skipped,
+// and the unique index on the levy's schedule and period start turns any
+// remaining race into a no-op.
+func (s *RecurringInvoiceService) IssueDue(ctx context.Context) error {
+	today := truncateDate(time.Now())
+
+	var scheduleIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.RecurringInvoice{}).
+		Where("active = ? AND next_issue_date <= ?", true, today).
+		Pluck("id", &scheduleIDs).Error; err != nil {
+		return err
+	}
+
+	for _, scheduleID := range scheduleIDs {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var schedule models.RecurringInvoice
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).First(&schedule, scheduleID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return s.issue(tx, &schedule, today)
+		})
+		if err != nil {
+			return fmt.Errorf("recurring invoice %s: %w", scheduleID, err)
+		}
+	}
+
+	return nil
+}
+
+// issue raises every period of a locked schedule due by today, catching up
+// on periods missed while the scheduler was not running. Periods missed while
+// the schedule was paused were skipped when it was resumed.
+func (s *RecurringInvoiceService) issue(tx *gorm.DB, schedule *models.RecurringInvoice, today time.Time) error {
+	for schedule.Active && schedule.NextIssueDate != nil && !schedule.NextIssueDate.After(today) {
+		periodStart := schedule.PeriodStart(schedule.PeriodsIssued)
+		periodEnd := schedule.PeriodStart(schedule.PeriodsIssued+1).AddDate(0, 0, -1)
+
+		input := LevyInput{
+			Category:    schedule.Category,
+			Description: schedule.Description,
+			Amount:      schedule.Amount,
+			SplitRule:   schedule.SplitRule,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+			IssuedDate:  today,
+			DueDate:     today.AddDate(0, 0, schedule.DueDays),
+			Send:        schedule.Send,
+		}
+
+		// Savepoint so a period that cannot be issued does not undo the
+		// periods before it
+		err := tx.Transaction(func(sp *gorm.DB) error {
+			_, err := s.levies.raise(sp, schedule.YachtID, schedule.CreatedBy, input, &schedule.ID)
+			return err
+		})
+		switch {
+		case err == nil:
+			schedule.LastError = ""
+		case isPgError(err, pgUniqueViolation):
+			// Already issued
+		case errors.Is(err, ErrNoShareholders), errors.Is(err, ErrInvalidLevy):
+			schedule.LastError = fmt.Sprintf("Period starting %s was not issued: %v", periodStart.Format("2 Jan 2006"), err)
+		default:
+			return err
+		}
+
+		schedule.PeriodsIssued++
+		schedule.NextIssueDate = nextIssueDate(schedule)
+		if schedule.NextIssueDate == nil {
+			schedule.Active = false
+		}
+	}
+
+	return tx.Save(schedule).Error
+}
+
+// skipPausedPeriods moves a schedule being resumed past the periods that
+// started before today, recording them as skipped
+func skipPausedPeriods(schedule *models.RecurringInvoice, today time.Time) {
+	skipTo := schedule.PeriodsBefore(today)
+	if skipTo <= schedule.PeriodsIssued {
+		return
+	}
+	schedule.LastError = fmt.Sprintf("Periods starting %s to %s were skipped while the schedule was paused",
+		schedule.PeriodStart(schedule.PeriodsIssued).Format("2 Jan 2006"), schedule.PeriodStart(skipTo-1).Format("2 Jan 2006"))
+	schedule.PeriodsIssued = skipTo
+}
+
+// nextIssueDate returns the start of the next period to issue, or nil if the
+// schedule has ended
+func nextIssueDate(schedule *models.RecurringInvoice) *time.Time {
+	next := schedule.PeriodStart(schedule.PeriodsIssued)
+	if schedule.EndDate != nil && next.After(*schedule.EndDate) {
+		return nil
+	}
+	return &next
+}
+
+func validateRecurringInvoice(schedule *models.RecurringInvoice) error {
+	switch {
+	case schedule.Cadence.Months() == 0:
+		return fmt.Errorf("%w: cadence must be monthly, quarterly or yearly", ErrInvalidRecurringInvoice)
+	case schedule.SplitRule != models.SplitBySharePercentage && schedule.SplitRule != models.SplitEqually:
+		return fmt.Errorf("%w: unknown split_rule %q", ErrInvalidRecurringInvoice, schedule.SplitRule)
+	case !schedule.Amount.IsPositive():
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidRecurringInvoice)
+	case schedule.Description == "":
+		return fmt.Errorf("%w: description is required", ErrInvalidRecurringInvoice)
+	case schedule.DueDays < 0 || schedule.DueDays > 365:
+		return fmt.Errorf("%w: due_days must be between 0 and 365", ErrInvalidRecurringInvoice)
+	case schedule.StartDate.IsZero():
+		return fmt.Errorf("%w: start_date is required", ErrInvalidRecurringInvoice)
+	case schedule.EndDate != nil && schedule.EndDate.Before(schedule.StartDate):
+		return fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidRecurringInvoice)
+	}
+
+	switch schedule.Category {
+	case models.LevyCategoryBerthing, models.LevyCategoryInsurance, models.LevyCategoryServicing,
+		models.LevyCategoryMaintenance, models.LevyCategoryOther:
+	default:
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidRecurringInvoice, schedule.Category)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidReportWindow is returned for a reporting window that is empty or
+// too long
+var ErrInvalidReportWindow = errors.New("invalid reporting window")
+
+// Longest rolling window a fairness report covers
+const maxFairnessWindowDays = 3 * 365
+
+// ReportingService computes syndicate reports
+type ReportingService struct {
+	db     *gorm.DB
+	ledger *fairshare.Ledger
+}
+
+// NewReportingService creates a new reporting service
+func NewReportingService(db *gorm.DB, ledger *fairshare.Ledger) *ReportingService {
+	return &ReportingService{
+		db:     db,
+		ledger: ledger,
+	}
+}
+
+// FairnessReport is a syndicate's fairness over a rolling window
+type FairnessReport struct {
+	YachtID uuid.UUID `json:"yacht_id"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	fairshare.Fairness
+}
+
+// Fairness scores each shareholder's value-weighted usage against their
+// entitlement over the days before now. Anyone who held a share during the
+// window is included, with the share weighted by the part of the window it
+// was held. Only days inside the window count towards bookings that straddle
+// it.
+func (s *ReportingService) Fairness(yachtID uuid.UUID, days int, now time.Time) (*FairnessReport, error) {
+	if days < 1 || days > maxFairnessWindowDays {
+		return nil, ErrInvalidReportWindow
+	}
+	to := now
+	from := time.Date(now.Year(), now.Month(), now.Day()-days, 0, 0, 0, 0, now.Location())
+
+	var shares []models.SyndicateShare
+	if err := s.db.Scopes(fairshare.HeldBetween(from, to)).Where("yacht_id = ?", yachtID).Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		if err := requireYacht(s.db, yachtID); err != nil {
+			return nil, err
+		}
+	}
+
+	// An owner may hold more than one share in the same yacht
+	index := make(map[uuid.UUID]int)
+	var owners []fairshare.OwnerFairness
+	for _, share := range shares {
+		i, ok := index[share.UserID]
+		if !ok {
+			i = len(owners)
+			index[share.UserID] = i
+			owners = append(owners, fairshare.OwnerFairness{UserID: share.UserID})
+		}
+		owners[i].SharePercentage += share.SharePercentage * fairshare.ShareFraction(share, from, to)
+	}
+
+	valuer, err := s.ledger.Valuer(s.db, yachtID)
+	if err != nil {
+		return nil, err
+	}
+
+	var bookings []models.Booking
+	if err := s.db.Where("yacht_id = ? AND status IN ? AND start_date < ? AND end_date > ?",
+		yachtID, []models.BookingStatus{models.BookingStatusConfirmed, models.BookingStatusCompleted}, to, from).
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+
+	for _, b := range bookings {
+		i, ok := index[b.UserID]
+		if !ok {
+			continue // Held no share during the window
+		}
+		for _, slot := range valuer.Slots(b.StartDate, b.EndDate) {
+			if slot.Date.Before(from) || !slot.Date.Before(to) {
+				continue
+			}
+			owners[i].Days++
+			owners[i].Used += slot.Value
+			if fairshare.IsPremium(slot.Type) {
+				owners[i].PremiumDays++
+			}
+		}
+	}
+
+	return &FairnessReport{
+		YachtID:  yachtID,
+		From:     from,
+		To:       to,
+		Fairness: fairshare.ScoreFairness(owners),
+	}, nil
+}
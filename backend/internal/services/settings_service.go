@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettingsService manages per-syndicate fair share settings
+type SettingsService struct {
+	db     *gorm.DB
+	ledger *fairshare.Ledger
+}
+
+// NewSettingsService creates a new settings service
+func NewSettingsService(db *gorm.DB, ledger *fairshare.Ledger) *SettingsService {
+	return &SettingsService{
+		db:     db,
+		ledger: ledger,
+	}
+}
+
+// SettingsInput holds changes to a syndicate's settings. Nil fields keep
+// their current value.
+type SettingsInput struct {
+	StandardWeekdayWeight *float64
+	PeakWeekdayWeight     *float64
+	WeekendWeight         *float64
+	PremiumWeekendWeight  *float64
+	HolidayWeight         *float64
+	Seasons               *[]fairshare.Season
+	RolloverDecay         *float64
+	HolidayRegion         *string
+	HolidayState          *string
+
+	CollectionLeadDays     *int
+	MaxAdvanceDays         *int
+	MaxConsecutiveDays     *int
+	MinTripGapDays         *int
+	TurnaroundHours        *int
+	CancellationFeePercent *float64
+	LateCancellationDays   *int
+
+	DraftEnabled      *bool
+	DraftPickHours    *int
+	StandbyOfferHours *int
+	SwapLockDays      *int
+}
+
+func (in SettingsInput) apply(cfg *fairshare.Config) {
+	weights := make(map[fairshare.SlotType]float64, len(cfg.Weights))
+	for k, v := range cfg.Weights {
+		weights[k] = v
+	}
+	setFloat(weights, fairshare.SlotStandardWeekday, in.StandardWeekdayWeight)
+	setFloat(weights, fairshare.SlotPeakWeekday, in.PeakWeekdayWeight)
+	setFloat(weights, fairshare.SlotStandardWeekend, in.WeekendWeight)
+	setFloat(weights, fairshare.SlotPremiumWeekend, in.PremiumWeekendWeight)
+	setFloat(weights, fairshare.SlotPublicHoliday, in.HolidayWeight)
+	cfg.Weights = weights
+
+	if in.Seasons != nil {
+		cfg.Seasons = *in.Seasons
+	}
+	if in.RolloverDecay != nil {
+		cfg.RolloverDecay = *in.RolloverDecay
+	}
+	if in.HolidayRegion != nil {
+		cfg.HolidayRegion = strings.ToUpper(*in.HolidayRegion)
+	}
+	if in.HolidayState != nil {
+		cfg.HolidayState = strings.ToUpper(*in.HolidayState)
+	}
+	if in.CollectionLeadDays != nil {
+		cfg.CollectionLeadDays = *in.CollectionLeadDays
+	}
+	if in.MaxAdvanceDays != nil {
+		cfg.MaxAdvanceDays = *in.MaxAdvanceDays
+	}
+	if in.MaxConsecutiveDays != nil {
+		cfg.MaxConsecutiveDays = *in.MaxConsecutiveDays
+	}
+	if in.MinTripGapDays != nil {
+		cfg.MinTripGapDays = *in.MinTripGapDays
+	}
+	if in.TurnaroundHours != nil {
+		cfg.TurnaroundHours = *in.TurnaroundHours
+	}
+	if in.CancellationFeePercent != nil {
+		cfg.CancellationFeePercent = *in.CancellationFeePercent
+	}
+	if in.LateCancellationDays != nil {
+		cfg.LateCancellationDays = *in.LateCancellationDays
+	}
+	if in.DraftEnabled != nil {
+		cfg.DraftEnabled = *in.DraftEnabled
+	}
+	if in.DraftPickHours != nil {
+		cfg.DraftPickHours = *in.DraftPickHours
+	}
+	if in.StandbyOfferHours != nil {
+		cfg.StandbyOfferHours = *in.StandbyOfferHours
+	}
+	if in.SwapLockDays != nil {
+		cfg.SwapLockDays = *in.SwapLockDays
+	}
+}
+
+func setFloat(weights map[fairshare.SlotType]float64, slotType fairshare.SlotType, v *float64) {
+	if v != nil {
+		weights[slotType] = *v
+	}
+}
+
+// Get returns a yacht's syndicate settings, or the defaults if none have
+// been saved
+func (s *SettingsService) Get(yachtID uuid.UUID) (*models.SyndicateSettings, error) {
+	var settings models.SyndicateSettings
+	err := s.db.Where("yacht_id = ?", yachtID).First(&settings).Error
+	if err == nil {
+		return &settings, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := requireYacht(s.db, yachtID); err != nil {
+		return nil, err
+	}
+	settings, err = fairshare.DefaultConfig().Settings(yachtID)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Save applies changes to a yacht's syndicate settings. The result is
+// validated as a whole before it is stored.
+func (s *SettingsService) Save(yachtID, updatedBy uuid.UUID, input SettingsInput) (*models.SyndicateSettings, error) {
+	var settings models.SyndicateSettings
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var yacht models.Yacht
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&yacht, yachtID).Error; err != nil {
+			return translateNotFound(err, ErrYachtNotFound)
+		}
+
+		cfg, err := s.ledger.Config(tx, yachtID)
+		if err != nil {
+			return err
+		}
+		input.apply(&cfg)
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+
+		settings, err = cfg.Settings(yachtID)
+		if err != nil {
+			return err
+		}
+		settings.UpdatedBy = &updatedBy
+
+		var existing models.SyndicateSettings
+		err = tx.Where("yacht_id = ?", yachtID).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(&settings).Error
+		case err != nil:
+			return err
+		}
+		settings.ID = existing.ID
+		settings.CreatedAt = existing.CreatedAt
+		return tx.Save(&settings).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// Reset removes a yacht's saved settings so the defaults apply again
+func (s *SettingsService) Reset(yachtID uuid.UUID) error {
+	if err := requireYacht(s.db, yachtID); err != nil {
+		return err
+	}
+	return s.db.Where("yacht_id = ?", yachtID).Delete(&models.SyndicateSettings{}).Error
+}
+
+func requireYacht(tx *gorm.DB, yachtID uuid.UUID) error {
+	var yacht models.Yacht
+	return translateNotFound(tx.Select("id").First(&yacht, yachtID).Error, ErrYachtNotFound)
+}
+
+// yachtCurrency returns the currency a yacht is invoiced in
+func yachtCurrency(tx *gorm.DB, yachtID uuid.UUID) (money.Currency, error) {
+	var yacht models.Yacht
+	if err := tx.Select("id", "currency").First(&yacht, yachtID).Error; err != nil {
+		return "", translateNotFound(err, ErrYachtNotFound)
+	}
+	return yacht.Currency, nil
+}
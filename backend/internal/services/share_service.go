@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrShareNotFound = errors.New("syndicate share not found")
+	ErrShareEnded    = errors.New("syndicate share has already ended")
+	ErrInvalidShare  = errors.New("invalid syndicate share")
+)
+
+// ShareService manages syndicate shares joining and leaving a yacht. Credits
+// for a share joined or ended partway through a year are pro-rated.
+type ShareService struct {
+	db       *gorm.DB
+	bookings *BookingService
+	ledger   *fairshare.Ledger
+}
+
+// NewShareService creates a new share service
+func NewShareService(db *gorm.DB, bookings *BookingService, ledger *fairshare.Ledger) *ShareService {
+	return &ShareService{
+		db:       db,
+		bookings: bookings,
+		ledger:   ledger,
+	}
+}
+
+// ShareSummary is a share with its entitlement pro-rated for a year
+type ShareSummary struct {
+	models.SyndicateShare
+	Year         int     `json:"year"`
+	HeldFraction float64 `json:"held_fraction"`  // Share of the year the share was held
+	DaysThisYear int     `json:"days_this_year"` // DaysPerYear pro-rated by HeldFraction
+}
+
+// ShareInput holds the fields of a new syndicate share
+type ShareInput struct {
+	UserID          uuid.UUID
+	SharePercentage float64
+	DaysPerYear     int
+	JoinedDate      time.Time
+}
+
+// ExitResult reports the outcome of an owner leaving a syndicate
+type ExitResult struct {
+	Share            models.SyndicateShare `json:"share"`
+	ReleasedBookings []models.Booking      `json:"released_bookings"`
+}
+
+// Shares returns a yacht's shares, including ended ones, with their
+// entitlement for a year
+func (s *ShareService) Shares(yachtID uuid.UUID, year int) ([]ShareSummary, error) {
+	var shares []models.SyndicateShare
+	if err := s.db.Preload("User").Where("yacht_id = ?", yachtID).
+		Order("joined_date ASC, created_at ASC").Find(&shares).Error; err != nil {
+		return nil, err
+	}
+
+	summaries := make([]ShareSummary, len(shares))
+	for i, share := range shares {
+		fraction := fairshare.YearFraction(share, year)
+		summaries[i] = ShareSummary{
+			SyndicateShare: share,
+			Year:           year,
+			HeldFraction:   round4(fraction),
+			DaysThisYear:   fairshare.ProRatedDays(share, year),
+		}
+	}
+	return summaries, nil
+}
+
+// Create adds a share to a yacht. If the owner has already been allocated
+// credits for the years the share covers, pro-rated credits are added.
+func (s *ShareService) Create(yachtID uuid.UUID, input ShareInput) (*models.SyndicateShare, error) {
+	if input.SharePercentage <= 0 || input.SharePercentage > 100 {
+		return nil, fmt.Errorf("%w: share_percentage must be between 0 and 100", ErrInvalidShare)
+	}
+	if input.DaysPerYear < 0 || input.DaysPerYear > 366 {
+		return nil, fmt.Errorf("%w: days_per_year must be between 0 and 366", ErrInvalidShare)
+	}
+	if input.JoinedDate.IsZero() {
+		return nil, fmt.Errorf("%w: joined_date is required", ErrInvalidShare)
+	}
+
+	share := models.SyndicateShare{
+		YachtID:         yachtID,
+		UserID:          input.UserID,
+		SharePercentage: input.SharePercentage,
+		DaysPerYear:     input.DaysPerYear,
+		JoinedDate:      truncateDate(input.JoinedDate),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Serialise share changes for the yacht
+		var yacht models.Yacht
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&yacht, yachtID).Error; err != nil {
+			return translateNotFound(err, ErrYachtNotFound)
+		}
+
+		var user models.User
+		if err := tx.Select("id").First(&user, input.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: user not found", ErrInvalidShare)
+			}
+			return err
+		}
+
+		var held float64
+		if err := tx.Model(&models.SyndicateShare{}).
+			Scopes(fairshare.HeldAt(share.JoinedDate)).
+			Select("COALESCE(SUM(share_percentage), 0)").
+			Where("yacht_id = ?", yachtID).
+			Scan(&held).Error; err != nil {
+			return err
+		}
+		if held+share.SharePercentage > 100.001 {
+			return fmt.Errorf("%w: only %.2f%% of the yacht is unallocated", ErrInvalidShare, 100-held)
+		}
+
+		if err := tx.Create(&share).Error; err != nil {
+			return err
+		}
+		return s.ledger.ProRateShare(tx, nil, share)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &share, nil
+}
+
+// Exit ends a share on exitDate. Credits already allocated for the rest of
+// the year are taken back pro rata. If the owner holds no other share in the
+// yacht, their bookings starting on or after exitDate are cancelled and the
+// dates offered to owners on standby, and their standby entries withdrawn.
+func (s *ShareService) Exit(shareID uuid.UUID, exitDate time.Time) (*ExitResult, error) {
+	result := ExitResult{ReleasedBookings: []models.Booking{}}
+	exitDate = truncateDate(exitDate)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		share := &result.Share
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(share, shareID).Error; err != nil {
+			return translateNotFound(err, ErrShareNotFound)
+		}
+		if share.EndedDate != nil {
+			return ErrShareEnded
+		}
+		if !share.JoinedDate.IsZero() && !exitDate.After(share.JoinedDate) {
+			return fmt.Errorf("%w: exit_date must be after joined_date", ErrInvalidShare)
+		}
+
+		previous := *share
+		share.EndedDate = &exitDate
+		if err := tx.Save(share).Error; err != nil {
+			return err
+		}
+		if err := s.ledger.ProRateShare(tx, &previous, *share); err != nil {
+			return err
+		}
+
+		var remaining int64
+		if err := tx.Model(&models.SyndicateShare{}).
+			Scopes(fairshare.HeldAt(exitDate)).
+			Where("yacht_id = ? AND user_id = ? AND id <> ?", share.YachtID, share.UserID, share.ID).
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return nil
+		}
+
+		now := time.Now()
+
+		var entries []models.StandbyEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("yacht_id = ? AND user_id = ? AND status = ? AND booking_id IS NULL",
+				share.YachtID, share.UserID, models.StandbyEntryStatusWaiting).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+		for i := range entries {
+			if err := s.bookings.withdrawEntry(tx, &entries[i], now); err != nil {
+				return err
+			}
+		}
+
+		var bookings []models.Booking
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("yacht_id = ? AND user_id = ? AND start_date >= ? AND status IN ?", share.YachtID, share.UserID, exitDate,
+				[]models.BookingStatus{models.BookingStatusPending, models.BookingStatusConfirmed, models.BookingStatusStandby}).
+			Order("start_date ASC").
+			Find(&bookings).Error; err != nil {
+			return err
+		}
+		for i := range bookings {
+			if err := s.bookings.release(tx, &bookings[i], now); err != nil {
+				return err
+			}
+			result.ReleasedBookings = append(result.ReleasedBookings, bookings[i])
+		}
+
+		message := fmt.Sprintf("Your share ends on %s.", exitDate.Format("2 Jan 2006"))
+		if n := len(bookings); n > 0 {
+			message += fmt.Sprintf(" %d booking(s) after that date have been released to the standby list.", n)
+		}
+		return notifyUser(tx, share.UserID, models.NotificationTypeBooking,
+			"Syndicate share ended", message, share.ID, "syndicate_share")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func round4(x float64) float64 {
+	return math.Round(x*10000) / 10000
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/bitcoinbrisbane/yachtlife/internal/stripe"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidStripeSignature = errors.New("invalid stripe webhook signature")
+	ErrInvalidStripeWebhook   = errors.New("invalid stripe webhook payload")
+	ErrStripeEventEarly       = errors.New("stripe event arrived before the event it follows")
+)
+
+// StripeWebhookService applies Stripe's payment events. Events are handled
+// as they arrive, inside the transaction that records them, so a failure
+// leaves the event unrecorded and Stripe's retry handles it again.
+type StripeWebhookService struct {
+	db        *gorm.DB
+	secret    string
+	tolerance time.Duration
+}
+
+// NewStripeWebhookService creates a new Stripe webhook service. secret is
+// the endpoint's signing secret.
+func NewStripeWebhookService(db *gorm.DB, secret string) *StripeWebhookService {
+	return &StripeWebhookService{
+		db:        db,
+		secret:    secret,
+		tolerance: stripe.DefaultTolerance,
+	}
+}
+
+// Receive verifies and applies a delivery, reporting whether the event was
+// new. Events already handled, and events about payments the app didn't
+// start, succeed without changing anything.
+func (s *StripeWebhookService) Receive(payload []byte, signature string, now time.Time) (bool, error) {
+	if err := stripe.VerifySignature(payload, signature, s.secret, now, s.tolerance); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidStripeSignature, err)
+	}
+	event, err := stripe.ParseEvent(payload)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidStripeWebhook, err)
+	}
+
+	handled := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		record := models.StripeEvent{
+			ID:          event.ID,
+			Type:        event.Type,
+			CreatedAt:   event.CreatedAt(),
+			ProcessedAt: now,
+		}
+
+		// Concurrent deliveries of the same event wait here for the first
+		// to commit, then find it recorded
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		handled = true
+
+		objectID, err := s.apply(tx, event, now)
+		if err != nil {
+			return err
+		}
+		if objectID == "" {
+			return nil
+		}
+		return tx.Model(&record).Update("object_id", objectID).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return handled, nil
+}
+
+// apply makes an event's change, returning the ID of the object it was
+// about. Other event types are recorded and otherwise ignored.
+func (s *StripeWebhookService) apply(tx *gorm.DB, event *stripe.Event, now time.Time) (string, error) {
+	switch event.Type {
+	case stripe.EventPaymentIntentSucceeded:
+		pi, err := event.PaymentIntent()
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidStripeWebhook, err)
+		}
+		return pi.ID, s.paymentSucceeded(tx, pi, event.CreatedAt())
+
+	case stripe.EventPaymentIntentPaymentFailed:
+		pi, err := event.PaymentIntent()
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidStripeWebhook, err)
+		}
+		return pi.ID, s.paymentFailed(tx, pi)
+
+	case stripe.EventChargeRefunded:
+		ch, err := event.Charge()
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidStripeWebhook, err)
+		}
+		return ch.ID, s.chargeRefunded(tx, ch, now)
+	}
+
+	return "", nil
+}
+
+// paymentSucceeded completes a payment and settles its invoice, which is
+// paid once nothing remains
+func (s *StripeWebhookService) paymentSucceeded(tx *gorm.DB, pi *stripe.PaymentIntent, paidAt time.Time) error {
+	payment, inv, err := lockStripePayment(tx, pi.ID)
+	if err != nil || payment == nil {
+		return err
+	}
+	switch payment.Status {
+	case models.PaymentStatusCompleted, models.PaymentStatusRefunded:
+		return nil
+	}
+
+	if err := tx.Model(payment).Updates(map[string]interface{}{
+		"status":         models.PaymentStatusCompleted,
+		"paid_at":        paidAt,
+		"failure_reason": "",
+	}).Error; err != nil {
+		return err
+	}
+	if err := settleInvoice(tx, inv, paidAt); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Thanks, your payment of %s for %s has been received", payment.Amount.Display(), inv.InvoiceNumber)
+	if inv.AmountDue.IsPositive() {
+		message += fmt.Sprintf(". %s remains to pay.", inv.AmountDue.Display())
+	}
+	return notifyUser(tx, payment.UserID, models.NotificationTypeInvoice, "Payment received",
+		message, inv.ID, "invoice")
+}
+
+// paymentFailed records why an attempt was declined. The intent can still be
+// retried, which completes the payment if it succeeds.
+func (s *StripeWebhookService) paymentFailed(tx *gorm.DB, pi *stripe.PaymentIntent) error {
+	payment, _, err := lockStripePayment(tx, pi.ID)
+	if err != nil || payment == nil {
+		return err
+	}
+	// Events can arrive out of order; a later success wins
+	if payment.Status != models.PaymentStatusPending && payment.Status != models.PaymentStatusFailed {
+		return nil
+	}
+
+	reason := "Payment was declined"
+	if pi.LastPaymentError != nil && pi.LastPaymentError.Message != "" {
+		reason = pi.LastPaymentError.Message
+	}
+	return tx.Model(payment).Updates(map[string]interface{}{
+		"status":         models.PaymentStatusFailed,
+		"failure_reason": reason,
+	}).Error
+}
+
+// chargeRefunded records refunds made through the app or Stripe's
+// dashboard. The charge carries the total refunded so far. Stripe does not
+// order events, so a refund arriving before its payment succeeded is
+// refused, leaving it unrecorded for Stripe to deliver again.
+func (s *StripeWebhookService) chargeRefunded(tx *gorm.DB, ch *stripe.Charge, now time.Time) error {
+	if ch.PaymentIntent == "" {
+		return nil
+	}
+	payment, inv, err := lockStripePayment(tx, ch.PaymentIntent)
+	if err != nil || payment == nil {
+		return err
+	}
+	switch payment.Status {
+	case models.PaymentStatusPending, models.PaymentStatusFailed:
+		return fmt.Errorf("%w: charge %s was refunded before its payment succeeded", ErrStripeEventEarly, ch.ID)
+	case models.PaymentStatusCompleted:
+	default:
+		return nil
+	}
+
+	// Stripe counts in the same minor units
+	refunded := money.New(ch.AmountRefunded, payment.Amount.Currency())
+	return applyRefund(tx, payment, inv, refunded, now)
+}
+
+// lockStripePayment locks the payment taken through an intent and its
+// invoice, returning nil if the app didn't start it
+func lockStripePayment(tx *gorm.DB, paymentIntentID string) (*models.Payment, *models.Invoice, error) {
+	return lockPayment(tx, "stripe_payment_id = ?", paymentIntentID)
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/fairshare"
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSwapNotFound       = errors.New("swap offer not found")
+	ErrSwapNotAllowed     = errors.New("you can only offer your own booking for another owner's booking")
+	ErrSwapDifferentYacht = errors.New("both bookings must be on the same yacht")
+	ErrSwapNotSwappable   = errors.New("only confirmed bookings can be swapped")
+	ErrSwapLocked         = errors.New("bookings cannot be swapped this close to departure")
+	ErrSwapClosed         = errors.New("swap offer has already been answered")
+	ErrSwapExists         = errors.New("you already have a pending offer for this swap")
+	ErrSwapVoid           = errors.New("one of the bookings has changed since the swap was offered")
+	ErrSwapRevalued       = errors.New("the credit value of one of the bookings has changed since the swap was offered")
+)
+
+// SwapService runs the owner-to-owner swap marketplace
+type SwapService struct {
+	db       *gorm.DB
+	bookings *BookingService
+	ledger   *fairshare.Ledger
+}
+
+// NewSwapService creates a new swap service
+func NewSwapService(db *gorm.DB, bookings *BookingService, ledger *fairshare.Ledger) *SwapService {
+	return &SwapService{
+		db:       db,
+		bookings: bookings,
+		ledger:   ledger,
+	}
+}
+
+// ProposeSwapInput holds the fields required to propose a swap
+type ProposeSwapInput struct {
+	ProposerID         uuid.UUID
+	OfferedBookingID   uuid.UUID
+	RequestedBookingID uuid.UUID
+	Message            string
+}
+
+// Propose offers the proposer's booking in exchange for another owner's
+// booking. The dates and slot values are recorded at proposal time so both
+// owners can see the credit adjustment they are agreeing to; the offer is
+// voided rather than accepted on different terms.
+func (s *SwapService) Propose(input ProposeSwapInput) (*models.SwapOffer, error) {
+	var swap models.SwapOffer
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var offered, requested models.Booking
+		if err := tx.First(&offered, input.OfferedBookingID).Error; err != nil {
+			return translateNotFound(err, ErrBookingNotFound)
+		}
+		if err := tx.First(&requested, input.RequestedBookingID).Error; err != nil {
+			return translateNotFound(err, ErrBookingNotFound)
+		}
+
+		if offered.UserID != input.ProposerID || requested.UserID == input.ProposerID {
+			return ErrSwapNotAllowed
+		}
+		cfg, err := s.ledger.Config(tx, offered.YachtID)
+		if err != nil {
+			return err
+		}
+		if err := checkSwappable(cfg, &offered, &requested, now); err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.SwapOffer{}).
+			Where("offered_booking_id = ? AND requested_booking_id = ? AND status = ?",
+				offered.ID, requested.ID, models.SwapOfferStatusPending).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrSwapExists
+		}
+
+		valuer, err := s.ledger.Valuer(tx, offered.YachtID)
+		if err != nil {
+			return err
+		}
+		offeredValue := valuer.RangeValue(offered.StartDate, offered.EndDate)
+		requestedValue := valuer.RangeValue(requested.StartDate, requested.EndDate)
+
+		swap = models.SwapOffer{
+			YachtID:            offered.YachtID,
+			ProposerID:         input.ProposerID,
+			RecipientID:        requested.UserID,
+			OfferedBookingID:   offered.ID,
+			RequestedBookingID: requested.ID,
+			OfferedStartDate:   offered.StartDate,
+			OfferedEndDate:     offered.EndDate,
+			RequestedStartDate: requested.StartDate,
+			RequestedEndDate:   requested.EndDate,
+			OfferedValue:       offeredValue,
+			RequestedValue:     requestedValue,
+			CreditAdjustment:   math.Round((offeredValue-requestedValue)*100) / 100,
+			Status:             models.SwapOfferStatusPending,
+			Message:            input.Message,
+		}
+		if err := tx.Create(&swap).Error; err != nil {
+			return err
+		}
+
+		return notifyUser(tx, swap.RecipientID, models.NotificationTypeBooking,
+			"Swap offer received",
+			fmt.Sprintf("An owner has offered %s in exchange for your booking on %s.",
+				formatBookingDates(&offered), formatBookingDates(&requested)),
+			swap.ID, "swap_offer")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(swap.ID)
+}
+
+// Get returns a swap offer with both bookings
+func (s *SwapService) Get(swapID uuid.UUID) (*models.SwapOffer, error) {
+	var swap models.SwapOffer
+	if err := s.db.Preload("Proposer").Preload("Recipient").
+		Preload("OfferedBooking").Preload("RequestedBooking").
+		First(&swap, swapID).Error; err != nil {
+		return nil, translateNotFound(err, ErrSwapNotFound)
+	}
+	return &swap, nil
+}
+
+// SwapOffersFilter narrows a swap offer listing
+type SwapOffersFilter struct {
+	UserID  *uuid.UUID // Offers made or received by this owner
+	YachtID *uuid.UUID
+	Status  models.SwapOfferStatus
+}
+
+// List returns swap offers, newest first
+func (s *SwapService) List(filter SwapOffersFilter) ([]models.SwapOffer, error) {
+	swaps := []models.SwapOffer{}
+	query := s.db.Preload("Proposer").Preload("Recipient").
+		Preload("OfferedBooking").Preload("RequestedBooking")
+	if filter.UserID != nil {
+		query = query.Where("proposer_id = ? OR recipient_id = ?", *filter.UserID, *filter.UserID)
+	}
+	if filter.YachtID != nil {
+		query = query.Where("yacht_id = ?", *filter.YachtID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	err := query.Order("created_at DESC").Find(&swaps).Error
+	return swaps, err
+}
+
+// Accept exchanges the two bookings. Both are reassigned in one transaction
+// and the ledger moves each booking's value to its new owner, which settles
+// the credit difference. The offer is voided instead if either booking has
+// changed owner or dates, or is now valued differently, since it was made,
+// or if either new owner could not have booked their new dates themselves.
+// Other pending offers for either booking are voided.
+func (s *SwapService) Accept(swapID, userID uuid.UUID) (*models.SwapOffer, error) {
+	var failure error
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		swap, err := lockSwap(tx, swapID)
+		if err != nil {
+			return err
+		}
+		if swap.RecipientID != userID {
+			return ErrSwapNotFound
+		}
+
+		// Lock both bookings in a fixed order so concurrent swaps can't deadlock
+		first, second := swap.OfferedBookingID, swap.RequestedBookingID
+		if second.String() < first.String() {
+			first, second = second, first
+		}
+		bookings := make(map[uuid.UUID]*models.Booking, 2)
+		for _, id := range []uuid.UUID{first, second} {
+			var booking models.Booking
+			if err := lockBooking(tx, id, &booking); err != nil {
+				return err
+			}
+			bookings[id] = &booking
+		}
+		offered := bookings[swap.OfferedBookingID]
+		requested := bookings[swap.RequestedBookingID]
+
+		cfg, err := s.ledger.Config(tx, swap.YachtID)
+		if err != nil {
+			return err
+		}
+		valuer, err := s.ledger.Valuer(tx, swap.YachtID)
+		if err != nil {
+			return err
+		}
+		if err := checkSwapTerms(swap, offered, requested, valuer); err != nil {
+			failure = err
+		} else if err := checkSwappable(cfg, offered, requested, now); err != nil {
+			failure = err
+		} else if err := s.exchange(tx, cfg, swap, offered, requested, now); isSwapRuleError(err) {
+			failure = err
+		} else if err != nil {
+			return err
+		}
+		if failure != nil {
+			swap.Status = models.SwapOfferStatusVoid
+			swap.StatusNote = failure.Error()
+			swap.RespondedAt = &now
+			return tx.Save(swap).Error
+		}
+
+		swap.Status = models.SwapOfferStatusAccepted
+		swap.RespondedAt = &now
+		if err := tx.Save(swap).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.SwapOffer{}).
+			Where("id <> ? AND status = ?", swap.ID, models.SwapOfferStatusPending).
+			Where("offered_booking_id IN ? OR requested_booking_id IN ?",
+				[]uuid.UUID{offered.ID, requested.ID}, []uuid.UUID{offered.ID, requested.ID}).
+			Updates(map[string]interface{}{
+				"status":       models.SwapOfferStatusVoid,
+				"status_note":  "One of the bookings was swapped in another offer",
+				"responded_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		return notifyUser(tx, swap.ProposerID, models.NotificationTypeBooking,
+			"Swap accepted",
+			fmt.Sprintf("Your swap was accepted. You now have the booking on %s.", formatBookingDates(requested)),
+			swap.ID, "swap_offer")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if failure != nil {
+		return nil, failure
+	}
+
+	return s.Get(swapID)
+}
+
+// exchange gives each booking of a swap to the other owner and moves their
+// credits. The bookings are checked as if each new owner had booked them, in
+// a savepoint so a swap breaking the booking rules changes nothing.
+func (s *SwapService) exchange(tx *gorm.DB, cfg fairshare.Config, swap *models.SwapOffer, offered, requested *models.Booking, now time.Time) error {
+	return tx.Transaction(func(sp *gorm.DB) error {
+		offered.UserID = swap.RecipientID
+		requested.UserID = swap.ProposerID
+		reassigned := []*models.Booking{offered, requested}
+
+		// Both are saved before checking so each sees the other's new owner
+		for _, booking := range reassigned {
+			if err := sp.Save(booking).Error; err != nil {
+				return translateBookingError(err)
+			}
+		}
+		for _, booking := range reassigned {
+			if err := requireSyndicateShare(sp, booking.YachtID, booking.UserID, booking.StartDate, booking.EndDate); err != nil {
+				return err
+			}
+			if err := s.bookings.checkBookingRules(sp, cfg, booking, now); err != nil {
+				return err
+			}
+		}
+		for _, booking := range reassigned {
+			if err := s.ledger.SyncBooking(sp, booking); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Decline turns down a swap offer (recipient only)
+func (s *SwapService) Decline(swapID, userID uuid.UUID) (*models.SwapOffer, error) {
+	return s.close(swapID, func(swap *models.SwapOffer) error {
+		if swap.RecipientID != userID {
+			return ErrSwapNotFound
+		}
+		swap.Status = models.SwapOfferStatusDeclined
+		return nil
+	})
+}
+
+// Withdraw cancels a swap offer before it is answered (proposer only)
+func (s *SwapService) Withdraw(swapID, userID uuid.UUID) (*models.SwapOffer, error) {
+	return s.close(swapID, func(swap *models.SwapOffer) error {
+		if swap.ProposerID != userID {
+			return ErrSwapNotFound
+		}
+		swap.Status = models.SwapOfferStatusWithdrawn
+		return nil
+	})
+}
+
+// close answers a pending swap offer without exchanging the bookings
+func (s *SwapService) close(swapID uuid.UUID, apply func(*models.SwapOffer) error) (*models.SwapOffer, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		swap, err := lockSwap(tx, swapID)
+		if err != nil {
+			return err
+		}
+		if err := apply(swap); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		swap.RespondedAt = &now
+		return tx.Save(swap).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(swapID)
+}
+
+// lockSwap loads a pending swap offer under a row lock
+func lockSwap(tx *gorm.DB, swapID uuid.UUID) (*models.SwapOffer, error) {
+	var swap models.SwapOffer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&swap, swapID).Error; err != nil {
+		return nil, translateNotFound(err, ErrSwapNotFound)
+	}
+	if swap.Status != models.SwapOfferStatusPending {
+		return nil, ErrSwapClosed
+	}
+	return &swap, nil
+}
+
+// checkSwapTerms checks that both bookings still have the owners, dates and
+// values the swap was offered on, so the ledger settles the credit
+// adjustment the owners agreed to
+func checkSwapTerms(swap *models.SwapOffer, offered, requested *models.Booking, valuer *fairshare.Valuer) error {
+	if offered.UserID != swap.ProposerID || requested.UserID != swap.RecipientID {
+		return ErrSwapVoid
+	}
+	if !offered.StartDate.Equal(swap.OfferedStartDate) || !offered.EndDate.Equal(swap.OfferedEndDate) ||
+		!requested.StartDate.Equal(swap.RequestedStartDate) || !requested.EndDate.Equal(swap.RequestedEndDate) {
+		return ErrSwapVoid
+	}
+	if math.Round(valuer.RangeValue(offered.StartDate, offered.EndDate)*100) != math.Round(swap.OfferedValue*100) ||
+		math.Round(valuer.RangeValue(requested.StartDate, requested.EndDate)*100) != math.Round(swap.RequestedValue*100) {
+		return ErrSwapRevalued
+	}
+	return nil
+}
+
+// isSwapRuleError reports whether err is a booking rule that a swap broke
+// for one of the new owners
+func isSwapRuleError(err error) bool {
+	return errors.Is(err, ErrNotSyndicateMember) || errors.Is(err, ErrBookingTooFarAhead) ||
+		errors.Is(err, ErrOutsideBookingWindow) || isTripRuleError(err)
+}
+
+// checkSwappable applies the swap rules: confirmed bookings on the same yacht,
+// neither starting inside the syndicate's swap lock window
+func checkSwappable(cfg fairshare.Config, offered, requested *models.Booking, now time.Time) error {
+	if offered.YachtID != requested.YachtID {
+		return ErrSwapDifferentYacht
+	}
+	for _, b := range []*models.Booking{offered, requested} {
+		if b.Status != models.BookingStatusConfirmed {
+			return ErrSwapNotSwappable
+		}
+		if b.StartDate.Before(now.AddDate(0, 0, cfg.SwapLockDays)) {
+			return ErrSwapLocked
+		}
+	}
+	return nil
+}
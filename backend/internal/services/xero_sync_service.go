@@ -0,0 +1,654 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/bitcoinbrisbane/yachtlife/internal/xero"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrXeroNotConnected = errors.New("xero is not connected")
+	ErrInvalidXeroState = errors.New("invalid or expired xero connection request")
+)
+
+// How long a manager has to approve the connection in Xero
+const xeroStateTTL = 15 * time.Minute
+
+// Status pulls overlap the previous pull by this much, to allow for clock
+// differences between the app and Xero. Applying a change twice is harmless.
+const xeroPullOverlap = 5 * time.Minute
+
+// XeroAccounts are the chart of accounts codes synced records are posted to
+type XeroAccounts struct {
+	Sales    string // Revenue account for invoice lines
+	Payments string // Bank account payments are received into
+}
+
+// XeroSyncService keeps invoices and payments in step with Xero. New
+// invoices and payments are pushed, and status changes made in Xero are
+// pulled back. A record Xero rejects keeps the reason in its XeroSyncError
+// and is retried on the next sync.
+type XeroSyncService struct {
+	db          *gorm.DB
+	client      xero.API
+	accounts    XeroAccounts
+	stateSecret []byte
+}
+
+// NewXeroSyncService creates a new Xero sync service. stateSecret signs the
+// state parameter of connection requests.
+func NewXeroSyncService(db *gorm.DB, client xero.API, accounts XeroAccounts, stateSecret string) *XeroSyncService {
+	return &XeroSyncService{
+		db:          db,
+		client:      client,
+		accounts:    accounts,
+		stateSecret: []byte(stateSecret),
+	}
+}
+
+// XeroSyncResult counts what a sync did
+type XeroSyncResult struct {
+	InvoicesPushed  int `json:"invoices_pushed"`
+	PaymentsPushed  int `json:"payments_pushed"`
+	InvoicesUpdated int `json:"invoices_updated"`
+	Rejected        int `json:"rejected"`
+}
+
+// XeroSyncStatus is the connection and the records waiting to be synced
+type XeroSyncStatus struct {
+	Connection       *models.XeroConnection `json:"connection"`
+	PendingInvoices  int64                  `json:"pending_invoices"`
+	PendingPayments  int64                  `json:"pending_payments"`
+	RejectedInvoices []models.Invoice       `json:"rejected_invoices"`
+	RejectedPayments []models.Payment       `json:"rejected_payments"`
+}
+
+// ConnectURL returns the Xero URL a manager approves the connection at. The
+// state parameter ties the callback to the manager and expires.
+func (s *XeroSyncService) ConnectURL(userID uuid.UUID, now time.Time) string {
+	payload := userID.String() + "|" + strconv.FormatInt(now.Add(xeroStateTTL).Unix(), 10)
+	state := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.signState(payload)
+	return s.client.AuthCodeURL(state)
+}
+
+// Connect completes a connection with the code Xero redirected back with
+func (s *XeroSyncService) Connect(ctx context.Context, code, state string, now time.Time) (*models.XeroConnection, error) {
+	userID, err := s.verifyState(state, now)
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return nil, fmt.Errorf("%w: missing code", ErrInvalidXeroState)
+	}
+
+	if _, err := s.client.Exchange(ctx, code); err != nil {
+		return nil, err
+	}
+
+	conn, err := s.connection(s.db)
+	if err != nil {
+		return nil, err
+	}
+	// A new organisation has none of the changes the last pull saw
+	if err := s.db.Model(conn).Updates(map[string]any{
+		"connected_by":   userID,
+		"last_pulled_at": nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+	conn.ConnectedBy = &userID
+	conn.LastPulledAt = nil
+	return conn, nil
+}
+
+// Status returns the connection and the records not yet in Xero
+func (s *XeroSyncService) Status() (*XeroSyncStatus, error) {
+	var status XeroSyncStatus
+
+	conn, err := s.connection(s.db)
+	if err != nil && !errors.Is(err, ErrXeroNotConnected) {
+		return nil, err
+	}
+	status.Connection = conn
+
+	if err := s.pendingInvoices(s.db).Count(&status.PendingInvoices).Error; err != nil {
+		return nil, err
+	}
+	if err := s.pendingPayments(s.db).Count(&status.PendingPayments).Error; err != nil {
+		return nil, err
+	}
+	if err := s.pendingInvoices(s.db).Where("xero_sync_error <> ''").
+		Order("updated_at DESC").Limit(50).Find(&status.RejectedInvoices).Error; err != nil {
+		return nil, err
+	}
+	if err := s.pendingPayments(s.db).Where("payments.xero_sync_error <> ''").
+		Order("payments.updated_at DESC").Limit(50).Find(&status.RejectedPayments).Error; err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// SyncDue runs a sync from the background scheduler. It does nothing until
+// an organisation is connected.
+func (s *XeroSyncService) SyncDue(ctx context.Context) error {
+	_, err := s.Sync(ctx)
+	if errors.Is(err, ErrXeroNotConnected) {
+		return nil
+	}
+	return err
+}
+
+// Sync pushes invoices, then payments against them, then pulls status
+// changes. It stops at the first failure that is not a rejected record, such
+// as Xero being unavailable, and the next sync carries on from there.
+func (s *XeroSyncService) Sync(ctx context.Context) (*XeroSyncResult, error) {
+	var result XeroSyncResult
+
+	if _, err := s.connection(s.db); err != nil {
+		return nil, err
+	}
+
+	err := s.pushInvoices(ctx, &result)
+	if err == nil {
+		err = s.pushPayments(ctx, &result)
+	}
+	if err == nil {
+		err = s.pullInvoices(ctx, &result)
+	}
+	if errors.Is(err, xero.ErrNotConnected) {
+		err = fmt.Errorf("%w: %v", ErrXeroNotConnected, err)
+	}
+	return &result, err
+}
+
+// pendingInvoices selects issued invoices not yet in Xero, or changed since
+// they were pushed. Drafts stay in the app until they are sent.
+func (s *XeroSyncService) pendingInvoices(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&models.Invoice{}).
+		Where("(xero_invoice_id = '' OR xero_outdated) AND status IN ?", []models.InvoiceStatus{
+			models.InvoiceStatusSent, models.InvoiceStatusPartiallyPaid, models.InvoiceStatusOverdue,
+			models.InvoiceStatusPaid,
+		})
+}
+
+// pendingPayments selects completed payments not yet in Xero whose invoice
+// already is. Payments recorded from Xero are already there.
+func (s *XeroSyncService) pendingPayments(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&models.Payment{}).
+		Joins("JOIN invoices ON invoices.id = payments.invoice_id").
+		Where("payments.status = ? AND payments.xero_payment_id = '' AND invoices.xero_invoice_id <> ''",
+			models.PaymentStatusCompleted).
+		Where("payments.payment_method <> ?", models.PaymentMethodXero)
+}
+
+// pushInvoices creates each pending invoice in Xero, or updates it if it
+// has changed since it was pushed. Each invoice is pushed under a row lock,
+// skipping invoices another server is pushing, with an idempotency key so a
+// push whose response was lost is not duplicated when retried. The key
+// includes the invoice's last update, so an invoice corrected after Xero
+// rejected it is sent afresh.
+func (s *XeroSyncService) pushInvoices(ctx context.Context, result *XeroSyncResult) error {
+	var invoiceIDs []uuid.UUID
+	if err := s.pendingInvoices(s.db.WithContext(ctx)).Order("issued_date ASC, invoice_number ASC").
+		Pluck("id", &invoiceIDs).Error; err != nil {
+		return err
+	}
+
+	for _, invoiceID := range invoiceIDs {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var inv models.Invoice
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).First(&inv, invoiceID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if inv.XeroInvoiceID != "" && !inv.XeroOutdated {
+				return nil
+			}
+			if err := tx.First(&inv.User, inv.UserID).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&inv.Yacht, inv.YachtID).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("invoice_id = ?", inv.ID).Order("created_at ASC").Find(&inv.Lines).Error; err != nil {
+				return err
+			}
+
+			key := fmt.Sprintf("invoice-%s-%d", inv.ID, inv.UpdatedAt.UnixMilli())
+			var created *xero.Invoice
+			if inv.XeroInvoiceID == "" {
+				created, err = s.client.CreateInvoice(ctx, s.xeroInvoice(&inv), key)
+			} else {
+				created, err = s.client.UpdateInvoice(ctx, s.xeroInvoice(&inv), key)
+			}
+			if xero.IsValidationError(err) {
+				result.Rejected++
+				return tx.Model(&inv).Update("xero_sync_error", err.Error()).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			result.InvoicesPushed++
+			changes := map[string]any{
+				"xero_invoice_id": created.InvoiceID,
+				"xero_synced_at":  time.Now(),
+				"xero_sync_error": "",
+				"xero_outdated":   false,
+			}
+			if created.UpdatedDateUTC != nil {
+				changes["xero_updated_at"] = created.UpdatedDateUTC.Time
+			}
+			return tx.Model(&inv).Updates(changes).Error
+		})
+		if err != nil {
+			return fmt.Errorf("pushing invoice %s: %w", invoiceID, err)
+		}
+	}
+
+	return nil
+}
+
+// pushPayments applies each pending payment to its invoice in Xero, locked
+// and keyed like pushInvoices
+func (s *XeroSyncService) pushPayments(ctx context.Context, result *XeroSyncResult) error {
+	var paymentIDs []uuid.UUID
+	if err := s.pendingPayments(s.db.WithContext(ctx)).Order("payments.created_at ASC").
+		Pluck("payments.id", &paymentIDs).Error; err != nil {
+		return err
+	}
+
+	for _, paymentID := range paymentIDs {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var payment models.Payment
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).First(&payment, paymentID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if payment.XeroPaymentID != "" {
+				return nil
+			}
+			if err := tx.First(&payment.Invoice, payment.InvoiceID).Error; err != nil {
+				return err
+			}
+
+			key := fmt.Sprintf("payment-%s-%d", payment.ID, payment.UpdatedAt.UnixMilli())
+			created, err := s.client.CreatePayment(ctx, s.xeroPayment(&payment), key)
+			if xero.IsValidationError(err) {
+				result.Rejected++
+				return tx.Model(&payment).Update("xero_sync_error", err.Error()).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			result.PaymentsPushed++
+			return tx.Model(&payment).Updates(map[string]any{
+				"xero_payment_id": created.PaymentID,
+				"xero_synced_at":  time.Now(),
+				"xero_sync_error": "",
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("pushing payment %s: %w", paymentID, err)
+		}
+	}
+
+	return nil
+}
+
+// pullInvoices applies status changes made in Xero since the last pull
+func (s *XeroSyncService) pullInvoices(ctx context.Context, result *XeroSyncResult) error {
+	conn, err := s.connection(s.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if conn.LastPulledAt != nil {
+		since = conn.LastPulledAt.Add(-xeroPullOverlap)
+	}
+	started := time.Now()
+
+	for page := 1; ; page++ {
+		invoices, err := s.client.Invoices(ctx, since, page)
+		if err != nil {
+			return fmt.Errorf("pulling invoices: %w", err)
+		}
+
+		for _, xinv := range invoices {
+			updated, err := s.applyXeroInvoice(s.db.WithContext(ctx), xinv)
+			if err != nil {
+				return fmt.Errorf("applying xero invoice %s: %w", xinv.InvoiceID, err)
+			}
+			if updated {
+				result.InvoicesUpdated++
+			}
+		}
+
+		if len(invoices) < xero.PageSize {
+			break
+		}
+	}
+
+	return s.db.WithContext(ctx).Model(conn).Update("last_pulled_at", started).Error
+}
+
+// applyXeroInvoice copies the status of an invoice in Xero to the app's
+// copy. Invoices raised directly in Xero are not imported. A state older
+// than the last one applied is ignored, so pulls and webhook events can
+// arrive in any order. It reports whether the app's invoice changed.
+func (s *XeroSyncService) applyXeroInvoice(db *gorm.DB, xinv xero.Invoice) (bool, error) {
+	updated := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var inv models.Invoice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("xero_invoice_id = ?", xinv.InvoiceID).First(&inv).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var xeroUpdatedAt *time.Time
+		if xinv.UpdatedDateUTC != nil && !xinv.UpdatedDateUTC.IsZero() {
+			xeroUpdatedAt = &xinv.UpdatedDateUTC.Time
+			if inv.XeroUpdatedAt != nil && xeroUpdatedAt.Before(*inv.XeroUpdatedAt) {
+				return nil
+			}
+		}
+
+		status := invoiceStatusFromXero(inv.Status, xinv.Status)
+		previous := inv.Status
+
+		changes := map[string]any{"xero_synced_at": time.Now()}
+		if xeroUpdatedAt != nil {
+			changes["xero_updated_at"] = *xeroUpdatedAt
+		}
+		if status != previous {
+			changes["status"] = status
+			if status != models.InvoiceStatusPaid {
+				changes["paid_date"] = nil
+			}
+			updated = true
+		}
+		if err := tx.Model(&inv).Updates(changes).Error; err != nil {
+			return err
+		}
+
+		// Paying off or reopening an invoice in Xero moves its balance
+		switch {
+		case status == models.InvoiceStatusPaid && previous != models.InvoiceStatusPaid:
+			paidAt := time.Now()
+			if xinv.FullyPaidOnDate != nil && !xinv.FullyPaidOnDate.IsZero() {
+				paidAt = xinv.FullyPaidOnDate.Time
+			}
+			return settleFromXero(tx, &inv, money.Zero(inv.Currency), paidAt)
+		case previous == models.InvoiceStatusPaid && status != models.InvoiceStatusPaid && status != models.InvoiceStatusCancelled:
+			return settleFromXero(tx, &inv, money.FromFloat(xinv.AmountDue, inv.Currency), time.Now())
+		}
+		return nil
+	})
+
+	return updated, err
+}
+
+// settleFromXero brings a locked invoice's balance to the amount Xero
+// reports is due. Payments made straight into Xero have no payment in the
+// app, so they are recorded as a single external payment for the
+// difference, replacing any recorded before, and the balance is then
+// settled from the invoice's payments as usual.
+func settleFromXero(tx *gorm.DB, inv *models.Invoice, xeroDue money.Money, at time.Time) error {
+	if err := tx.Model(&models.Payment{}).
+		Where("invoice_id = ? AND payment_method = ? AND status = ?",
+			inv.ID, models.PaymentMethodXero, models.PaymentStatusCompleted).
+		Update("status", models.PaymentStatusCancelled).Error; err != nil {
+		return err
+	}
+	if err := settleInvoice(tx, inv, at); err != nil {
+		return err
+	}
+
+	external := inv.AmountDue.Sub(xeroDue)
+	if !external.IsPositive() {
+		return nil
+	}
+	paidAt := at
+	if err := tx.Create(&models.Payment{
+		InvoiceID:     inv.ID,
+		UserID:        inv.UserID,
+		Currency:      inv.Currency,
+		Amount:        external,
+		PaymentMethod: models.PaymentMethodXero,
+		Status:        models.PaymentStatusCompleted,
+		PaidAt:        &paidAt,
+	}).Error; err != nil {
+		return err
+	}
+	return settleInvoice(tx, inv, at)
+}
+
+// invoiceStatusFromXero maps a Xero invoice status onto the app's. Xero is
+// the source of truth for payment; overdue is left to the app because Xero
+// has no such status.
+func invoiceStatusFromXero(current models.InvoiceStatus, xeroStatus string) models.InvoiceStatus {
+	switch xeroStatus {
+	case xero.InvoiceStatusPaid:
+		return models.InvoiceStatusPaid
+	case xero.InvoiceStatusVoided, xero.InvoiceStatusDeleted:
+		return models.InvoiceStatusCancelled
+	case xero.InvoiceStatusAuthorised, xero.InvoiceStatusSubmitted:
+		// A payment removed in Xero reopens the invoice
+		if current == models.InvoiceStatusPaid || current == models.InvoiceStatusDraft {
+			return models.InvoiceStatusSent
+		}
+	}
+	return current
+}
+
+// xeroInvoice converts an invoice for pushing, with its lines loaded. The
+// first line is the amount it was raised for. Amounts include GST.
+func (s *XeroSyncService) xeroInvoice(inv *models.Invoice) xero.Invoice {
+	name := strings.TrimSpace(inv.User.FirstName + " " + inv.User.LastName)
+	if name == "" {
+		name = inv.User.Email
+	}
+
+	description := inv.Description
+	if description == "" {
+		description = inv.InvoiceNumber
+	}
+
+	raised := inv.Amount
+	for _, line := range inv.Lines {
+		raised = raised.Sub(line.Amount)
+	}
+	lineItems := []xero.LineItem{{
+		Description: description,
+		Quantity:    1,
+		UnitAmount:  raised.Float64(),
+		AccountCode: s.accounts.Sales,
+	}}
+	for _, line := range inv.Lines {
+		lineItems = append(lineItems, xero.LineItem{
+			Description: line.Description,
+			Quantity:    1,
+			UnitAmount:  line.Amount.Float64(),
+			AccountCode: s.accounts.Sales,
+		})
+	}
+
+	return xero.Invoice{
+		InvoiceID:       inv.XeroInvoiceID,
+		Type:            xero.InvoiceTypeReceivable,
+		Contact:         xero.Contact{Name: name, EmailAddress: inv.User.Email},
+		InvoiceNumber:   inv.InvoiceNumber,
+		Reference:       inv.Yacht.Name,
+		CurrencyCode:    string(inv.Currency),
+		Date:            xero.NewDate(inv.IssuedDate),
+		DueDate:         xero.NewDate(inv.DueDate),
+		Status:          xero.InvoiceStatusAuthorised,
+		LineAmountTypes: xero.LineAmountsInclusive,
+		LineItems:       lineItems,
+	}
+}
+
+// xeroPayment converts a payment for pushing
+func (s *XeroSyncService) xeroPayment(payment *models.Payment) xero.Payment {
+	date := payment.CreatedAt
+	if payment.PaidAt != nil {
+		date = *payment.PaidAt
+	}
+
+	reference := "YachtLife " + payment.ID.String()
+	if payment.StripePaymentID != "" {
+		reference = "Stripe " + payment.StripePaymentID
+	}
+
+	return xero.Payment{
+		Invoice:   xero.PaymentInvoice{InvoiceID: payment.Invoice.XeroInvoiceID},
+		Account:   xero.Account{Code: s.accounts.Payments},
+		Date:      xero.NewDate(date),
+		Amount:    payment.Amount.Float64(),
+		Reference: reference,
+	}
+}
+
+// connection returns the connected organisation
+func (s *XeroSyncService) connection(tx *gorm.DB) (*models.XeroConnection, error) {
+	var conn models.XeroConnection
+	if err := tx.Order("created_at ASC").First(&conn).Error; err != nil {
+		return nil, translateNotFound(err, ErrXeroNotConnected)
+	}
+	return &conn, nil
+}
+
+func (s *XeroSyncService) signState(payload string) string {
+	mac := hmac.New(sha256.New, s.stateSecret)
+	mac.Write([]byte("xero-connect|" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyState checks a connection request's state and returns the manager
+// who made it
+func (s *XeroSyncService) verifyState(state string, now time.Time) (uuid.UUID, error) {
+	encoded, signature, ok := strings.Cut(state, ".")
+	if !ok {
+		return uuid.Nil, ErrInvalidXeroState
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return uuid.Nil, ErrInvalidXeroState
+	}
+	payload := string(raw)
+	if !hmac.Equal([]byte(signature), []byte(s.signState(payload))) {
+		return uuid.Nil, ErrInvalidXeroState
+	}
+
+	user, expiry, ok := strings.Cut(payload, "|")
+	if !ok {
+		return uuid.Nil, ErrInvalidXeroState
+	}
+	expires, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || now.Unix() > expires {
+		return uuid.Nil, ErrInvalidXeroState
+	}
+	userID, err := uuid.Parse(user)
+	if err != nil {
+		return uuid.Nil, ErrInvalidXeroState
+	}
+	return userID, nil
+}
+
+// xeroTokenStore keeps the Xero token in the single xero_connections row.
+// Updates lock the row, so servers sharing the database refresh one at a
+// time.
+type xeroTokenStore struct {
+	db *gorm.DB
+}
+
+// NewXeroTokenStore creates a token store backed by the database
+func NewXeroTokenStore(db *gorm.DB) xero.TokenStore {
+	return &xeroTokenStore{db: db}
+}
+
+func (s *xeroTokenStore) Load(ctx context.Context) (*xero.Token, error) {
+	var conn models.XeroConnection
+	err := s.db.WithContext(ctx).Order("created_at ASC").First(&conn).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, xero.ErrNotConnected
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokenFromConnection(&conn), nil
+}
+
+func (s *xeroTokenStore) Update(ctx context.Context, fn func(*xero.Token) (*xero.Token, error)) (*xero.Token, error) {
+	var token *xero.Token
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var conn models.XeroConnection
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("created_at ASC").First(&conn).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		exists := err == nil
+
+		var current *xero.Token
+		if exists {
+			current = tokenFromConnection(&conn)
+		}
+		if token, err = fn(current); err != nil {
+			return err
+		}
+
+		conn.TenantID = token.TenantID
+		conn.TenantName = token.TenantName
+		conn.AccessToken = token.AccessToken
+		conn.RefreshToken = token.RefreshToken
+		conn.TokenExpiry = token.Expiry
+		if exists {
+			return tx.Save(&conn).Error
+		}
+		return tx.Create(&conn).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func tokenFromConnection(conn *models.XeroConnection) *xero.Token {
+	return &xero.Token{
+		AccessToken:  conn.AccessToken,
+		RefreshToken: conn.RefreshToken,
+		Expiry:       conn.TokenExpiry,
+		TenantID:     conn.TenantID,
+		TenantName:   conn.TenantName,
+	}
+}
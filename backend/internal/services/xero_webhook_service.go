@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/xero"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidXeroSignature = errors.New("invalid xero webhook signature")
+	ErrInvalidXeroWebhook   = errors.New("invalid xero webhook payload")
+)
+
+// Queued events are retried with backoff, then given up on
+const (
+	xeroWebhookMaxAttempts = 8
+	xeroWebhookBatchSize   = 100
+)
+
+// XeroWebhookService receives Xero's change notifications. Deliveries are
+// verified and queued so Xero gets its answer within its five second limit,
+// then worked through by the background scheduler.
+type XeroWebhookService struct {
+	db   *gorm.DB
+	sync *XeroSyncService
+	key  string
+}
+
+// NewXeroWebhookService creates a new Xero webhook service. key is the
+// webhook signing key from the Xero app.
+func NewXeroWebhookService(db *gorm.DB, sync *XeroSyncService, key string) *XeroWebhookService {
+	return &XeroWebhookService{
+		db:   db,
+		sync: sync,
+		key:  key,
+	}
+}
+
+// Receive verifies a delivery and queues its events, returning how many were
+// new. Xero's intent to receive check is a signed delivery with no events,
+// so it succeeds without queueing anything. Events already queued are
+// dropped.
+func (s *XeroWebhookService) Receive(body []byte, signature string, now time.Time) (int, error) {
+	if !xero.VerifySignature(body, signature, s.key) {
+		return 0, ErrInvalidXeroSignature
+	}
+	payload, err := xero.ParseWebhook(body)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidXeroWebhook, err)
+	}
+	if len(payload.Events) == 0 {
+		return 0, nil
+	}
+
+	events := make([]models.XeroWebhookEvent, 0, len(payload.Events))
+	for _, e := range payload.Events {
+		eventDate, err := e.EventDate()
+		if err != nil {
+			eventDate = now
+		}
+		events = append(events, models.XeroWebhookEvent{
+			EventKey:      xeroEventKey(e),
+			TenantID:      e.TenantID,
+			EventCategory: e.EventCategory,
+			EventType:     e.EventType,
+			ResourceID:    e.ResourceID,
+			EventDate:     eventDate,
+			Status:        models.XeroWebhookEventPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&events)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(result.RowsAffected), nil
+}
+
+// ProcessDue applies queued events that are due. Run from the background
+// scheduler.
+//
+// Events carry no data, so each is applied by fetching the invoice's
+// current state from Xero, which makes the order events are processed in
+// irrelevant. applyXeroInvoice also refuses states older than the one last
+// applied, for when a slow fetch races a newer pull.
+func (s *XeroWebhookService) ProcessDue(ctx context.Context) error {
+	now := time.Now()
+
+	var eventIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.XeroWebhookEvent{}).
+		Where("status = ? AND next_attempt_at <= ?", models.XeroWebhookEventPending, now).
+		Order("event_date ASC").
+		Limit(xeroWebhookBatchSize).
+		Pluck("id", &eventIDs).Error; err != nil {
+		return err
+	}
+
+	for _, eventID := range eventIDs {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var event models.XeroWebhookEvent
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).First(&event, eventID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if event.Status != models.XeroWebhookEventPending {
+				return nil
+			}
+
+			status, err := s.process(ctx, tx, &event)
+			event.Attempts++
+			if err != nil {
+				event.LastError = err.Error()
+				event.NextAttemptAt = time.Now().Add(xeroWebhookBackoff(event.Attempts))
+				if event.Attempts >= xeroWebhookMaxAttempts {
+					event.Status = models.XeroWebhookEventFailed
+				}
+			} else {
+				processedAt := time.Now()
+				event.Status = status
+				event.LastError = ""
+				event.ProcessedAt = &processedAt
+			}
+			return tx.Save(&event).Error
+		})
+		if err != nil {
+			return fmt.Errorf("xero webhook event %s: %w", eventID, err)
+		}
+	}
+
+	return nil
+}
+
+// process applies one event, returning whether it was processed or ignored
+func (s *XeroWebhookService) process(ctx context.Context, tx *gorm.DB, event *models.XeroWebhookEvent) (models.XeroWebhookEventStatus, error) {
+	if event.EventCategory != xero.EventCategoryInvoice {
+		return models.XeroWebhookEventIgnored, nil
+	}
+
+	conn, err := s.sync.connection(tx)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(event.TenantID, conn.TenantID) {
+		return models.XeroWebhookEventIgnored, nil
+	}
+
+	// Skip the fetch for invoices raised directly in Xero
+	var count int64
+	if err := tx.Model(&models.Invoice{}).Where("xero_invoice_id = ?", event.ResourceID).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return models.XeroWebhookEventIgnored, nil
+	}
+
+	xinv, err := s.sync.client.Invoice(ctx, event.ResourceID)
+	if errors.Is(err, xero.ErrNotFound) {
+		return models.XeroWebhookEventIgnored, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.sync.applyXeroInvoice(tx, *xinv); err != nil {
+		return "", err
+	}
+	return models.XeroWebhookEventProcessed, nil
+}
+
+// xeroEventKey identifies an event across redeliveries
+func xeroEventKey(e xero.WebhookEvent) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		e.TenantID, e.EventCategory, e.EventType, e.ResourceID, e.EventDateUTC,
+	}, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+// xeroWebhookBackoff is the wait before retrying an event, doubling from a
+// minute up to an hour
+func xeroWebhookBackoff(attempts int) time.Duration {
+	wait := time.Minute << (attempts - 1)
+	if wait > time.Hour || wait <= 0 {
+		return time.Hour
+	}
+	return wait
+}
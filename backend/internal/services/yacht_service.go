@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bitcoinbrisbane/yachtlife/internal/models"
+	"github.com/bitcoinbrisbane/yachtlife/internal/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCurrency = errors.New("invalid currency")
+	ErrCurrencyInUse   = errors.New("currency cannot be changed")
+)
+
+// YachtService manages yacht details that affect other records
+type YachtService struct {
+	db *gorm.DB
+}
+
+// NewYachtService creates a new yacht service
+func NewYachtService(db *gorm.DB) *YachtService {
+	return &YachtService{
+		db: db,
+	}
+}
+
+// SetCurrency changes the currency a yacht's syndicate is invoiced in. It
+// can only change before anything has been billed, since invoices, levies
+// and schedules keep the currency they were raised in.
+func (s *YachtService) SetCurrency(yachtID uuid.UUID, code string) (*models.Yacht, error) {
+	currency, err := money.ParseCurrency(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not supported", ErrInvalidCurrency, code)
+	}
+
+	var yacht models.Yacht
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Levies lock the yacht too, so none can be raised mid-change
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&yacht, yachtID).Error; err != nil {
+			return translateNotFound(err, ErrYachtNotFound)
+		}
+		if yacht.Currency == currency {
+			return nil
+		}
+
+		for _, billed := range []interface{}{&models.Invoice{}, &models.Levy{}, &models.RecurringInvoice{}} {
+			var count int64
+			if err := tx.Model(billed).Where("yacht_id = ?", yachtID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: the yacht has already been invoiced in %s", ErrCurrencyInUse, yacht.Currency)
+			}
+		}
+
+		yacht.Currency = currency
+		return tx.Model(&yacht).Update("currency", currency).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &yacht, nil
+}
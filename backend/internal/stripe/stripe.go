@@ -0,0 +1,293 @@
+// Package stripe is a client for the parts of the Stripe API the app takes
+// invoice payments through
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is Stripe's API
+const DefaultAPIURL = "https://api.stripe.com"
+
+// APIVersion pins the shape of API responses and webhook events
+const APIVersion = "2024-06-20"
+
+// PaymentIntent statuses
+const (
+	PaymentIntentRequiresPaymentMethod = "requires_payment_method"
+	PaymentIntentRequiresConfirmation  = "requires_confirmation"
+	PaymentIntentRequiresAction        = "requires_action"
+	PaymentIntentProcessing            = "processing"
+	PaymentIntentSucceeded             = "succeeded"
+	PaymentIntentCanceled              = "canceled"
+)
+
+// API is the part of the Stripe API the app uses. *Client implements it.
+type API interface {
+	// CreatePaymentIntent starts collecting a payment. Repeating a call with
+	// the same idempotency key returns the first intent.
+	CreatePaymentIntent(ctx context.Context, params PaymentIntentParams, idempotencyKey string) (*PaymentIntent, error)
+
+	// PaymentIntent returns an intent's current state
+	PaymentIntent(ctx context.Context, id string) (*PaymentIntent, error)
+
+	// CancelPaymentIntent stops an intent that has not been paid being used
+	CancelPaymentIntent(ctx context.Context, id string) (*PaymentIntent, error)
+
+	// CreateRefund returns some or all of a paid intent's money. Repeating a
+	// call with the same idempotency key returns the first refund.
+	CreateRefund(ctx context.Context, params RefundParams, idempotencyKey string) (*Refund, error)
+}
+
+// PaymentIntentParams describes a payment to collect
+type PaymentIntentParams struct {
+	Amount       int64  // In the currency's smallest unit, e.g. cents
+	Currency     string // Lower case ISO code, e.g. aud
+	Description  string
+	ReceiptEmail string
+	Metadata     map[string]string
+}
+
+// PaymentIntent is a payment being collected
+type PaymentIntent struct {
+	ID               string            `json:"id"`
+	Amount           int64             `json:"amount"`
+	AmountReceived   int64             `json:"amount_received"`
+	Currency         string            `json:"currency"`
+	Status           string            `json:"status"`
+	ClientSecret     string            `json:"client_secret"`
+	Description      string            `json:"description"`
+	LatestCharge     string            `json:"latest_charge"`
+	Metadata         map[string]string `json:"metadata"`
+	LastPaymentError *Error            `json:"last_payment_error"`
+	Created          int64             `json:"created"`
+}
+
+// Reusable reports whether the intent can still be paid
+func (pi *PaymentIntent) Reusable() bool {
+	switch pi.Status {
+	case PaymentIntentRequiresPaymentMethod, PaymentIntentRequiresConfirmation, PaymentIntentRequiresAction:
+		return true
+	}
+	return false
+}
+
+// Refund reasons Stripe accepts
+const (
+	RefundReasonDuplicate           = "duplicate"
+	RefundReasonFraudulent          = "fraudulent"
+	RefundReasonRequestedByCustomer = "requested_by_customer"
+)
+
+// Refund statuses
+const (
+	RefundPending   = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+	RefundCanceled  = "canceled"
+)
+
+// RefundParams describes money to return from a paid intent
+type RefundParams struct {
+	PaymentIntent string
+	Amount        int64  // In the currency's smallest unit; the whole remaining amount if zero
+	Reason        string // One of the RefundReason constants, or empty
+	Metadata      map[string]string
+}
+
+// Refund is money returned from a charge
+type Refund struct {
+	ID            string            `json:"id"`
+	Amount        int64             `json:"amount"`
+	Currency      string            `json:"currency"`
+	PaymentIntent string            `json:"payment_intent"`
+	Charge        string            `json:"charge"`
+	Status        string            `json:"status"`
+	Reason        string            `json:"reason"`
+	Metadata      map[string]string `json:"metadata"`
+	Created       int64             `json:"created"`
+}
+
+// Error is an error response from Stripe, or the reason a payment failed
+type Error struct {
+	StatusCode  int    `json:"-"`
+	Type        string `json:"type"` // e.g. card_error, invalid_request_error
+	Code        string `json:"code"`
+	DeclineCode string `json:"decline_code"`
+	Message     string `json:"message"`
+	Param       string `json:"param"`
+}
+
+func (e *Error) Error() string {
+	msg := "stripe:"
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" %d", e.StatusCode)
+	}
+	if e.Type != "" {
+		msg += " " + e.Type
+	}
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Config identifies the account to Stripe. APIURL defaults to Stripe's and
+// is overridden to point at a stub in tests.
+type Config struct {
+	SecretKey string
+	APIURL    string
+}
+
+// Client calls the Stripe API
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+}
+
+// NewClient creates a new client. A nil httpClient uses one with a 30
+// second timeout.
+func NewClient(cfg Config, httpClient *http.Client) *Client {
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultAPIURL
+	}
+	cfg.APIURL = strings.TrimSuffix(cfg.APIURL, "/")
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Client{
+		cfg:        cfg,
+		httpClient: httpClient,
+	}
+}
+
+// CreatePaymentIntent starts collecting a payment by any method enabled on
+// the account, including cards and Apple Pay
+func (c *Client) CreatePaymentIntent(ctx context.Context, params PaymentIntentParams, idempotencyKey string) (*PaymentIntent, error) {
+	form := url.Values{
+		"amount":                             {fmt.Sprintf("%d", params.Amount)},
+		"currency":                           {params.Currency},
+		"automatic_payment_methods[enabled]": {"true"},
+	}
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	if params.ReceiptEmail != "" {
+		form.Set("receipt_email", params.ReceiptEmail)
+	}
+	setMetadata(form, params.Metadata)
+
+	var pi PaymentIntent
+	if err := c.do(ctx, http.MethodPost, "/v1/payment_intents", form, idempotencyKey, &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// PaymentIntent returns an intent's current state
+func (c *Client) PaymentIntent(ctx context.Context, id string) (*PaymentIntent, error) {
+	var pi PaymentIntent
+	if err := c.do(ctx, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(id), nil, "", &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// CancelPaymentIntent cancels an intent that has not been paid
+func (c *Client) CancelPaymentIntent(ctx context.Context, id string) (*PaymentIntent, error) {
+	var pi PaymentIntent
+	if err := c.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(id)+"/cancel", url.Values{}, "", &pi); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// CreateRefund refunds a paid intent's charge
+func (c *Client) CreateRefund(ctx context.Context, params RefundParams, idempotencyKey string) (*Refund, error) {
+	form := url.Values{"payment_intent": {params.PaymentIntent}}
+	if params.Amount > 0 {
+		form.Set("amount", fmt.Sprintf("%d", params.Amount))
+	}
+	if params.Reason != "" {
+		form.Set("reason", params.Reason)
+	}
+	setMetadata(form, params.Metadata)
+
+	var refund Refund
+	if err := c.do(ctx, http.MethodPost, "/v1/refunds", form, idempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// setMetadata adds metadata fields in a stable order
+func setMetadata(form url.Values, metadata map[string]string) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		form.Set("metadata["+key+"]", metadata[key])
+	}
+}
+
+// do makes a form encoded request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, form url.Values, idempotencyKey string, out any) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.APIURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.cfg.SecretKey)
+	req.Header.Set("Stripe-Version", APIVersion)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("stripe: reading response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var parsed struct {
+			Error *Error `json:"error"`
+		}
+		if json.Unmarshal(raw, &parsed) != nil || parsed.Error == nil {
+			return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(raw))}
+		}
+		parsed.Error.StatusCode = resp.StatusCode
+		return parsed.Error
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("stripe: decoding response: %w", err)
+	}
+	return nil
+}
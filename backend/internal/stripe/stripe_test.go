@@ -0,0 +1,312 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubStripe is a local stand-in for Stripe's PaymentIntents API
+type stubStripe struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu         sync.Mutex
+	intents    map[string]*PaymentIntent
+	refunds    map[string]*Refund
+	refunded   map[string]int64 // By intent
+	idempotent map[string]string
+}
+
+func newStubStripe(t *testing.T) *stubStripe {
+	s := &stubStripe{
+		t:          t,
+		intents:    map[string]*PaymentIntent{},
+		refunds:    map[string]*Refund{},
+		refunded:   map[string]int64{},
+		idempotent: map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/payment_intents", s.authorised(s.handleCreate))
+	mux.HandleFunc("GET /v1/payment_intents/{id}", s.authorised(s.handleGet))
+	mux.HandleFunc("POST /v1/payment_intents/{id}/cancel", s.authorised(s.handleCancel))
+	mux.HandleFunc("POST /v1/refunds", s.authorised(s.handleRefund))
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *stubStripe) client() *Client {
+	return NewClient(Config{SecretKey: "sk_test_123", APIURL: s.server.URL}, s.server.Client())
+}
+
+func (s *stubStripe) authorised(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk_test_123" {
+			writeError(w, http.StatusUnauthorized, Error{Type: "invalid_request_error", Message: "Invalid API Key provided"})
+			return
+		}
+		assert.Equal(s.t, APIVersion, r.Header.Get("Stripe-Version"))
+		next(w, r)
+	}
+}
+
+func (s *stubStripe) handleCreate(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.Header.Get("Idempotency-Key")
+	if id, ok := s.idempotent[key]; ok && key != "" {
+		writeJSON(w, http.StatusOK, s.intents[id])
+		return
+	}
+
+	require.NoError(s.t, r.ParseForm())
+	amount, err := strconv.ParseInt(r.PostForm.Get("amount"), 10, 64)
+	if err != nil || amount < 50 {
+		writeError(w, http.StatusBadRequest, Error{
+			Type: "invalid_request_error", Code: "amount_too_small", Param: "amount",
+			Message: "Amount must be at least $0.50 aud",
+		})
+		return
+	}
+	assert.Equal(s.t, "true", r.PostForm.Get("automatic_payment_methods[enabled]"))
+
+	id := fmt.Sprintf("pi_%d", len(s.intents)+1)
+	pi := &PaymentIntent{
+		ID:           id,
+		Amount:       amount,
+		Currency:     r.PostForm.Get("currency"),
+		Status:       PaymentIntentRequiresPaymentMethod,
+		ClientSecret: id + "_secret_abc",
+		Description:  r.PostForm.Get("description"),
+		Metadata:     map[string]string{},
+	}
+	for field, values := range r.PostForm {
+		if key, ok := strings.CutPrefix(field, "metadata["); ok {
+			pi.Metadata[strings.TrimSuffix(key, "]")] = values[0]
+		}
+	}
+	s.intents[id] = pi
+	if key != "" {
+		s.idempotent[key] = id
+	}
+	writeJSON(w, http.StatusOK, pi)
+}
+
+func (s *stubStripe) handleGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pi, ok := s.intents[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, Error{Type: "invalid_request_error", Code: "resource_missing", Message: "No such payment_intent"})
+		return
+	}
+	writeJSON(w, http.StatusOK, pi)
+}
+
+func (s *stubStripe) handleCancel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pi, ok := s.intents[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, Error{Type: "invalid_request_error", Code: "resource_missing", Message: "No such payment_intent"})
+		return
+	}
+	pi.Status = PaymentIntentCanceled
+	writeJSON(w, http.StatusOK, pi)
+}
+
+// pay completes an intent as if the customer had paid it
+func (s *stubStripe) pay(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pi := s.intents[id]
+	pi.Status = PaymentIntentSucceeded
+	pi.AmountReceived = pi.Amount
+	pi.LatestCharge = "ch_" + strings.TrimPrefix(id, "pi_")
+}
+
+func (s *stubStripe) handleRefund(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.Header.Get("Idempotency-Key")
+	if id, ok := s.idempotent[key]; ok && key != "" {
+		writeJSON(w, http.StatusOK, s.refunds[id])
+		return
+	}
+
+	require.NoError(s.t, r.ParseForm())
+	pi, ok := s.intents[r.PostForm.Get("payment_intent")]
+	if !ok || pi.Status != PaymentIntentSucceeded {
+		writeError(w, http.StatusBadRequest, Error{
+			Type: "invalid_request_error", Code: "charge_not_refundable", Message: "This PaymentIntent has not been paid",
+		})
+		return
+	}
+	remaining := pi.AmountReceived - s.refunded[pi.ID]
+	amount := remaining
+	if value := r.PostForm.Get("amount"); value != "" {
+		amount, _ = strconv.ParseInt(value, 10, 64)
+	}
+	if amount <= 0 || amount > remaining {
+		writeError(w, http.StatusBadRequest, Error{
+			Type: "invalid_request_error", Code: "amount_too_large", Param: "amount",
+			Message: "Refund amount is greater than unrefunded amount on charge",
+		})
+		return
+	}
+
+	id := fmt.Sprintf("re_%d", len(s.refunds)+1)
+	refund := &Refund{
+		ID:            id,
+		Amount:        amount,
+		Currency:      pi.Currency,
+		PaymentIntent: pi.ID,
+		Charge:        pi.LatestCharge,
+		Status:        RefundSucceeded,
+		Reason:        r.PostForm.Get("reason"),
+		Metadata:      map[string]string{},
+	}
+	for field, values := range r.PostForm {
+		if key, ok := strings.CutPrefix(field, "metadata["); ok {
+			refund.Metadata[strings.TrimSuffix(key, "]")] = values[0]
+		}
+	}
+	s.refunds[id] = refund
+	s.refunded[pi.ID] += amount
+	if key != "" {
+		s.idempotent[key] = id
+	}
+	writeJSON(w, http.StatusOK, refund)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, e Error) {
+	writeJSON(w, status, map[string]Error{"error": e})
+}
+
+// TestCreatePaymentIntent tests creating, fetching and cancelling an intent
+func TestCreatePaymentIntent(t *testing.T) {
+	stub := newStubStripe(t)
+	client := stub.client()
+	ctx := context.Background()
+
+	params := PaymentIntentParams{
+		Amount:      125050,
+		Currency:    "aud",
+		Description: "YL-2026-001",
+		Metadata:    map[string]string{"invoice_id": "inv-1", "payment_id": "pay-1"},
+	}
+	pi, err := client.CreatePaymentIntent(ctx, params, "payment-pay-1")
+	require.NoError(t, err)
+	assert.Equal(t, "pi_1", pi.ID)
+	assert.Equal(t, int64(125050), pi.Amount)
+	assert.Equal(t, "pi_1_secret_abc", pi.ClientSecret)
+	assert.Equal(t, "inv-1", pi.Metadata["invoice_id"])
+	assert.True(t, pi.Reusable())
+
+	// A retried request returns the same intent
+	again, err := client.CreatePaymentIntent(ctx, params, "payment-pay-1")
+	require.NoError(t, err)
+	assert.Equal(t, pi.ID, again.ID)
+	assert.Len(t, stub.intents, 1)
+
+	fetched, err := client.PaymentIntent(ctx, pi.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "YL-2026-001", fetched.Description)
+
+	canceled, err := client.CancelPaymentIntent(ctx, pi.ID)
+	require.NoError(t, err)
+	assert.Equal(t, PaymentIntentCanceled, canceled.Status)
+	assert.False(t, canceled.Reusable())
+}
+
+// TestCreateRefund tests partly and then fully refunding a paid intent
+func TestCreateRefund(t *testing.T) {
+	stub := newStubStripe(t)
+	client := stub.client()
+	ctx := context.Background()
+
+	pi, err := client.CreatePaymentIntent(ctx, PaymentIntentParams{Amount: 100000, Currency: "aud"}, "")
+	require.NoError(t, err)
+
+	// Nothing can be refunded before the intent is paid
+	_, err = client.CreateRefund(ctx, RefundParams{PaymentIntent: pi.ID}, "")
+	var stripeErr *Error
+	require.ErrorAs(t, err, &stripeErr)
+	assert.Equal(t, "charge_not_refundable", stripeErr.Code)
+
+	stub.pay(pi.ID)
+
+	params := RefundParams{
+		PaymentIntent: pi.ID,
+		Amount:        40000,
+		Reason:        RefundReasonRequestedByCustomer,
+		Metadata:      map[string]string{"payment_id": "pay-1"},
+	}
+	refund, err := client.CreateRefund(ctx, params, "refund-pay-1-0")
+	require.NoError(t, err)
+	assert.Equal(t, int64(40000), refund.Amount)
+	assert.Equal(t, RefundSucceeded, refund.Status)
+	assert.Equal(t, "ch_1", refund.Charge)
+	assert.Equal(t, RefundReasonRequestedByCustomer, refund.Reason)
+	assert.Equal(t, "pay-1", refund.Metadata["payment_id"])
+
+	// A retried request returns the same refund
+	again, err := client.CreateRefund(ctx, params, "refund-pay-1-0")
+	require.NoError(t, err)
+	assert.Equal(t, refund.ID, again.ID)
+	assert.Equal(t, int64(40000), stub.refunded[pi.ID])
+
+	// No amount refunds the rest, and no more can be refunded after that
+	rest, err := client.CreateRefund(ctx, RefundParams{PaymentIntent: pi.ID}, "refund-pay-1-40000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(60000), rest.Amount)
+
+	_, err = client.CreateRefund(ctx, RefundParams{PaymentIntent: pi.ID, Amount: 1}, "")
+	require.ErrorAs(t, err, &stripeErr)
+	assert.Equal(t, "amount_too_large", stripeErr.Code)
+}
+
+// TestStripeErrors tests decoding Stripe's error responses
+func TestStripeErrors(t *testing.T) {
+	stub := newStubStripe(t)
+	ctx := context.Background()
+
+	_, err := stub.client().CreatePaymentIntent(ctx, PaymentIntentParams{Amount: 10, Currency: "aud"}, "")
+	var stripeErr *Error
+	require.ErrorAs(t, err, &stripeErr)
+	assert.Equal(t, http.StatusBadRequest, stripeErr.StatusCode)
+	assert.Equal(t, "amount_too_small", stripeErr.Code)
+	assert.Equal(t, "amount", stripeErr.Param)
+
+	_, err = stub.client().PaymentIntent(ctx, "pi_missing")
+	require.ErrorAs(t, err, &stripeErr)
+	assert.Equal(t, http.StatusNotFound, stripeErr.StatusCode)
+
+	_, err = NewClient(Config{SecretKey: "sk_wrong", APIURL: stub.server.URL}, stub.server.Client()).
+		PaymentIntent(ctx, "pi_1")
+	require.ErrorAs(t, err, &stripeErr)
+	assert.Equal(t, http.StatusUnauthorized, stripeErr.StatusCode)
+	assert.Contains(t, err.Error(), "Invalid API Key")
+}
@@ -0,0 +1,92 @@
+package stripe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sign builds a Stripe-Signature header the way Stripe does
+func sign(payload []byte, secret string, at time.Time) string {
+	timestamp := fmt.Sprintf("%d", at.Unix())
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(payload)))
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestVerifySignature tests checking the Stripe-Signature header
+func TestVerifySignature(t *testing.T) {
+	secret := "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded"}`)
+	sentAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	header := sign(payload, secret, sentAt)
+
+	assert.NoError(t, VerifySignature(payload, header, secret, sentAt.Add(time.Minute), DefaultTolerance))
+
+	// One of several signatures matching is enough, as when rolling secrets
+	_, current, _ := strings.Cut(header, ",")
+	rolled := sign(payload, "whsec_old", sentAt) + "," + current
+	assert.NoError(t, VerifySignature(payload, rolled, secret, sentAt, DefaultTolerance))
+
+	assert.ErrorIs(t, VerifySignature(payload, header, "whsec_other", sentAt, DefaultTolerance), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature(append(payload, ' '), header, secret, sentAt, DefaultTolerance), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature(payload, "", secret, sentAt, DefaultTolerance), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature(payload, "v1=abc", secret, sentAt, DefaultTolerance), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature(payload, header, "", sentAt, DefaultTolerance), ErrInvalidSignature)
+
+	// A replayed delivery is rejected once it is outside the tolerance
+	assert.ErrorIs(t, VerifySignature(payload, header, secret, sentAt.Add(6*time.Minute), DefaultTolerance), ErrSignatureExpired)
+	assert.ErrorIs(t, VerifySignature(payload, header, secret, sentAt.Add(-6*time.Minute), DefaultTolerance), ErrSignatureExpired)
+}
+
+// TestParseEvent tests decoding webhook events and their objects
+func TestParseEvent(t *testing.T) {
+	event, err := ParseEvent([]byte(`{
+		"id": "evt_1",
+		"object": "event",
+		"type": "payment_intent.succeeded",
+		"created": 1772355600,
+		"livemode": false,
+		"data": {"object": {
+			"id": "pi_1",
+			"object": "payment_intent",
+			"amount": 125050,
+			"amount_received": 125050,
+			"currency": "aud",
+			"status": "succeeded",
+			"latest_charge": "ch_1",
+			"metadata": {"payment_id": "pay-1"}
+		}}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "evt_1", event.ID)
+	assert.Equal(t, EventPaymentIntentSucceeded, event.Type)
+	assert.Equal(t, time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), event.CreatedAt())
+
+	pi, err := event.PaymentIntent()
+	require.NoError(t, err)
+	assert.Equal(t, "pi_1", pi.ID)
+	assert.Equal(t, int64(125050), pi.AmountReceived)
+	assert.Equal(t, "pay-1", pi.Metadata["payment_id"])
+
+	event, err = ParseEvent([]byte(`{"id":"evt_2","type":"charge.refunded","created":1772355600,
+		"data":{"object":{"id":"ch_1","amount":125050,"amount_refunded":50000,"payment_intent":"pi_1","refunded":false}}}`))
+	require.NoError(t, err)
+	ch, err := event.Charge()
+	require.NoError(t, err)
+	assert.Equal(t, "pi_1", ch.PaymentIntent)
+	assert.Equal(t, int64(50000), ch.AmountRefunded)
+	assert.False(t, ch.Refunded)
+
+	_, err = ParseEvent([]byte(`{"type":"charge.refunded"}`))
+	assert.Error(t, err)
+	_, err = ParseEvent([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package xero
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Access tokens are refreshed this long before they expire, so a request
+// never goes out with a token that lapses in flight
+const expiryLeeway = time.Minute
+
+// Token is an organisation's OAuth2 grant
+type Token struct {
+	AccessToken  string
+	RefreshToken string // Single use: Xero issues a new one on every refresh
+	Expiry       time.Time
+	TenantID     string // The connected organisation
+	TenantName   string
+}
+
+// Valid reports whether the access token can still be used at now
+func (t *Token) Valid(now time.Time) bool {
+	return t != nil && t.AccessToken != "" && now.Before(t.Expiry.Add(-expiryLeeway))
+}
+
+// TokenStore persists the organisation's token. Because refresh tokens are
+// single use, Update must serialise callers — across processes, not just
+// goroutines — so two servers never spend the same refresh token.
+type TokenStore interface {
+	// Load returns the stored token, or ErrNotConnected if there is none
+	Load(ctx context.Context) (*Token, error)
+
+	// Update stores the token returned by fn. fn receives the current
+	// token, or nil if there is none, and runs while other updates wait.
+	Update(ctx context.Context, fn func(current *Token) (*Token, error)) (*Token, error)
+}
+
+// MemoryTokenStore keeps the token in memory. It suits tests and single
+// process tools.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewMemoryTokenStore creates a store holding token, which may be nil
+func NewMemoryTokenStore(token *Token) *MemoryTokenStore {
+	return &MemoryTokenStore{token: token}
+}
+
+// Load returns a copy of the stored token
+func (s *MemoryTokenStore) Load(context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil {
+		return nil, ErrNotConnected
+	}
+	token := *s.token
+	return &token, nil
+}
+
+// Update replaces the stored token with fn's result
+func (s *MemoryTokenStore) Update(_ context.Context, fn func(*Token) (*Token, error)) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *Token
+	if s.token != nil {
+		token := *s.token
+		current = &token
+	}
+
+	next, err := fn(current)
+	if err != nil {
+		return nil, err
+	}
+	stored := *next
+	s.token = &stored
+	return next, nil
+}
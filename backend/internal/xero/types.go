@@ -0,0 +1,224 @@
+package xero
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Invoice types
+const (
+	InvoiceTypeReceivable = "ACCREC"
+)
+
+// Invoice statuses
+const (
+	InvoiceStatusDraft      = "DRAFT"
+	InvoiceStatusSubmitted  = "SUBMITTED"
+	InvoiceStatusAuthorised = "AUTHORISED"
+	InvoiceStatusPaid       = "PAID"
+	InvoiceStatusVoided     = "VOIDED"
+	InvoiceStatusDeleted    = "DELETED"
+)
+
+// Line amount types
+const (
+	LineAmountsInclusive = "Inclusive" // Amounts include tax
+	LineAmountsExclusive = "Exclusive"
+	LineAmountsNoTax     = "NoTax"
+)
+
+// Invoice is an accounting invoice. Fields Xero computes are only set on
+// invoices it returns.
+type Invoice struct {
+	InvoiceID       string     `json:"InvoiceID,omitempty"`
+	Type            string     `json:"Type"`
+	Contact         Contact    `json:"Contact"`
+	InvoiceNumber   string     `json:"InvoiceNumber,omitempty"`
+	Reference       string     `json:"Reference,omitempty"`
+	Date            *Date      `json:"Date,omitempty"`
+	DueDate         *Date      `json:"DueDate,omitempty"`
+	Status          string     `json:"Status,omitempty"`
+	LineAmountTypes string     `json:"LineAmountTypes,omitempty"`
+	LineItems       []LineItem `json:"LineItems,omitempty"`
+	CurrencyCode    string     `json:"CurrencyCode,omitempty"`
+
+	Total           float64 `json:"Total,omitempty"`
+	AmountDue       float64 `json:"AmountDue,omitempty"`
+	AmountPaid      float64 `json:"AmountPaid,omitempty"`
+	FullyPaidOnDate *Date   `json:"FullyPaidOnDate,omitempty"`
+	UpdatedDateUTC  *Date   `json:"UpdatedDateUTC,omitempty"`
+}
+
+// Contact is the customer an invoice is addressed to. Without a ContactID,
+// Xero matches an existing contact by name or creates one.
+type Contact struct {
+	ContactID    string `json:"ContactID,omitempty"`
+	Name         string `json:"Name,omitempty"`
+	EmailAddress string `json:"EmailAddress,omitempty"`
+}
+
+// LineItem is one line of an invoice
+type LineItem struct {
+	Description string  `json:"Description"`
+	Quantity    float64 `json:"Quantity"`
+	UnitAmount  float64 `json:"UnitAmount"`
+	AccountCode string  `json:"AccountCode,omitempty"`
+}
+
+// Payment is money received against an invoice into a bank account
+type Payment struct {
+	PaymentID string         `json:"PaymentID,omitempty"`
+	Invoice   PaymentInvoice `json:"Invoice"`
+	Account   Account        `json:"Account"`
+	Date      *Date          `json:"Date,omitempty"`
+	Amount    float64        `json:"Amount"`
+	Reference string         `json:"Reference,omitempty"`
+	Status    string         `json:"Status,omitempty"`
+}
+
+// PaymentInvoice identifies the invoice a payment is against
+type PaymentInvoice struct {
+	InvoiceID string `json:"InvoiceID"`
+}
+
+// Account identifies a chart of accounts entry by its code
+type Account struct {
+	Code string `json:"Code"`
+}
+
+// Date is a Xero date. Xero returns dates in the Microsoft JSON form
+// /Date(1573755038314+0000)/ and accepts them as ISO dates.
+type Date struct {
+	time.Time
+}
+
+// NewDate wraps t as a Date
+func NewDate(t time.Time) *Date {
+	return &Date{t}
+}
+
+var msDatePattern = regexp.MustCompile(`^/Date\((-?\d+)([+-]\d{4})?\)/$`)
+
+// MarshalJSON renders the date as YYYY-MM-DD
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Format("2006-01-02"))
+}
+
+// UnmarshalJSON accepts Microsoft JSON dates and ISO dates and date-times
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		if string(data) == "null" {
+			d.Time = time.Time{}
+			return nil
+		}
+		return err
+	}
+	if s == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	// The offset is only the zone the date was written in; the
+	// milliseconds are always since the Unix epoch in UTC
+	if m := msDatePattern.FindStringSubmatch(s); m != nil {
+		ms, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("xero: invalid date %q", s)
+		}
+		d.Time = time.UnixMilli(ms).UTC()
+		return nil
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04:05", time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			d.Time = t.UTC()
+			return nil
+		}
+	}
+	return fmt.Errorf("xero: invalid date %q", s)
+}
+
+// APIError is an error response from Xero
+type APIError struct {
+	StatusCode int
+	Type       string   // e.g. ValidationException, or an OAuth2 error code
+	Message    string   // Summary from Xero
+	Details    []string // Validation messages for the record
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("xero: %d", e.StatusCode)
+	if e.Type != "" {
+		msg += " " + e.Type
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if len(e.Details) > 0 {
+		msg += ": " + strings.Join(e.Details, "; ")
+	}
+	return msg
+}
+
+// IsValidationError reports whether Xero rejected the record itself, as
+// opposed to the request failing. Retrying a rejected record unchanged
+// fails the same way.
+func IsValidationError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Type == "ValidationException"
+}
+
+// errorBody covers the shapes of Xero's accounting, identity and
+// gateway error responses
+type errorBody struct {
+	Type     string `json:"Type"`
+	Message  string `json:"Message"`
+	Title    string `json:"Title"`
+	Detail   string `json:"Detail"`
+	Elements []struct {
+		ValidationErrors []struct {
+			Message string `json:"Message"`
+		} `json:"ValidationErrors"`
+	} `json:"Elements"`
+
+	OAuthError       string `json:"error"`
+	OAuthDescription string `json:"error_description"`
+}
+
+func parseAPIError(status int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status}
+
+	var parsed errorBody
+	if json.Unmarshal(body, &parsed) != nil {
+		apiErr.Message = strings.TrimSpace(string(body))
+		return apiErr
+	}
+
+	switch {
+	case parsed.OAuthError != "":
+		apiErr.Type = parsed.OAuthError
+		apiErr.Message = parsed.OAuthDescription
+	case parsed.Type != "":
+		apiErr.Type = parsed.Type
+		apiErr.Message = parsed.Message
+	default:
+		apiErr.Type = parsed.Title
+		apiErr.Message = parsed.Detail
+	}
+	for _, element := range parsed.Elements {
+		for _, v := range element.ValidationErrors {
+			apiErr.Details = append(apiErr.Details, v.Message)
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,74 @@
+package xero
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the webhook payload's signature
+const SignatureHeader = "X-Xero-Signature"
+
+// Webhook event categories and types
+const (
+	EventCategoryInvoice = "INVOICE"
+	EventCategoryContact = "CONTACT"
+
+	EventTypeCreate = "CREATE"
+	EventTypeUpdate = "UPDATE"
+)
+
+// WebhookPayload is a batch of webhook events. Xero's intent to receive
+// check sends a payload with no events.
+type WebhookPayload struct {
+	Events             []WebhookEvent `json:"events"`
+	FirstEventSequence int64          `json:"firstEventSequence"`
+	LastEventSequence  int64          `json:"lastEventSequence"`
+}
+
+// WebhookEvent says a resource changed. It carries no data; the resource
+// must be fetched for its current state.
+type WebhookEvent struct {
+	ResourceURL   string `json:"resourceUrl"`
+	ResourceID    string `json:"resourceId"`
+	EventDateUTC  string `json:"eventDateUtc"`
+	EventType     string `json:"eventType"`
+	EventCategory string `json:"eventCategory"`
+	TenantID      string `json:"tenantId"`
+	TenantType    string `json:"tenantType"`
+}
+
+// EventDate parses the event's time. Xero sends it without a zone.
+func (e WebhookEvent) EventDate() (time.Time, error) {
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, e.EventDateUTC); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("xero: invalid event date %q", e.EventDateUTC)
+}
+
+// VerifySignature reports whether signature is the base64 HMAC-SHA256 of
+// body under the webhook key
+func VerifySignature(body []byte, signature, key string) bool {
+	if key == "" || signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected))
+}
+
+// ParseWebhook decodes a payload whose signature has been verified
+func ParseWebhook(body []byte) (*WebhookPayload, error) {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("xero: invalid webhook payload: %w", err)
+	}
+	return &payload, nil
+}
@@ -0,0 +1,59 @@
+package xero
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifySignature tests checking the x-xero-signature header
+func TestVerifySignature(t *testing.T) {
+	key := "webhook-key"
+	body := []byte(`{"events":[],"firstEventSequence":0,"lastEventSequence":0,"entropy":"S0m3r4Nd0mt3xt"}`)
+	signature := "wcOLdy5OczU/5w5H8lzkPt8JdwIxZ8V5IrhHyLlMz3M="
+
+	assert.True(t, VerifySignature(body, signature, key))
+	assert.False(t, VerifySignature(body, signature, "other-key"))
+	assert.False(t, VerifySignature(append(body, ' '), signature, key))
+	assert.False(t, VerifySignature(body, "", key))
+	assert.False(t, VerifySignature(body, signature, ""))
+}
+
+// TestParseWebhook tests decoding webhook events
+func TestParseWebhook(t *testing.T) {
+	body := []byte(`{
+		"events": [{
+			"resourceUrl": "https://api.xero.com/api.xro/2.0/Invoices/717f2bfc-c6d4-41fd-b238-3f2f0c0cf777",
+			"resourceId": "717f2bfc-c6d4-41fd-b238-3f2f0c0cf777",
+			"eventDateUtc": "2026-03-01T01:15:39.902",
+			"eventType": "UPDATE",
+			"eventCategory": "INVOICE",
+			"tenantId": "c2cc9b6e-9458-4c7d-93cc-f02b81b0594f",
+			"tenantType": "ORGANISATION"
+		}],
+		"firstEventSequence": 1,
+		"lastEventSequence": 1,
+		"entropy": "YSXBTSKFOFKHJNRVEKRO"
+	}`)
+
+	payload, err := ParseWebhook(body)
+	require.NoError(t, err)
+	require.Len(t, payload.Events, 1)
+
+	event := payload.Events[0]
+	assert.Equal(t, EventCategoryInvoice, event.EventCategory)
+	assert.Equal(t, EventTypeUpdate, event.EventType)
+	assert.Equal(t, "717f2bfc-c6d4-41fd-b238-3f2f0c0cf777", event.ResourceID)
+
+	date, err := event.EventDate()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, time.March, 1, 1, 15, 39, 902000000, time.UTC), date)
+
+	_, err = WebhookEvent{EventDateUTC: "yesterday"}.EventDate()
+	assert.Error(t, err)
+
+	_, err = ParseWebhook([]byte("not json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,403 @@
+// Package xero is a client for the parts of the Xero accounting API the app
+// syncs invoices and payments through
+package xero
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotConnected is returned when no organisation has been connected, or
+// its grant has been revoked and it must be connected again
+var ErrNotConnected = errors.New("xero: not connected")
+
+// ErrNotFound is returned for a record Xero does not have
+var ErrNotFound = errors.New("xero: not found")
+
+// Default endpoints
+const (
+	DefaultAuthorizeURL = "https://login.xero.com/identity/connect/authorize"
+	DefaultTokenURL     = "https://identity.xero.com/connect/token"
+	DefaultAPIURL       = "https://api.xero.com"
+)
+
+// Scopes requested when connecting an organisation
+const Scopes = "openid offline_access accounting.transactions accounting.contacts"
+
+// PageSize is the number of records Xero returns per page
+const PageSize = 100
+
+// API is the part of the Xero API the sync uses. *Client implements it.
+type API interface {
+	// AuthCodeURL is the URL to send a user to to connect an organisation
+	AuthCodeURL(state string) string
+
+	// Exchange completes a connection with the code Xero redirected back
+	// with, storing the new token
+	Exchange(ctx context.Context, code string) (*Token, error)
+
+	// CreateInvoice creates an invoice. Repeating a call with the same
+	// idempotency key returns the first result rather than a duplicate.
+	CreateInvoice(ctx context.Context, invoice Invoice, idempotencyKey string) (*Invoice, error)
+
+	// UpdateInvoice replaces the details and lines of the invoice with
+	// invoice.InvoiceID, idempotently like CreateInvoice. Xero rejects
+	// changes to an invoice with payments allocated to it.
+	UpdateInvoice(ctx context.Context, invoice Invoice, idempotencyKey string) (*Invoice, error)
+
+	// CreatePayment applies a payment to an invoice, idempotently like
+	// CreateInvoice
+	CreatePayment(ctx context.Context, payment Payment, idempotencyKey string) (*Payment, error)
+
+	// Invoices returns a page of receivable invoices changed since the given
+	// time, starting from page 1. A short page is the last.
+	Invoices(ctx context.Context, modifiedSince time.Time, page int) ([]Invoice, error)
+
+	// Invoice returns an invoice's current state, or ErrNotFound
+	Invoice(ctx context.Context, invoiceID string) (*Invoice, error)
+}
+
+// Config identifies the app to Xero. The URLs default to Xero's and are
+// overridden to point at a fake server in tests.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+
+	AuthorizeURL string
+	TokenURL     string
+	APIURL       string
+}
+
+// Client calls the Xero API for the organisation whose token is in its
+// store, refreshing the token as it expires
+type Client struct {
+	cfg        Config
+	store      TokenStore
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewClient creates a new client. A nil httpClient uses one with a 30
+// second timeout.
+func NewClient(cfg Config, store TokenStore, httpClient *http.Client) *Client {
+	if cfg.AuthorizeURL == "" {
+		cfg.AuthorizeURL = DefaultAuthorizeURL
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = DefaultTokenURL
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultAPIURL
+	}
+	cfg.APIURL = strings.TrimSuffix(cfg.APIURL, "/")
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Client{
+		cfg:        cfg,
+		store:      store,
+		httpClient: httpClient,
+		now:        time.Now,
+	}
+}
+
+// AuthCodeURL is the URL to send a user to to connect an organisation
+func (c *Client) AuthCodeURL(state string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.cfg.ClientID},
+		"redirect_uri":  {c.cfg.RedirectURI},
+		"scope":         {Scopes},
+		"state":         {state},
+	}
+	return c.cfg.AuthorizeURL + "?" + q.Encode()
+}
+
+// Exchange swaps an authorization code for a token, looks up the
+// organisation it grants access to and stores both. Connecting replaces any
+// previous organisation.
+func (c *Client) Exchange(ctx context.Context, code string) (*Token, error) {
+	token, err := c.requestToken(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.cfg.RedirectURI},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var connections []struct {
+		TenantID   string `json:"tenantId"`
+		TenantType string `json:"tenantType"`
+		TenantName string `json:"tenantName"`
+	}
+	if err := c.send(ctx, token, http.MethodGet, c.cfg.APIURL+"/connections", nil, nil, &connections); err != nil {
+		return nil, err
+	}
+	for _, conn := range connections {
+		if conn.TenantType == "ORGANISATION" {
+			token.TenantID = conn.TenantID
+			token.TenantName = conn.TenantName
+			break
+		}
+	}
+	if token.TenantID == "" {
+		return nil, errors.New("xero: no organisation was connected")
+	}
+
+	return c.store.Update(ctx, func(*Token) (*Token, error) {
+		return token, nil
+	})
+}
+
+// CreateInvoice creates an invoice
+func (c *Client) CreateInvoice(ctx context.Context, invoice Invoice, idempotencyKey string) (*Invoice, error) {
+	var resp struct {
+		Invoices []Invoice `json:"Invoices"`
+	}
+	body := map[string][]Invoice{"Invoices": {invoice}}
+	if err := c.do(ctx, http.MethodPut, "/Invoices", nil, idempotencyKey, body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Invoices) != 1 {
+		return nil, fmt.Errorf("xero: expected 1 invoice in response, got %d", len(resp.Invoices))
+	}
+	return &resp.Invoices[0], nil
+}
+
+// UpdateInvoice replaces the details and lines of an existing invoice
+func (c *Client) UpdateInvoice(ctx context.Context, invoice Invoice, idempotencyKey string) (*Invoice, error) {
+	if invoice.InvoiceID == "" {
+		return nil, errors.New("xero: updating an invoice needs its InvoiceID")
+	}
+	var resp struct {
+		Invoices []Invoice `json:"Invoices"`
+	}
+	body := map[string][]Invoice{"Invoices": {invoice}}
+	path := "/Invoices/" + url.PathEscape(invoice.InvoiceID)
+	if err := c.do(ctx, http.MethodPost, path, nil, idempotencyKey, body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Invoices) != 1 {
+		return nil, fmt.Errorf("xero: expected 1 invoice in response, got %d", len(resp.Invoices))
+	}
+	return &resp.Invoices[0], nil
+}
+
+// CreatePayment applies a payment to an invoice
+func (c *Client) CreatePayment(ctx context.Context, payment Payment, idempotencyKey string) (*Payment, error) {
+	var resp struct {
+		Payments []Payment `json:"Payments"`
+	}
+	body := map[string][]Payment{"Payments": {payment}}
+	if err := c.do(ctx, http.MethodPut, "/Payments", nil, idempotencyKey, body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Payments) != 1 {
+		return nil, fmt.Errorf("xero: expected 1 payment in response, got %d", len(resp.Payments))
+	}
+	return &resp.Payments[0], nil
+}
+
+// Invoices returns a page of receivable invoices changed since the given
+// time. A zero time returns every invoice.
+func (c *Client) Invoices(ctx context.Context, modifiedSince time.Time, page int) ([]Invoice, error) {
+	query := url.Values{
+		"page":  {strconv.Itoa(page)},
+		"where": {`Type=="` + InvoiceTypeReceivable + `"`},
+	}
+	header := http.Header{}
+	if !modifiedSince.IsZero() {
+		header.Set("If-Modified-Since", modifiedSince.UTC().Format("2006-01-02T15:04:05"))
+	}
+
+	var resp struct {
+		Invoices []Invoice `json:"Invoices"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/Invoices?"+query.Encode(), header, "", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Invoices, nil
+}
+
+// Invoice returns an invoice's current state
+func (c *Client) Invoice(ctx context.Context, invoiceID string) (*Invoice, error) {
+	var resp struct {
+		Invoices []Invoice `json:"Invoices"`
+	}
+	err := c.do(ctx, http.MethodGet, "/Invoices/"+url.PathEscape(invoiceID), nil, "", nil, &resp)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Invoices) != 1 {
+		return nil, ErrNotFound
+	}
+	return &resp.Invoices[0], nil
+}
+
+// do calls the accounting API as the connected organisation, refreshing the
+// token and retrying once if Xero rejects it
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, idempotencyKey string, body, out any) error {
+	token, err := c.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if !token.Valid(c.now()) {
+		if token, err = c.refresh(ctx, token); err != nil {
+			return err
+		}
+	}
+
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Xero-Tenant-Id", token.TenantID)
+	if idempotencyKey != "" {
+		header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	endpoint := c.cfg.APIURL + "/api.xro/2.0" + path
+	err = c.send(ctx, token, method, endpoint, header, body, out)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		if token, err = c.refresh(ctx, token); err != nil {
+			return err
+		}
+		err = c.send(ctx, token, method, endpoint, header, body, out)
+	}
+	return err
+}
+
+// refresh replaces a stale token. If another caller has already refreshed
+// it, their token is used rather than spending the refresh token again.
+func (c *Client) refresh(ctx context.Context, stale *Token) (*Token, error) {
+	return c.store.Update(ctx, func(current *Token) (*Token, error) {
+		if current == nil || current.RefreshToken == "" {
+			return nil, ErrNotConnected
+		}
+		if current.AccessToken != stale.AccessToken && current.Valid(c.now()) {
+			return current, nil
+		}
+
+		token, err := c.requestToken(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {current.RefreshToken},
+		})
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Type == "invalid_grant" {
+			// Expired after 60 days unused, or the connection was removed
+			return nil, fmt.Errorf("%w: %v", ErrNotConnected, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		token.TenantID = current.TenantID
+		token.TenantName = current.TenantName
+		return token, nil
+	})
+}
+
+// requestToken calls the token endpoint with a grant
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.cfg.ClientID, c.cfg.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := c.roundTrip(req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.AccessToken == "" {
+		return nil, errors.New("xero: token response has no access token")
+	}
+
+	return &Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		Expiry:       c.now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// send makes an authenticated JSON request
+func (c *Client) send(ctx context.Context, token *Token, method, endpoint string, header http.Header, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.roundTrip(req, out)
+}
+
+// roundTrip sends a request and decodes a successful JSON response into out
+func (c *Client) roundTrip(req *http.Request, out any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("xero: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("xero: reading response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := parseAPIError(resp.StatusCode, body)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("xero: decoding response: %w", err)
+	}
+	return nil
+}
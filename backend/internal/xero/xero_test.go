@@ -0,0 +1,536 @@
+package xero
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeXero is a local stand-in for Xero's identity and accounting APIs
+type fakeXero struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu            sync.Mutex
+	accessTokens  map[string]bool
+	refreshTokens map[string]bool
+	issued        int
+	tokenRequests int
+	invoices      []Invoice
+	payments      []Payment
+	idempotent    map[string][]byte
+	modifiedSince []string
+}
+
+func newFakeXero(t *testing.T) *fakeXero {
+	f := &fakeXero{
+		t:             t,
+		accessTokens:  map[string]bool{},
+		refreshTokens: map[string]bool{},
+		idempotent:    map[string][]byte{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", f.handleToken)
+	mux.HandleFunc("GET /connections", f.authorised(f.handleConnections))
+	mux.HandleFunc("PUT /api.xro/2.0/Invoices", f.authorised(f.tenant(f.handleCreateInvoice)))
+	mux.HandleFunc("GET /api.xro/2.0/Invoices", f.authorised(f.tenant(f.handleListInvoices)))
+	mux.HandleFunc("GET /api.xro/2.0/Invoices/{id}", f.authorised(f.tenant(f.handleGetInvoice)))
+	mux.HandleFunc("POST /api.xro/2.0/Invoices/{id}", f.authorised(f.tenant(f.handleUpdateInvoice)))
+	mux.HandleFunc("PUT /api.xro/2.0/Payments", f.authorised(f.tenant(f.handleCreatePayment)))
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeXero) client(store TokenStore) *Client {
+	return NewClient(Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURI:  "https://app.example/xero/callback",
+		AuthorizeURL: f.server.URL + "/authorize",
+		TokenURL:     f.server.URL + "/token",
+		APIURL:       f.server.URL,
+	}, store, f.server.Client())
+}
+
+// grant issues a token pair as if the organisation had been connected
+func (f *fakeXero) grant() *Token {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	access, refresh := f.issue()
+	return &Token{AccessToken: access, RefreshToken: refresh, Expiry: time.Now().Add(30 * time.Minute), TenantID: "tenant-1"}
+}
+
+func (f *fakeXero) issue() (string, string) {
+	f.issued++
+	access := fmt.Sprintf("access-%d", f.issued)
+	refresh := fmt.Sprintf("refresh-%d", f.issued)
+	f.accessTokens[access] = true
+	f.refreshTokens[refresh] = true
+	return access, refresh
+}
+
+func (f *fakeXero) revokeAccessTokens() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accessTokens = map[string]bool{}
+}
+
+func (f *fakeXero) handleToken(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokenRequests++
+
+	if user, pass, ok := r.BasicAuth(); !ok || user != "client" || pass != "secret" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	switch r.PostFormValue("grant_type") {
+	case "authorization_code":
+		if r.PostFormValue("code") != "good-code" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+	case "refresh_token":
+		token := r.PostFormValue("refresh_token")
+		if !f.refreshTokens[token] {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(f.refreshTokens, token) // Refresh tokens are single use
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	access, refresh := f.issue()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  access,
+		"refresh_token": refresh,
+		"expires_in":    1800,
+		"token_type":    "Bearer",
+	})
+}
+
+func (f *fakeXero) authorised(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		ok := f.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		f.mu.Unlock()
+		if !ok {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"Title": "Unauthorized", "Status": 401, "Detail": "TokenExpired"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (f *fakeXero) tenant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Xero-Tenant-Id") != "tenant-1" {
+			writeJSON(w, http.StatusForbidden, map[string]any{"Title": "Forbidden", "Status": 403, "Detail": "AuthenticationUnsuccessful"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (f *fakeXero) handleConnections(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []map[string]string{
+		{"tenantId": "practice-1", "tenantType": "PRACTICEMANAGER", "tenantName": "Practice"},
+		{"tenantId": "tenant-1", "tenantType": "ORGANISATION", "tenantName": "Sea Breeze Syndicate"},
+	})
+}
+
+// replay answers a repeated idempotency key with the first response
+func (f *fakeXero) replay(w http.ResponseWriter, r *http.Request) bool {
+	key := r.Header.Get("Idempotency-Key")
+	if body, ok := f.idempotent[key]; ok && key != "" {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+		return true
+	}
+	return false
+}
+
+func (f *fakeXero) remember(w http.ResponseWriter, r *http.Request, v any) {
+	body, _ := json.Marshal(v)
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		f.idempotent[key] = body
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (f *fakeXero) handleCreateInvoice(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.replay(w, r) {
+		return
+	}
+
+	var req struct{ Invoices []Invoice }
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+	require.Len(f.t, req.Invoices, 1)
+	inv := req.Invoices[0]
+
+	if inv.Contact.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"ErrorNumber": 10,
+			"Type":        "ValidationException",
+			"Message":     "A validation exception occurred",
+			"Elements": []map[string]any{
+				{"ValidationErrors": []map[string]string{{"Message": "A Contact must be specified for this type of transaction"}}},
+			},
+		})
+		return
+	}
+
+	inv.InvoiceID = fmt.Sprintf("xero-invoice-%d", len(f.invoices)+1)
+	inv.UpdatedDateUTC = NewDate(time.Now())
+	f.invoices = append(f.invoices, inv)
+	f.remember(w, r, map[string][]Invoice{"Invoices": {inv}})
+}
+
+func (f *fakeXero) handleListInvoices(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modifiedSince = append(f.modifiedSince, r.Header.Get("If-Modified-Since"))
+
+	assert.Equal(f.t, `Type=="ACCREC"`, r.URL.Query().Get("where"))
+	if r.URL.Query().Get("page") != "1" {
+		writeJSON(w, http.StatusOK, map[string][]Invoice{"Invoices": {}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]Invoice{"Invoices": f.invoices})
+}
+
+func (f *fakeXero) handleGetInvoice(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, inv := range f.invoices {
+		if inv.InvoiceID == r.PathValue("id") {
+			writeJSON(w, http.StatusOK, map[string][]Invoice{"Invoices": {inv}})
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]any{"Title": "Not Found", "Status": 404})
+}
+
+func (f *fakeXero) handleUpdateInvoice(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.replay(w, r) {
+		return
+	}
+
+	var req struct{ Invoices []Invoice }
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+	require.Len(f.t, req.Invoices, 1)
+	update := req.Invoices[0]
+	assert.Equal(f.t, r.PathValue("id"), update.InvoiceID)
+
+	for _, payment := range f.payments {
+		if payment.Invoice.InvoiceID == update.InvoiceID {
+			writeJSON(w, http.StatusBadRequest, map[string]any{
+				"ErrorNumber": 10,
+				"Type":        "ValidationException",
+				"Message":     "A validation exception occurred",
+				"Elements": []map[string]any{
+					{"ValidationErrors": []map[string]string{{"Message": "This document cannot be edited as it has a payment or credit note allocated to it."}}},
+				},
+			})
+			return
+		}
+	}
+
+	for i, inv := range f.invoices {
+		if inv.InvoiceID == update.InvoiceID {
+			update.UpdatedDateUTC = NewDate(time.Now())
+			f.invoices[i] = update
+			f.remember(w, r, map[string][]Invoice{"Invoices": {update}})
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]any{"Title": "Not Found", "Status": 404})
+}
+
+func (f *fakeXero) handleCreatePayment(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.replay(w, r) {
+		return
+	}
+
+	var req struct{ Payments []Payment }
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+	payment := req.Payments[0]
+	payment.PaymentID = fmt.Sprintf("xero-payment-%d", len(f.payments)+1)
+	payment.Status = "AUTHORISED"
+	f.payments = append(f.payments, payment)
+	f.remember(w, r, map[string][]Payment{"Payments": {payment}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func testInvoice() Invoice {
+	return Invoice{
+		Type:            InvoiceTypeReceivable,
+		Contact:         Contact{Name: "Alex Owner", EmailAddress: "alex@example.com"},
+		InvoiceNumber:   "YL-2026-001",
+		Date:            NewDate(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)),
+		DueDate:         NewDate(time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)),
+		Status:          InvoiceStatusAuthorised,
+		LineAmountTypes: LineAmountsInclusive,
+		LineItems:       []LineItem{{Description: "Berthing levy", Quantity: 1, UnitAmount: 1250.5, AccountCode: "200"}},
+	}
+}
+
+// TestExchange tests connecting an organisation with an authorization code
+func TestExchange(t *testing.T) {
+	fake := newFakeXero(t)
+	store := NewMemoryTokenStore(nil)
+	client := fake.client(store)
+
+	authURL := client.AuthCodeURL("state-123")
+	assert.True(t, strings.HasPrefix(authURL, fake.server.URL+"/authorize?"))
+	assert.Contains(t, authURL, "state=state-123")
+	assert.Contains(t, authURL, "offline_access")
+
+	_, err := client.Exchange(context.Background(), "bad-code")
+	require.Error(t, err)
+	_, err = store.Load(context.Background())
+	assert.ErrorIs(t, err, ErrNotConnected)
+
+	token, err := client.Exchange(context.Background(), "good-code")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-1", token.TenantID)
+	assert.Equal(t, "Sea Breeze Syndicate", token.TenantName)
+
+	stored, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, token.AccessToken, stored.AccessToken)
+	assert.True(t, stored.Valid(time.Now()))
+}
+
+// TestCreateInvoice tests pushing an invoice, including a retried push
+func TestCreateInvoice(t *testing.T) {
+	fake := newFakeXero(t)
+	client := fake.client(NewMemoryTokenStore(fake.grant()))
+	ctx := context.Background()
+
+	created, err := client.CreateInvoice(ctx, testInvoice(), "invoice-1")
+	require.NoError(t, err)
+	assert.Equal(t, "xero-invoice-1", created.InvoiceID)
+	assert.Equal(t, "YL-2026-001", created.InvoiceNumber)
+	assert.Equal(t, "2026-03-31", created.DueDate.Format("2006-01-02"))
+
+	// A retry after a lost response must not create a second invoice
+	again, err := client.CreateInvoice(ctx, testInvoice(), "invoice-1")
+	require.NoError(t, err)
+	assert.Equal(t, created.InvoiceID, again.InvoiceID)
+	assert.Len(t, fake.invoices, 1)
+
+	payment, err := client.CreatePayment(ctx, Payment{
+		Invoice: PaymentInvoice{InvoiceID: created.InvoiceID},
+		Account: Account{Code: "090"},
+		Date:    NewDate(time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)),
+		Amount:  1250.5,
+	}, "payment-1")
+	require.NoError(t, err)
+	assert.Equal(t, "xero-payment-1", payment.PaymentID)
+	assert.Equal(t, 1250.5, payment.Amount)
+}
+
+// TestUpdateInvoice tests replacing an invoice's lines, which Xero refuses
+// once a payment is allocated to it
+func TestUpdateInvoice(t *testing.T) {
+	fake := newFakeXero(t)
+	client := fake.client(NewMemoryTokenStore(fake.grant()))
+	ctx := context.Background()
+
+	created, err := client.CreateInvoice(ctx, testInvoice(), "invoice-1")
+	require.NoError(t, err)
+
+	_, err = client.UpdateInvoice(ctx, testInvoice(), "")
+	require.Error(t, err)
+
+	update := testInvoice()
+	update.InvoiceID = created.InvoiceID
+	update.LineItems = append(update.LineItems, LineItem{Description: "Late fee", Quantity: 1, UnitAmount: 50, AccountCode: "200"})
+	updated, err := client.UpdateInvoice(ctx, update, "invoice-1-update")
+	require.NoError(t, err)
+	assert.Equal(t, created.InvoiceID, updated.InvoiceID)
+	assert.Len(t, updated.LineItems, 2)
+	require.Len(t, fake.invoices, 1)
+	assert.Len(t, fake.invoices[0].LineItems, 2)
+
+	_, err = client.CreatePayment(ctx, Payment{
+		Invoice: PaymentInvoice{InvoiceID: created.InvoiceID},
+		Account: Account{Code: "090"},
+		Amount:  100,
+	}, "payment-1")
+	require.NoError(t, err)
+
+	_, err = client.UpdateInvoice(ctx, update, "invoice-1-update-2")
+	require.Error(t, err)
+	assert.True(t, IsValidationError(err))
+	assert.Contains(t, err.Error(), "payment or credit note allocated")
+}
+
+// TestCreateInvoiceValidationError tests that rejected records are
+// reported as validation errors with Xero's messages
+func TestCreateInvoiceValidationError(t *testing.T) {
+	fake := newFakeXero(t)
+	client := fake.client(NewMemoryTokenStore(fake.grant()))
+
+	inv := testInvoice()
+	inv.Contact = Contact{}
+	_, err := client.CreateInvoice(context.Background(), inv, "invoice-2")
+	require.Error(t, err)
+	assert.True(t, IsValidationError(err))
+	assert.Contains(t, err.Error(), "A Contact must be specified")
+
+	// Failures of the request itself are not validation errors
+	token := fake.grant()
+	token.TenantID = "tenant-2"
+	_, err = fake.client(NewMemoryTokenStore(token)).CreateInvoice(context.Background(), testInvoice(), "")
+	require.Error(t, err)
+	assert.False(t, IsValidationError(err))
+	assert.Contains(t, err.Error(), "403")
+}
+
+// TestTokenRefresh tests refreshing expired and rejected access tokens
+func TestTokenRefresh(t *testing.T) {
+	fake := newFakeXero(t)
+	ctx := context.Background()
+
+	t.Run("expired", func(t *testing.T) {
+		token := fake.grant()
+		token.Expiry = time.Now().Add(-time.Minute)
+		store := NewMemoryTokenStore(token)
+
+		_, err := fake.client(store).Invoices(ctx, time.Time{}, 1)
+		require.NoError(t, err)
+
+		stored, err := store.Load(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, token.AccessToken, stored.AccessToken)
+		assert.NotEqual(t, token.RefreshToken, stored.RefreshToken, "rotated refresh token is stored")
+		assert.Equal(t, "tenant-1", stored.TenantID)
+		assert.True(t, stored.Valid(time.Now()))
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		token := fake.grant()
+		store := NewMemoryTokenStore(token)
+		client := fake.client(store)
+		fake.revokeAccessTokens()
+
+		_, err := client.Invoices(ctx, time.Time{}, 1)
+		require.NoError(t, err)
+
+		stored, err := store.Load(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, token.AccessToken, stored.AccessToken)
+	})
+
+	t.Run("already refreshed elsewhere", func(t *testing.T) {
+		stale := fake.grant()
+		stale.Expiry = time.Now().Add(-time.Minute)
+		store := NewMemoryTokenStore(fake.grant())
+		before := fake.tokenRequests
+
+		token, err := fake.client(store).refresh(ctx, stale)
+		require.NoError(t, err)
+		assert.Equal(t, before, fake.tokenRequests, "the stored token is still valid")
+		assert.NotEqual(t, stale.AccessToken, token.AccessToken)
+	})
+
+	t.Run("revoked grant", func(t *testing.T) {
+		token := &Token{AccessToken: "gone", RefreshToken: "gone", Expiry: time.Now().Add(-time.Minute), TenantID: "tenant-1"}
+		_, err := fake.client(NewMemoryTokenStore(token)).Invoices(ctx, time.Time{}, 1)
+		assert.ErrorIs(t, err, ErrNotConnected)
+	})
+
+	t.Run("not connected", func(t *testing.T) {
+		_, err := fake.client(NewMemoryTokenStore(nil)).Invoices(ctx, time.Time{}, 1)
+		assert.ErrorIs(t, err, ErrNotConnected)
+	})
+}
+
+// TestInvoices tests pulling invoices changed since a time
+func TestInvoices(t *testing.T) {
+	fake := newFakeXero(t)
+	client := fake.client(NewMemoryTokenStore(fake.grant()))
+	ctx := context.Background()
+
+	_, err := client.CreateInvoice(ctx, testInvoice(), "")
+	require.NoError(t, err)
+
+	since := time.Date(2026, time.March, 1, 9, 30, 0, 0, time.FixedZone("AEST", 10*60*60))
+	invoices, err := client.Invoices(ctx, since, 1)
+	require.NoError(t, err)
+	require.Len(t, invoices, 1)
+	assert.Equal(t, "xero-invoice-1", invoices[0].InvoiceID)
+
+	invoices, err = client.Invoices(ctx, time.Time{}, 2)
+	require.NoError(t, err)
+	assert.Empty(t, invoices)
+
+	assert.Equal(t, []string{"2026-02-28T23:30:00", ""}, fake.modifiedSince)
+
+	inv, err := client.Invoice(ctx, "xero-invoice-1")
+	require.NoError(t, err)
+	assert.Equal(t, "YL-2026-001", inv.InvoiceNumber)
+
+	_, err = client.Invoice(ctx, "xero-invoice-9")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestDateJSON tests decoding the date formats Xero returns
+func TestDateJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected time.Time
+	}{
+		{"Microsoft date", `"/Date(1772323200000+0000)/"`, time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"Microsoft date with offset", `"/Date(1772362800000+1000)/"`, time.Date(2026, time.March, 1, 11, 0, 0, 0, time.UTC)},
+		{"Date time", `"2026-03-01T11:00:00"`, time.Date(2026, time.March, 1, 11, 0, 0, 0, time.UTC)},
+		{"Date", `"2026-03-01"`, time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"Null", `null`, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Date
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &d))
+			assert.True(t, tt.expected.Equal(d.Time), "got %v", d.Time)
+		})
+	}
+
+	var d Date
+	assert.Error(t, json.Unmarshal([]byte(`"1 March 2026"`), &d))
+
+	encoded, err := json.Marshal(Payment{Date: NewDate(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC))})
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"Date":"2026-03-01"`)
+}